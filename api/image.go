@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/FiveIT/eseuri/server/server/config"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/routes"
+	"github.com/FiveIT/eseuri/server/utils"
+	"github.com/gofiber/adaptor/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+func newImage() http.Handler {
+	app := fiber.New(config.Config())
+
+	app.Use(utils.Panic)
+	app.Use(utils.Logger)
+
+	// The requests are rewritten to this function, which gets their original path.
+	app.Get("/api/works/:id/images/:image", utils.Guard.Scoped(auth.ScopeReadWorks).User(), routes.WorkImage(utils.GraphQLClient, config.Storage()))
+
+	return adaptor.FiberApp(app)
+}
+
+//nolint:gochecknoglobals
+var image = newImage()
+
+func Image(w http.ResponseWriter, r *http.Request) {
+	image.ServeHTTP(w, r)
+}
@@ -4,8 +4,10 @@ import (
 	"net/http"
 
 	"github.com/FiveIT/eseuri/server/server/config"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
 	"github.com/FiveIT/eseuri/server/server/routes"
 	"github.com/FiveIT/eseuri/server/utils"
+	"github.com/FiveIT/eseuri/server/workstatus"
 	"github.com/gofiber/adaptor/v2"
 	"github.com/gofiber/fiber/v2"
 )
@@ -15,10 +17,12 @@ func newEmail() http.Handler {
 
 	app.Use(utils.Panic)
 	app.Use(utils.Logger)
-	app.Use(utils.Auth)
-	app.Use(utils.AuthAssert)
 
-	app.Use(routes.SendEmailStatusWork())
+	// The requests are rewritten to this function, which gets their original path.
+	app.Post("/api/works/:id/notify",
+		utils.Guard.Scoped(auth.ScopeReview).Registered(workstatus.RoleTeacher),
+		utils.RateLimiter.Route("email", config.EmailLimit),
+		routes.SendEmailStatusWork(utils.GraphQLClient))
 
 	return adaptor.FiberApp(app)
 }
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/FiveIT/eseuri/server/server/config"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/routes"
+	"github.com/FiveIT/eseuri/server/utils"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/adaptor/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+func newTransition() http.Handler {
+	app := fiber.New(config.Config())
+
+	app.Use(utils.Panic)
+	app.Use(utils.Logger)
+
+	review := utils.Guard.Scoped(auth.ScopeReview)
+	teacher := review.Registered(workstatus.RoleTeacher)
+
+	// The requests are rewritten to this function, which gets their original path. The works' status
+	// can't be changed through Hasura, so the reviews, and the leases they hold, are handled here.
+	app.Post("/api/works/:id/transition", review.Registered(), routes.TransitionWork(utils.GraphQLClient))
+	app.Get("/api/works/:id/lease", teacher, routes.ReviewLease(utils.GraphQLClient))
+	app.Post("/api/works/:id/lease", teacher, routes.RenewReviewLease(utils.GraphQLClient))
+
+	return adaptor.FiberApp(app)
+}
+
+//nolint:gochecknoglobals
+var transition = newTransition()
+
+func Transition(w http.ResponseWriter, r *http.Request) {
+	transition.ServeHTTP(w, r)
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"net/http"
+	"sync"
 
 	"github.com/FiveIT/eseuri/server/server/config"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
 	"github.com/FiveIT/eseuri/server/server/routes"
 	"github.com/FiveIT/eseuri/server/utils"
 	"github.com/gofiber/adaptor/v2"
@@ -15,17 +17,26 @@ func newUpload() http.Handler {
 
 	app.Use(utils.Panic)
 	app.Use(utils.Logger)
-	app.Use(utils.Auth)
-	app.Use(utils.AuthAssert)
+	app.Use(utils.Guard.Scoped(auth.ScopeUpload).Registered())
+	app.Use(utils.RateLimiter.Route("upload", config.UploadLimit))
 
-	app.Use(routes.Upload(utils.TikaClient, utils.GraphQLClient))
+	app.Use(routes.Upload(utils.TikaClient, utils.GraphQLClient, config.Storage(), config.SpellingChecker()))
 
 	return adaptor.FiberApp(app)
 }
 
+// The handler is created on the first request, so that only this function loads the spelling dictionary.
+//
 //nolint:gochecknoglobals
-var upload = newUpload()
+var (
+	upload     http.Handler
+	uploadOnce sync.Once
+)
 
 func Upload(w http.ResponseWriter, r *http.Request) {
+	uploadOnce.Do(func() {
+		upload = newUpload()
+	})
+
 	upload.ServeHTTP(w, r)
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/FiveIT/eseuri/server/server/config"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
 	"github.com/FiveIT/eseuri/server/server/routes"
 	"github.com/FiveIT/eseuri/server/utils"
 	"github.com/gofiber/adaptor/v2"
@@ -15,7 +16,7 @@ func newUser() http.Handler {
 
 	app.Use(utils.Panic)
 	app.Use(utils.Logger)
-	app.Use(utils.Auth)
+	app.Use(utils.Guard.Scoped(auth.ScopeReadWorks).User())
 
 	app.Use(routes.UserInfo(utils.GraphQLClient))
 
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/issuer"
+	"github.com/rs/zerolog/log"
+)
+
+// Prints a token issued by the offline issuer, for making requests to a local instance of the service.
+// The issuer's key must be set in DEV_JWT_PRIVATE_KEY, otherwise the token is signed with a throwaway key,
+// and the service must be started with TRUST_DEV_ISSUER=1.
+func main() {
+	var (
+		user       = flag.Int("user", 1, "the ID of the user")
+		role       = flag.String("role", "student", "the role of the user")
+		registered = flag.Bool("registered", true, "whether the user completed their registration")
+		expiresIn  = flag.Duration("expires", time.Hour, "for how long the token is valid")
+		secret     = flag.Bool("secret", false, "print the Hasura JWT secret and the issuer's key instead")
+	)
+
+	flag.Parse()
+
+	iss, err := issuer.Default()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create token issuer")
+	}
+
+	if *secret {
+		jwtSecret, err := iss.JWTSecret()
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create JWT secret")
+		}
+
+		fmt.Printf("HASURA_GRAPHQL_JWT_SECRET='%s'\nDEV_JWT_PRIVATE_KEY='%s'\n", jwtSecret, iss.PrivateKeyPEM())
+
+		return
+	}
+
+	token, err := iss.Token(issuer.User{ID: *user, Role: *role, Registered: *registered}, *expiresIn)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to issue token")
+	}
+
+	fmt.Println(token)
+}
@@ -0,0 +1,18 @@
+set search_path to public;
+
+create table work_images
+(
+    id           serial primary key,
+    work_id      int  not null,
+    paragraph    int  not null check (paragraph >= 0),
+    position     int  not null check (position >= 0),
+    content_type text not null,
+    size         int  not null check (size > 0),
+    storage_key  text not null unique,
+    unique (work_id, position)
+);
+
+create index idx_work_images_work on work_images (work_id);
+
+alter table work_images
+    add constraint fk_work_images foreign key (work_id) references works (id) on delete cascade on update cascade;
@@ -0,0 +1,8 @@
+set search_path to public;
+
+drop trigger record_work_transition on works;
+drop trigger record_work_creation on works;
+drop function trigger_record_work_transition;
+drop function hasura_session_user_id;
+
+drop table work_transitions;
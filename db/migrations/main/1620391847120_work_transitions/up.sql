@@ -0,0 +1,59 @@
+set search_path to public;
+
+create table work_transitions
+(
+    id          serial primary key,
+    work_id     int       not null,
+    from_status text               default null,
+    to_status   text      not null,
+    actor_id    int                default null,
+    created_at  timestamp not null default (localtimestamp)
+);
+
+create index idx_work_transitions_work on work_transitions (work_id);
+create index idx_work_transitions_actor on work_transitions (actor_id);
+
+alter table work_transitions
+    add constraint fk_work_transitions foreign key (work_id) references works (id) on delete cascade on update cascade;
+alter table work_transitions
+    add constraint fk_from_status_work_transitions foreign key (from_status) references work_status (value) on delete restrict on update cascade;
+alter table work_transitions
+    add constraint fk_to_status_work_transitions foreign key (to_status) references work_status (value) on delete restrict on update cascade;
+alter table work_transitions
+    add constraint fk_actor_work_transitions foreign key (actor_id) references users_all (id) on delete set null on update cascade;
+
+-- the actor is the user whose ID is set in the Hasura session variables of the request
+create function hasura_session_user_id() returns int
+    stable as
+$$
+begin
+    return (nullif(current_setting('hasura.user', true), '')::json ->> 'x-hasura-user-id')::int;
+end;
+$$ language plpgsql;
+
+create function trigger_record_work_transition() returns trigger as
+$$
+declare
+    previous text := null;
+begin
+    if tg_op = 'UPDATE' then
+        previous := old.status;
+    end if;
+    insert into work_transitions (work_id, from_status, to_status, actor_id)
+    values (new.id, previous, new.status, hasura_session_user_id());
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger record_work_creation
+    after insert
+    on works
+    for each row
+execute function trigger_record_work_transition();
+
+create trigger record_work_transition
+    after update
+    on works
+    for each row
+    when (old.status is distinct from new.status)
+execute function trigger_record_work_transition();
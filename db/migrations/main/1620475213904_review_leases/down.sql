@@ -0,0 +1,10 @@
+set search_path to public;
+
+drop function expire_review_leases;
+drop function renew_review_lease;
+drop function claim_review_lease;
+
+drop trigger work_leaves_review on works;
+drop function trigger_work_leaves_review;
+
+drop table review_leases;
@@ -0,0 +1,99 @@
+set search_path to public;
+
+create table review_leases
+(
+    work_id             int primary key,
+    teacher_id          int       not null,
+    previous_teacher_id int                default null,
+    expires_at          timestamp not null,
+    created_at          timestamp not null default (localtimestamp)
+);
+
+create index idx_review_leases_teacher on review_leases (teacher_id);
+create index idx_review_leases_expires_at on review_leases (expires_at);
+
+alter table review_leases
+    add constraint fk_work_review_leases foreign key (work_id) references works (id) on delete cascade on update cascade;
+alter table review_leases
+    add constraint fk_teacher_review_leases foreign key (teacher_id) references teachers (user_id) on delete cascade on update cascade;
+
+-- when a work leaves review, its lease is released and, if it goes back to pending,
+-- the teacher requested by the author (if any) is restored
+create function trigger_work_leaves_review() returns trigger as
+$$
+begin
+    if new.status = 'pending' then
+        select previous_teacher_id from review_leases where work_id = new.id into new.teacher_id;
+    end if;
+    delete from review_leases where work_id = new.id;
+    return new;
+end;
+$$ language plpgsql;
+
+create trigger work_leaves_review
+    before update
+    on works
+    for each row
+    when (old.status = 'inReview' and new.status <> 'inReview')
+execute function trigger_work_leaves_review();
+
+-- claims the review of a pending work for the given teacher. If the work is already
+-- reviewed by someone, the existing lease is returned; if the work isn't pending
+-- anymore, nothing is returned
+create function claim_review_lease(workID int, teacherID int, ttl int) returns setof review_leases
+    volatile as
+$$
+declare
+    previous int;
+begin
+    if exists(select 1 from review_leases where work_id = workID) then
+        return query select * from review_leases where work_id = workID;
+        return;
+    end if;
+
+    update works w
+    set status     = 'inReview',
+        teacher_id = teacherID
+    from works old
+    where w.id = workID
+      and old.id = w.id
+      and w.status = 'pending'
+    returning old.teacher_id into previous;
+
+    if not found then
+        return;
+    end if;
+
+    return query insert into review_leases (work_id, teacher_id, previous_teacher_id, expires_at)
+        values (workID, teacherID, previous, localtimestamp + make_interval(secs => ttl))
+        returning *;
+end;
+$$ language plpgsql;
+
+-- extends the lease the teacher holds on the work
+create function renew_review_lease(workID int, teacherID int, ttl int) returns setof review_leases
+    volatile as
+$$
+begin
+    return query update review_leases
+        set expires_at = localtimestamp + make_interval(secs => ttl)
+        where work_id = workID
+          and teacher_id = teacherID
+          and expires_at > localtimestamp
+        returning *;
+end;
+$$ language plpgsql;
+
+-- puts the works whose review was abandoned back to pending
+create function expire_review_leases() returns setof review_leases
+    volatile as
+$$
+begin
+    return query
+        with expired as (select * from review_leases where expires_at <= localtimestamp for update),
+             updated as (update works w set status = 'pending' from expired e where w.id = e.work_id returning w.id)
+        select e.*
+        from expired e
+                 join updated u on u.id = e.work_id;
+end;
+$$ language plpgsql;
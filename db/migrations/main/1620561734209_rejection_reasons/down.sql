@@ -0,0 +1,9 @@
+set search_path to public;
+
+alter table works
+    drop constraint feedback_length,
+    drop constraint fk_rejection_reason_works,
+    drop column feedback,
+    drop column rejection_reason;
+
+drop table rejection_reason;
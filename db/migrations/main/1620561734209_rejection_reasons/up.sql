@@ -0,0 +1,23 @@
+set search_path to public;
+
+create table rejection_reason
+(
+    value   text primary key,
+    comment text default null
+);
+
+insert into rejection_reason (value, comment)
+values ('offTopic', 'The work doesn''t treat the subject it was uploaded for.'),
+       ('tooShort', 'The work is too short to properly treat its subject.'),
+       ('plagiarism', 'The work is copied, fully or partially, from another source.'),
+       ('formatting', 'The work isn''t properly formatted.'),
+       ('language', 'The work has too many spelling, grammar or style mistakes.');
+
+alter table works
+    add column rejection_reason text default null,
+    add column feedback         text default null;
+
+alter table works
+    add constraint fk_rejection_reason_works foreign key (rejection_reason) references rejection_reason (value) on delete restrict on update cascade;
+alter table works
+    add constraint feedback_length check (char_length(feedback) <= 2000);
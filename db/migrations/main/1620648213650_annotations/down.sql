@@ -0,0 +1,6 @@
+set search_path to public;
+
+drop table annotations;
+
+alter table works
+    drop column version;
@@ -0,0 +1,43 @@
+set search_path to public;
+
+-- the version of a work's content, incremented each time the author uploads a new one
+alter table works
+    add column version int not null default 1;
+
+create table annotations
+(
+    id           serial primary key,
+    work_id      int       not null,
+    user_id      int       not null,
+    version      int       not null,
+    -- rune offsets in the work's content; a position if they are equal
+    start_offset int       not null,
+    end_offset   int       not null,
+    body         text      not null,
+    -- the annotated passage was removed in a newer version of the content
+    outdated     boolean   not null default false,
+    resolved_by  int                default null,
+    resolved_at  timestamp          default null,
+    created_at   timestamp not null default (localtimestamp),
+    updated_at   timestamp          default null
+);
+
+create index idx_annotations_work on annotations (work_id);
+
+alter table annotations
+    add constraint fk_work_annotations foreign key (work_id) references works (id) on delete cascade on update cascade;
+alter table annotations
+    add constraint fk_user_annotations foreign key (user_id) references users_all (id) on delete cascade on update cascade;
+alter table annotations
+    add constraint fk_resolved_by_annotations foreign key (resolved_by) references users_all (id) on delete set null on update cascade;
+alter table annotations
+    add constraint valid_offsets check (0 <= start_offset and start_offset <= end_offset);
+alter table annotations
+    add constraint body_length check (char_length(body) between 1 and 2000);
+
+create trigger update_annotations_body
+    before update
+    on annotations
+    for each row
+    when (old.body is distinct from new.body)
+execute function trigger_set_updated_at();
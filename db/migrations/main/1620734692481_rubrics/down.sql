@@ -0,0 +1,7 @@
+set search_path to public;
+
+drop table work_grade_scores;
+drop function get_criterion_max_score;
+drop table work_grades;
+drop table rubric_criteria;
+drop table rubrics;
@@ -0,0 +1,98 @@
+set search_path to public;
+
+-- the grading template of a work type
+create table rubrics
+(
+    id        serial primary key,
+    work_type text not null unique,
+    name      text not null
+);
+
+alter table rubrics
+    add constraint fk_work_type_rubrics foreign key (work_type) references work_type (value) on delete cascade on update cascade;
+
+create table rubric_criteria
+(
+    id          serial primary key,
+    rubric_id   int  not null,
+    key         text not null,
+    name        text not null,
+    description text not null,
+    max_score   int  not null,
+    position    int  not null,
+    unique (rubric_id, key),
+    unique (rubric_id, position)
+);
+
+alter table rubric_criteria
+    add constraint fk_rubric_rubric_criteria foreign key (rubric_id) references rubrics (id) on delete cascade on update cascade;
+alter table rubric_criteria
+    add constraint positive_max_score check (max_score > 0);
+
+-- the grade a teacher gave to a work, using the rubric of its type
+create table work_grades
+(
+    work_id    int primary key,
+    rubric_id  int       not null,
+    teacher_id int                default null,
+    created_at timestamp not null default (localtimestamp),
+    updated_at timestamp          default null
+);
+
+create index idx_work_grades_teacher on work_grades (teacher_id);
+
+alter table work_grades
+    add constraint fk_work_work_grades foreign key (work_id) references works (id) on delete cascade on update cascade;
+alter table work_grades
+    add constraint fk_rubric_work_grades foreign key (rubric_id) references rubrics (id) on delete restrict on update cascade;
+alter table work_grades
+    add constraint fk_teacher_work_grades foreign key (teacher_id) references teachers (user_id) on delete set null on update cascade;
+
+create function get_criterion_max_score(criterionID int) returns int stable as
+$$
+declare
+    maxScore int;
+begin
+    select max_score from rubric_criteria where id = criterionID into maxScore;
+    return maxScore;
+end;
+$$ language plpgsql;
+
+create table work_grade_scores
+(
+    work_id      int not null,
+    criterion_id int not null,
+    score        int not null,
+    primary key (work_id, criterion_id)
+);
+
+alter table work_grade_scores
+    add constraint fk_grade_work_grade_scores foreign key (work_id) references work_grades (work_id) on delete cascade on update cascade;
+alter table work_grade_scores
+    add constraint fk_criterion_work_grade_scores foreign key (criterion_id) references rubric_criteria (id) on delete cascade on update cascade;
+alter table work_grade_scores
+    add constraint score_in_range check (score between 0 and get_criterion_max_score(criterion_id));
+
+-- the rubrics used at the Romanian language and literature BAC exam
+insert into rubrics (work_type, name)
+values ('essay', 'Eseu - Subiectul al III-lea, BAC'),
+       ('characterization', 'Caracterizare de personaj - Subiectul al III-lea, BAC');
+
+insert into rubric_criteria (rubric_id, key, name, description, max_score, position)
+select r.id, c.key, c.name, c.description, c.max_score, c.position
+from rubrics r
+         cross join (values ('argumentStructure', 'Structura argumentării',
+                             'Ideile sunt organizate logic, în introducere, cuprins și încheiere, iar fiecare idee este susținută de argumente.',
+                             6, 1),
+                            ('literaryConcepts', 'Utilizarea conceptelor operaționale',
+                             'Conceptele de teorie literară (temă, viziune, perspectivă narativă, construcția personajului etc.) sunt folosite corect și adecvat.',
+                             6, 2),
+                            ('quotations', 'Citate și exemple',
+                             'Afirmațiile sunt susținute prin citate și secvențe relevante din opera literară.',
+                             6, 3),
+                            ('spellingPunctuation', 'Ortografie și punctuație',
+                             'Sunt respectate normele ortografice și de punctuație.',
+                             2, 4),
+                            ('wordCount', 'Numărul minim de cuvinte',
+                             'Lucrarea are cel puțin 400 de cuvinte.',
+                             1, 5)) as c (key, name, description, max_score, position);
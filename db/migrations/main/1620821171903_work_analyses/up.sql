@@ -0,0 +1,16 @@
+set search_path to public;
+
+-- the analysis reports of the works' content, computed by the server
+create table work_analyses
+(
+    work_id          int primary key,
+    -- the version of the work's content that was analyzed
+    version          int       not null,
+    -- the version of the heuristics used to compute the report
+    analyzer_version int       not null,
+    report           jsonb     not null,
+    created_at       timestamp not null default (localtimestamp)
+);
+
+alter table work_analyses
+    add constraint fk_work_work_analyses foreign key (work_id) references works (id) on delete cascade on update cascade;
@@ -0,0 +1,4 @@
+set search_path to public;
+
+alter table works
+    drop column spelling_issues;
@@ -0,0 +1,7 @@
+set search_path to public;
+
+-- the number of spelling issues found in the work's content, if the spelling checker is enabled
+alter table works
+    add column spelling_issues int default null;
+alter table works
+    add constraint non_negative_spelling_issues check (spelling_issues >= 0);
@@ -0,0 +1,3 @@
+set search_path to public;
+
+drop table work_abstracts;
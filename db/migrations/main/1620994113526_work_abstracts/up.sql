@@ -0,0 +1,19 @@
+set search_path to public;
+
+-- the extractive summaries and keywords of the approved works, computed by the server
+create table work_abstracts
+(
+    work_id            int primary key,
+    -- the version of the work's content that was summarized
+    version            int       not null,
+    -- the version of the algorithm used to compute the summary
+    summarizer_version int       not null,
+    -- the summary's sentences, in the order they appear in the work
+    sentences          jsonb     not null,
+    -- the work's keywords, the most important first
+    keywords           jsonb     not null,
+    created_at         timestamp not null default (localtimestamp)
+);
+
+alter table work_abstracts
+    add constraint fk_work_work_abstracts foreign key (work_id) references works (id) on delete cascade on update cascade;
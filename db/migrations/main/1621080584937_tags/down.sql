@@ -0,0 +1,5 @@
+set search_path to public;
+
+drop view title_tag_counts;
+drop table work_tags;
+drop table tags;
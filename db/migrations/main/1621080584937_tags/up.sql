@@ -0,0 +1,51 @@
+set search_path to public;
+
+-- the controlled vocabulary of the works' themes, managed by the administrators
+create table tags
+(
+    id         serial primary key,
+    name       text      not null unique,
+    created_at timestamp not null default (localtimestamp)
+);
+
+-- the tags given to the works by teachers
+create table work_tags
+(
+    work_id    int       not null,
+    tag_id     int       not null,
+    -- the teacher that tagged the work
+    teacher_id int,
+    created_at timestamp not null default (localtimestamp),
+    primary key (work_id, tag_id)
+);
+
+alter table work_tags
+    add constraint fk_work_work_tags foreign key (work_id) references works (id) on delete cascade on update cascade;
+alter table work_tags
+    add constraint fk_tag_work_tags foreign key (tag_id) references tags (id) on delete cascade on update cascade;
+alter table work_tags
+    add constraint fk_teacher_work_tags foreign key (teacher_id) references teachers (user_id) on delete set null on update cascade;
+
+create index work_tags_tag_id_index on work_tags (tag_id);
+
+-- the number of approved works about each title that have each tag,
+-- counting both the essays on the title and the characterizations of its characters
+create view title_tag_counts as
+select title_id, tag_id, name, count(work_id) work_count
+from (
+         select e.title_id, wt.tag_id, t.name, wt.work_id
+         from work_tags wt
+                  join tags t on t.id = wt.tag_id
+                  join works w on w.id = wt.work_id
+                  join essays e on e.work_id = wt.work_id
+         where w.status = 'approved'
+         union all
+         select c.title_id, wt.tag_id, t.name, wt.work_id
+         from work_tags wt
+                  join tags t on t.id = wt.tag_id
+                  join works w on w.id = wt.work_id
+                  join characterizations c2 on c2.work_id = wt.work_id
+                  join characters c on c.id = c2.character_id
+         where w.status = 'approved'
+     ) as q
+group by title_id, tag_id, name;
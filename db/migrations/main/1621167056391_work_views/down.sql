@@ -0,0 +1,7 @@
+set search_path to public;
+
+drop view title_view_stats;
+drop view work_view_stats;
+drop function record_work_view;
+drop table work_view_days;
+drop table work_views;
@@ -0,0 +1,102 @@
+set search_path to public;
+
+-- the views of the approved works in the current windows, deduplicated per visitor.
+-- visitors are keyed hashes that change every window, and are deleted when their window ends
+create table work_views
+(
+    work_id      int       not null,
+    visitor      text      not null,
+    window_start timestamp not null,
+    completion   real      not null default 0,
+    primary key (work_id, visitor)
+);
+
+alter table work_views
+    add constraint fk_work_work_views foreign key (work_id) references works (id) on delete cascade on update cascade;
+alter table work_views
+    add constraint completion_fraction check (completion between 0 and 1);
+
+create index work_views_window_start_index on work_views (window_start);
+
+-- the anonymous number of views and reads of each work, per day
+create table work_view_days
+(
+    work_id        int  not null,
+    day            date not null,
+    views          int  not null default 0,
+    reads          int  not null default 0,
+    -- the sum of the completions of the views, used to compute their average
+    completion_sum real not null default 0,
+    primary key (work_id, day)
+);
+
+alter table work_view_days
+    add constraint fk_work_work_view_days foreign key (work_id) references works (id) on delete cascade on update cascade;
+
+create index work_view_days_day_index on work_view_days (day);
+
+-- records a view of a work, or updates its completion if the visitor already viewed the work in the window.
+-- the view is counted on the day its window starts, and it becomes a read when its completion reaches the threshold
+create function record_work_view(workid int, visitorid text, windowstart timestamp, newcompletion real,
+                                 readthreshold real) returns setof work_view_days
+    volatile as
+$$
+declare
+    previous real;
+begin
+    insert into work_views (work_id, visitor, window_start)
+    values (workid, visitorid, windowstart)
+    on conflict do nothing;
+
+    if found then
+        insert into work_view_days (work_id, day, views)
+        values (workid, windowstart::date, 1)
+        on conflict (work_id, day) do update set views = work_view_days.views + 1;
+    end if;
+
+    select completion into previous from work_views where work_id = workid and visitor = visitorid for update;
+
+    if newcompletion > previous then
+        update work_views set completion = newcompletion where work_id = workid and visitor = visitorid;
+
+        update work_view_days
+        set completion_sum = completion_sum + (newcompletion - previous),
+            reads          = reads + (case when previous < readthreshold and newcompletion >= readthreshold then 1 else 0 end)
+        where work_id = workid
+          and day = windowstart::date;
+    end if;
+
+    return query select * from work_view_days where work_id = workid and day = windowstart::date;
+end;
+$$ language plpgsql;
+
+-- the views and reads of each work, in total and in the last 7 days
+create view work_view_stats as
+select d.work_id,
+       sum(d.views)                                             views,
+       sum(d.reads)                                             reads,
+       coalesce(sum(d.views) filter (where d.day > current_date - 7), 0) week_views,
+       coalesce(sum(d.reads) filter (where d.day > current_date - 7), 0) week_reads,
+       sum(d.completion_sum) / greatest(sum(d.views), 1)        average_completion
+from work_view_days d
+group by d.work_id;
+
+-- the views and reads of the works about each title, counting both the essays
+-- on the title and the characterizations of its characters
+create view title_view_stats as
+select title_id,
+       sum(views)      views,
+       sum(reads)      reads,
+       sum(week_views) week_views,
+       sum(week_reads) week_reads
+from (
+         select e.title_id, s.views, s.reads, s.week_views, s.week_reads
+         from work_view_stats s
+                  join essays e on e.work_id = s.work_id
+         union all
+         select c.title_id, s.views, s.reads, s.week_views, s.week_reads
+         from work_view_stats s
+                  join characterizations c2 on c2.work_id = s.work_id
+                  join characters c on c.id = c2.character_id
+     ) as q
+group by title_id;
@@ -0,0 +1,26 @@
+set search_path to public;
+
+-- the works each user opened recently, with where they stopped reading, so the reader can resume.
+-- the position is the paragraph of the work's version the user read, and how far they scrolled, as a fraction
+create table reading_history
+(
+    user_id    int       not null,
+    work_id    int       not null,
+    version    int       not null,
+    paragraph  int       not null default 0,
+    progress   real      not null default 0,
+    opened_at  timestamp not null default (localtimestamp),
+    updated_at timestamp not null default (localtimestamp),
+    primary key (user_id, work_id)
+);
+
+alter table reading_history
+    add constraint fk_user_reading_history foreign key (user_id) references users_all (id) on delete cascade on update cascade;
+alter table reading_history
+    add constraint fk_work_reading_history foreign key (work_id) references works (id) on delete cascade on update cascade;
+alter table reading_history
+    add constraint paragraph_positive check (paragraph >= 0);
+alter table reading_history
+    add constraint progress_fraction check (progress between 0 and 1);
+
+create index reading_history_user_id_updated_at_index on reading_history (user_id, updated_at desc);
@@ -0,0 +1,29 @@
+set search_path to public;
+
+-- the personal access tokens the users create for their scripts. only the hashes of the tokens are stored,
+-- and their first characters are kept so the users can tell them apart
+create table access_tokens
+(
+    id           serial primary key,
+    user_id      int       not null,
+    name         text      not null,
+    prefix       text      not null,
+    hash         text      not null
+        constraint access_tokens_hash_key unique,
+    scopes       text[]    not null,
+    created_at   timestamp not null default (localtimestamp),
+    expires_at   timestamp not null,
+    last_used_at timestamp default null,
+    revoked_at   timestamp default null
+);
+
+alter table access_tokens
+    add constraint fk_user_access_token foreign key (user_id) references users_all (id) on delete cascade on update cascade;
+alter table access_tokens
+    add constraint access_token_name_not_empty check (name <> '');
+alter table access_tokens
+    add constraint access_token_scopes_known check (cardinality(scopes) > 0 and scopes <@ array ['read-works', 'review', 'upload']);
+alter table access_tokens
+    add constraint access_token_expires_after_creation check (expires_at > created_at);
+
+create index access_tokens_user_id_index on access_tokens (user_id);
@@ -0,0 +1,8 @@
+set search_path to public;
+
+drop trigger delete_teacher_revoke_tokens on teachers;
+drop trigger delete_student_revoke_tokens on students;
+drop function trigger_revoke_user_tokens();
+
+alter table users_all
+    drop column tokens_valid_after;
@@ -0,0 +1,26 @@
+set search_path to public;
+
+-- the tokens of a user that were issued before this time are revoked. it's updated when the user
+-- loses their role, which happens when they delete their account or when their role changes
+alter table users_all
+    add column tokens_valid_after timestamptz default null;
+
+create function trigger_revoke_user_tokens() returns trigger as
+$$
+begin
+    update users_all set tokens_valid_after = now() where id = old.user_id;
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger delete_student_revoke_tokens
+    after delete
+    on students
+    for each row
+execute function trigger_revoke_user_tokens();
+
+create trigger delete_teacher_revoke_tokens
+    after delete
+    on teachers
+    for each row
+execute function trigger_revoke_user_tokens();
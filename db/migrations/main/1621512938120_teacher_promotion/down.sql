@@ -0,0 +1,4 @@
+set search_path to public;
+
+drop trigger approve_teacher_request_promote_teacher on teacher_requests;
+drop function trigger_promote_teacher();
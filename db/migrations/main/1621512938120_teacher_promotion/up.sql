@@ -0,0 +1,19 @@
+set search_path to public;
+
+-- approving a teacher request makes its initiator a teacher. removing the student
+-- revokes their tokens, so they log in again with the teacher role
+create function trigger_promote_teacher() returns trigger as
+$$
+begin
+    delete from students where user_id = new.user_id;
+    insert into teachers (user_id) values (new.user_id) on conflict do nothing;
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger approve_teacher_request_promote_teacher
+    after update
+    on teacher_requests
+    for each row
+    when (old.status is distinct from new.status and new.status = 'approved')
+execute function trigger_promote_teacher();
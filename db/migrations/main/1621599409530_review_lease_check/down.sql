@@ -0,0 +1,4 @@
+set search_path to public;
+
+drop trigger check_review_lease on works;
+drop function trigger_check_review_lease;
@@ -0,0 +1,26 @@
+set search_path to public;
+
+-- a work can be approved or rejected only by the teacher that holds its review lease,
+-- while the lease hasn't expired. The check is done in the same statement as the
+-- transition, so a lease can't expire between checking and updating the work
+create function trigger_check_review_lease() returns trigger as
+$$
+begin
+    if not exists(select 1
+                  from review_leases
+                  where work_id = new.id
+                    and teacher_id = old.teacher_id
+                    and expires_at > localtimestamp) then
+        raise exception 'recenzia lucrării a expirat';
+    end if;
+    return new;
+end;
+$$ language plpgsql;
+
+-- triggers fire in alphabetical order, so this runs before work_leaves_review releases the lease
+create trigger check_review_lease
+    before update
+    on works
+    for each row
+    when (old.status = 'inReview' and new.status in ('approved', 'rejected'))
+execute function trigger_check_review_lease();
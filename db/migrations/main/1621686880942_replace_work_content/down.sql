@@ -0,0 +1,3 @@
+set search_path to public;
+
+drop function replace_work_content;
@@ -0,0 +1,37 @@
+set search_path to public;
+
+-- replaces the content of the work, if it is still at the expected version, together with its images, and
+-- moves its annotations onto the new version. If the content was changed in the meantime an exception
+-- is raised, so nothing is replaced. The removed images are returned, so their files can be deleted
+create function replace_work_content(workID int, expectedVersion int, newContent text, spellingIssues int,
+                                     newImages jsonb, newAnnotations jsonb) returns setof work_images
+    volatile as
+$$
+begin
+    update works
+    set content         = newContent,
+        spelling_issues = spellingIssues,
+        version         = version + 1
+    where id = workID
+      and version = expectedVersion;
+
+    if not found then
+        raise exception 'conținutul lucrării s-a schimbat între timp';
+    end if;
+
+    return query delete from work_images where work_id = workID returning *;
+
+    insert into work_images (work_id, paragraph, "position", content_type, size, storage_key)
+    select workID, i.paragraph, i."position", i.content_type, i.size, i.storage_key
+    from jsonb_to_recordset(newImages) as i(paragraph int, "position" int, content_type text, size int, storage_key text);
+
+    update annotations a
+    set version      = n.version,
+        start_offset = n.start_offset,
+        end_offset   = n.end_offset,
+        outdated     = n.outdated
+    from jsonb_to_recordset(newAnnotations) as n(id int, version int, start_offset int, end_offset int, outdated boolean)
+    where a.id = n.id
+      and a.work_id = workID;
+end;
+$$ language plpgsql;
@@ -0,0 +1,11 @@
+set search_path to public;
+
+drop function search_works;
+drop table work_search_results;
+drop view work_search_documents;
+
+drop index idx_works_search_content;
+alter table works
+    drop column search_content;
+
+drop text search configuration romanian_unaccent;
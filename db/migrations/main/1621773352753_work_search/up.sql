@@ -0,0 +1,71 @@
+set search_path to public;
+
+create extension if not exists unaccent;
+
+-- works are searched by the stems of their words, without diacritics, so that searching
+-- for "pământul" finds works that contain "pamantului"
+create text search configuration romanian_unaccent (copy = romanian);
+alter text search configuration romanian_unaccent
+    alter mapping for hword, hword_part, word with unaccent, romanian_stem;
+
+alter table works
+    add column search_content tsvector generated always as (to_tsvector('romanian_unaccent', content)) stored;
+
+create index idx_works_search_content on works using gin (search_content);
+
+-- the approved works, as they are searched. Matches in the work's subject (its title
+-- and character name) weigh more than matches in its content
+create view work_search_documents as
+select w.id                                                                    as work_id,
+       case when e.work_id is not null then 'essay' else 'characterization' end as type,
+       t.id                                                                    as title_id,
+       ch.id                                                                   as character_id,
+       u.school_id                                                             as school_id,
+       w.teacher_id                                                            as teacher_id,
+       w.content                                                               as content,
+       setweight(to_tsvector('romanian_unaccent', coalesce(t.name, '') || ' ' || coalesce(ch.name, '')), 'A') ||
+       setweight(w.search_content, 'D')                                        as document
+from works w
+         left join essays e on e.work_id = w.id
+         left join characterizations c on c.work_id = w.id
+         left join characters ch on ch.id = c.character_id
+         left join titles t on t.id = coalesce(e.title_id, ch.title_id)
+         left join users u on u.id = w.user_id
+where w.status = 'approved'
+  and (e.work_id is not null or c.work_id is not null);
+
+-- the works found by the search functions, with their relevance and the fragments of their content
+-- that match the search. Nothing is stored in it, it is the type of the functions' results
+create table work_search_results
+(
+    work_id  int primary key,
+    score    real not null,
+    headline text
+);
+
+-- finds the approved works that contain all the words of the text and match the filters that aren't null.
+-- If the text has no words, all the works that match the filters are found, with a score of 0 and no headline.
+-- The headline's fragments are separated by U+E002, and its matches are enclosed by U+E000 and U+E001
+create function search_works(search text default '', worktype text default null, titleid int default null,
+                             characterid int default null, schoolid int default null, teacherid int default null,
+                             tagid int default null) returns setof work_search_results
+    stable as
+$$
+select d.work_id,
+       (case when numnode(q.terms) = 0 then 0 else ts_rank_cd(d.document, q.terms) end)::real,
+       case
+           when numnode(q.terms) <> 0 then ts_headline('romanian_unaccent', d.content, q.terms,
+                                                       'MaxFragments=3, MaxWords=25, MinWords=10, StartSel="' ||
+                                                       chr(57344) || '", StopSel="' || chr(57345) ||
+                                                       '", FragmentDelimiter="' || chr(57346) || '"')
+           end
+from work_search_documents d,
+     (select websearch_to_tsquery('romanian_unaccent', coalesce(search, '')) as terms) q
+where (numnode(q.terms) = 0 or d.document @@ q.terms)
+  and (worktype is null or d.type = worktype)
+  and (titleid is null or d.title_id = titleid)
+  and (characterid is null or d.character_id = characterid)
+  and (schoolid is null or d.school_id = schoolid)
+  and (teacherid is null or d.teacher_id = teacherid)
+  and (tagid is null or exists(select 1 from work_tags wt where wt.work_id = d.work_id and wt.tag_id = tagid));
+$$ language sql;
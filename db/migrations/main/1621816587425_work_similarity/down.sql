@@ -0,0 +1,6 @@
+set search_path to public;
+
+drop function similar_works;
+drop trigger update_work_terms on works;
+drop function trigger_update_work_terms;
+drop table work_terms;
@@ -0,0 +1,94 @@
+set search_path to public;
+
+-- the terms of the approved works' contents and how many times they occur in each work. They are
+-- updated with the works, so only the works that were approved or changed are analyzed again
+create table work_terms
+(
+    work_id   int  not null,
+    term      text not null,
+    frequency int  not null,
+    primary key (work_id, term)
+);
+
+create index idx_work_terms_term on work_terms (term);
+
+alter table work_terms
+    add constraint fk_work_terms foreign key (work_id) references works (id) on delete cascade on update cascade;
+
+create function trigger_update_work_terms() returns trigger as
+$$
+begin
+    delete from work_terms where work_id = new.id;
+
+    if new.status = 'approved' then
+        insert into work_terms (work_id, term, frequency)
+        select new.id, t.lexeme, coalesce(array_length(t.positions, 1), 1)
+        from unnest(new.search_content) t;
+    end if;
+
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger update_work_terms
+    after insert or update of status, content
+    on works
+    for each row
+execute procedure trigger_update_work_terms();
+
+insert into work_terms (work_id, term, frequency)
+select w.id, t.lexeme, coalesce(array_length(t.positions, 1), 1)
+from works w,
+     unnest(w.search_content) t
+where w.status = 'approved';
+
+-- finds the approved works similar to the given one, which doesn't have to be approved, the most similar
+-- first. The works are compared by the cosine similarity of the TF-IDF vectors of their contents, where
+-- the weight of a term is (1 + ln(frequency)) * (ln((1 + works) / (1 + works with the term)) + 1).
+-- The works about the same title or character as the given one are preferred
+create function similar_works(workid int) returns setof work_search_results
+    stable as
+$$
+with corpus as (select count(distinct work_id) as works from work_terms),
+     documents as (select term, count(*) as works from work_terms group by term),
+     weights as (select t.work_id,
+                        t.term,
+                        (1 + ln(t.frequency::float8)) * (ln((1 + c.works)::float8 / (1 + d.works)) + 1) as weight
+                 from work_terms t
+                          join documents d on d.term = t.term,
+                      corpus c),
+     source as (select s.lexeme                                                                    as term,
+                       (1 + ln(coalesce(array_length(s.positions, 1), 1)::float8)) *
+                       (ln((1 + c.works)::float8 / (1 + coalesce(d.works, 0))) + 1) as weight
+                from works w
+                         cross join unnest(w.search_content) s
+                         left join documents d on d.term = s.lexeme
+                         cross join corpus c
+                where w.id = workid),
+     subject as (select coalesce(e.title_id, ch.title_id) as title_id, c.character_id
+                 from works w
+                          left join essays e on e.work_id = w.id
+                          left join characterizations c on c.work_id = w.id
+                          left join characters ch on ch.id = c.character_id
+                 where w.id = workid),
+     dots as (select w.work_id, sum(w.weight * s.weight) as dot
+              from weights w
+                       join source s on s.term = w.term
+              where w.work_id <> workid
+              group by w.work_id),
+     norms as (select work_id, sqrt(sum(weight * weight)) as norm
+               from weights
+               where work_id in (select work_id from dots)
+               group by work_id)
+select d.work_id,
+       (d.dot / (n.norm * (select sqrt(sum(weight * weight)) from source))
+           + case when sd.title_id = sub.title_id then 0.15 else 0 end
+           + case when sd.character_id = sub.character_id then 0.15 else 0 end)::real,
+       null::text
+from dots d
+         join norms n on n.work_id = d.work_id
+         join work_search_documents sd on sd.work_id = d.work_id,
+     subject sub
+where n.norm <> 0
+  and (select sum(weight * weight) from source) <> 0;
+$$ language sql;
@@ -0,0 +1,22 @@
+set search_path to public;
+
+-- returns the valid access token with the given hash and records that it was used. The time it was
+-- last used is updated at most once a minute, so that authenticating doesn't write on every request
+create function authenticate_access_token(tokenhash text) returns setof access_tokens
+    volatile as
+$$
+begin
+    update access_tokens
+    set last_used_at = localtimestamp
+    where hash = tokenhash
+      and revoked_at is null
+      and expires_at > localtimestamp
+      and (last_used_at is null or last_used_at < localtimestamp - interval '1 minute');
+
+    return query select *
+                 from access_tokens
+                 where hash = tokenhash
+                   and revoked_at is null
+                   and expires_at > localtimestamp;
+end;
+$$ language plpgsql;
@@ -0,0 +1,31 @@
+set search_path to public;
+
+create or replace function claim_review_lease(workID int, teacherID int, ttl int) returns setof review_leases
+    volatile as
+$$
+declare
+    previous int;
+begin
+    if exists(select 1 from review_leases where work_id = workID) then
+        return query select * from review_leases where work_id = workID;
+        return;
+    end if;
+
+    update works w
+    set status     = 'inReview',
+        teacher_id = teacherID
+    from works old
+    where w.id = workID
+      and old.id = w.id
+      and w.status = 'pending'
+    returning old.teacher_id into previous;
+
+    if not found then
+        return;
+    end if;
+
+    return query insert into review_leases (work_id, teacher_id, previous_teacher_id, expires_at)
+        values (workID, teacherID, previous, localtimestamp + make_interval(secs => ttl))
+        returning *;
+end;
+$$ language plpgsql;
@@ -0,0 +1,52 @@
+set search_path to public;
+
+-- claims the review of a pending work for the given teacher. If the work is already
+-- reviewed by someone, the existing lease is returned, unless it expired, in which case
+-- the teacher takes it over. If the work isn't pending anymore, the lease of whoever
+-- claimed it first is returned, or nothing if the work isn't in review
+create or replace function claim_review_lease(workID int, teacherID int, ttl int) returns setof review_leases
+    volatile as
+$$
+declare
+    lease    review_leases;
+    previous int;
+begin
+    select * into lease from review_leases where work_id = workID for update;
+
+    if found and lease.expires_at > localtimestamp then
+        return next lease;
+        return;
+    end if;
+
+    if found then
+        update works set teacher_id = teacherID where id = workID;
+
+        return query update review_leases
+            set teacher_id = teacherID,
+                expires_at = localtimestamp + make_interval(secs => ttl),
+                created_at = localtimestamp
+            where work_id = workID
+            returning *;
+        return;
+    end if;
+
+    update works w
+    set status     = 'inReview',
+        teacher_id = teacherID
+    from works old
+    where w.id = workID
+      and old.id = w.id
+      and w.status = 'pending'
+    returning old.teacher_id into previous;
+
+    if not found then
+        -- another teacher claimed the work first, in a transaction that ended after this one's lookup
+        return query select * from review_leases where work_id = workID;
+        return;
+    end if;
+
+    return query insert into review_leases (work_id, teacher_id, previous_teacher_id, expires_at)
+        values (workID, teacherID, previous, localtimestamp + make_interval(secs => ttl))
+        returning *;
+end;
+$$ language plpgsql;
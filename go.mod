@@ -8,7 +8,6 @@ require (
 	github.com/form3tech-oss/jwt-go v3.2.2+incompatible
 	github.com/gofiber/adaptor/v2 v2.1.3
 	github.com/gofiber/fiber/v2 v2.9.0
-	github.com/gofiber/jwt/v2 v2.2.1
 	github.com/google/go-tika v0.1.21
 	github.com/machinebox/graphql v0.2.2
 	github.com/rs/zerolog v1.20.0
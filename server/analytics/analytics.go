@@ -0,0 +1,87 @@
+/*
+Package analytics measures how much the approved works are read, without tracking the readers.
+
+A view is counted once for every visitor, work and window. Visitors are identified by keyed hashes
+of their user ID or IP address, the work and the window, so the hashes are useless to anyone that
+doesn't know the key, and the views of the same visitor can't be linked across works or windows.
+The hashes are kept only while their window lasts, to deduplicate the views; afterwards, only the
+number of views and reads of each work and day remain.
+*/
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// Window is the period during which the views of the same visitor are counted once.
+	// Windows start at midnight UTC, so the views of a window are counted on the same day.
+	Window = 24 * time.Hour
+	// ReadThreshold is the completion from which a view counts as a read.
+	ReadThreshold = 0.9
+	// maxReadingSpeed is the number of words per minute over which readers are considered to skim.
+	maxReadingSpeed = 400
+	// keySize is the size in bytes of the keys generated when no secret is configured.
+	keySize = 32
+)
+
+// Hasher computes the identifiers of the visitors.
+type Hasher struct {
+	key []byte
+}
+
+// NewHasher creates a hasher that uses the given secret as its key. If the secret is empty,
+// a random key is generated, so views are deduplicated only until the server restarts.
+func NewHasher(secret string) (*Hasher, error) {
+	if secret != "" {
+		return &Hasher{key: []byte(secret)}, nil
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("analytics: failed to generate key: %w", err)
+	}
+
+	return &Hasher{key: key}, nil
+}
+
+// WindowStart returns the start of the window the given time is in.
+func WindowStart(t time.Time) time.Time {
+	return t.UTC().Truncate(Window)
+}
+
+// Visitor returns the identifier of the visitor with the given identity, which is either
+// their user ID or their IP address, when they view the work at the given time.
+func (h *Hasher) Visitor(identity string, workID int, t time.Time) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(identity))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.Itoa(workID)))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(WindowStart(t).Unix(), 10)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Completion approximates how much of a work of the given number of words was read, given how far
+// the reader scrolled, as a fraction, and for how many seconds they read. Scrolling to the end
+// doesn't count as reading the whole work if there wasn't enough time to read it.
+func Completion(progress, seconds float64, words int) float64 {
+	progress = math.Max(0, math.Min(1, progress))
+
+	if words == 0 {
+		return progress
+	}
+
+	//nolint:gomnd
+	read := math.Max(0, seconds) / 60 * maxReadingSpeed / float64(words)
+
+	return math.Min(progress, read)
+}
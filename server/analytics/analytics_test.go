@@ -0,0 +1,79 @@
+package analytics_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/analytics"
+)
+
+func TestVisitor(t *testing.T) {
+	t.Parallel()
+
+	h, err := analytics.NewHasher("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	morning := time.Date(2021, time.May, 20, 8, 0, 0, 0, time.UTC)
+	evening := morning.Add(12 * time.Hour)
+	visitor := h.Visitor("user:1", 1, morning)
+
+	if len(visitor) != 64 {
+		t.Fatalf("Expected a hex SHA-256 hash, got %q", visitor)
+	}
+
+	if v := h.Visitor("user:1", 1, evening); v != visitor {
+		t.Errorf("Expected the same visitor in the same window, got %q and %q", visitor, v)
+	}
+
+	for name, v := range map[string]string{
+		"Next window":    h.Visitor("user:1", 1, morning.Add(analytics.Window)),
+		"Other work":     h.Visitor("user:1", 2, morning),
+		"Other identity": h.Visitor("user:2", 1, morning),
+	} {
+		if v == visitor {
+			t.Errorf("%s: expected a different visitor", name)
+		}
+	}
+
+	other, err := analytics.NewHasher("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := other.Visitor("user:1", 1, morning); v == visitor {
+		t.Error("Expected a different visitor for a different key")
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Progress float64
+		Seconds  float64
+		Words    int
+		Expected float64
+	}{
+		{Name: "Read", Progress: 1, Seconds: 300, Words: 1000, Expected: 1},
+		{Name: "Skimmed", Progress: 1, Seconds: 30, Words: 1000, Expected: 0.2},
+		{Name: "Half read", Progress: 0.5, Seconds: 600, Words: 1000, Expected: 0.5},
+		{Name: "Invalid values", Progress: 2, Seconds: -10, Words: 1000, Expected: 0},
+		{Name: "Empty work", Progress: 0.7, Seconds: 0, Words: 0, Expected: 0.7},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if c := analytics.Completion(test.Progress, test.Seconds, test.Words); math.Abs(c-test.Expected) > 1e-9 {
+				t.Fatalf("Expected %v, got %v", test.Expected, c)
+			}
+		})
+	}
+}
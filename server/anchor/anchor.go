@@ -0,0 +1,292 @@
+/*
+Package anchor moves ranges of a text, such as the passages of a work that
+were annotated, onto a newer version of the same text.
+
+The offsets are rune offsets, not byte offsets, so they can be used directly
+by clients that index strings by characters. The two versions are compared
+word by word, and each range is mapped using the parts of the text that
+didn't change between versions.
+*/
+package anchor
+
+import (
+	"unicode"
+)
+
+// maxEdits is the maximum number of word insertions and deletions between two
+// versions for which the versions are compared entirely. If the versions differ
+// more, only their common beginning and ending are used to map the ranges.
+const maxEdits = 1000
+
+// Range is a half-open interval [Start, End) of rune offsets in a text.
+// If Start equals End, the range is a position between two runes.
+type Range struct {
+	Start int
+	End   int
+}
+
+// Len returns the number of runes in the range.
+func (r Range) Len() int {
+	return r.End - r.Start
+}
+
+// Result is a range mapped onto the new version of a text.
+type Result struct {
+	Range
+	// Lost is true if none of the range's runes are in the new version.
+	// The range is then empty and positioned where the removed text was.
+	Lost bool
+}
+
+// segment is a run of runes that is the same in both versions.
+type segment struct {
+	old, new, len int
+}
+
+// Rebase maps the given ranges, which are relative to the old text, onto the new text.
+// Ranges that were partially changed are shrunk to the runes that still exist.
+// The ranges must be valid for the old text.
+func Rebase(old, new string, ranges []Range) []Result {
+	segments := diff([]rune(old), []rune(new))
+	results := make([]Result, 0, len(ranges))
+
+	for _, r := range ranges {
+		results = append(results, rebase(segments, r))
+	}
+
+	return results
+}
+
+func rebase(segments []segment, r Range) Result {
+	if r.Len() == 0 {
+		p := position(segments, r.Start)
+
+		return Result{Range: Range{Start: p, End: p}, Lost: false}
+	}
+
+	start, end := -1, -1
+
+	for _, s := range segments {
+		// The part of the range that is also in the segment.
+		from, to := max(r.Start, s.old), min(r.End, s.old+s.len)
+		if from >= to {
+			continue
+		}
+
+		if start == -1 {
+			start = s.new + from - s.old
+		}
+
+		end = s.new + to - s.old
+	}
+
+	if start == -1 {
+		p := position(segments, r.Start)
+
+		return Result{Range: Range{Start: p, End: p}, Lost: true}
+	}
+
+	return Result{Range: Range{Start: start, End: end}, Lost: false}
+}
+
+// position maps a position between runes. Positions inside changed text
+// are mapped to the beginning of the text that replaced it.
+func position(segments []segment, p int) int {
+	ret := 0
+
+	for _, s := range segments {
+		if p < s.old {
+			break
+		}
+
+		if p <= s.old+s.len {
+			return s.new + p - s.old
+		}
+
+		ret = s.new + s.len
+	}
+
+	return ret
+}
+
+// diff returns the segments that are the same in both texts, in order.
+func diff(old, new []rune) []segment {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	segments := []segment{{old: 0, new: 0, len: prefix}}
+
+	oldMiddle, newMiddle := old[prefix:len(old)-suffix], new[prefix:len(new)-suffix]
+	for _, s := range diffWords(words(oldMiddle), words(newMiddle)) {
+		segments = append(segments, segment{old: prefix + s.old, new: prefix + s.new, len: s.len})
+	}
+
+	return append(segments, segment{old: len(old) - suffix, new: len(new) - suffix, len: suffix})
+}
+
+// wordList is a text split into words, each other character being a word
+// by itself. The last offset is the text's length.
+type wordList struct {
+	text    []rune
+	offsets []int
+}
+
+func words(text []rune) wordList {
+	// Guess a word length, so the offsets aren't reallocated too often.
+	//nolint:gomnd
+	offsets := make([]int, 0, len(text)/4+2)
+
+	for i, r := range text {
+		if i == 0 || isWordRune(r) != isWordRune(text[i-1]) || !isWordRune(r) {
+			offsets = append(offsets, i)
+		}
+	}
+
+	return wordList{text: text, offsets: append(offsets, len(text))}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (w wordList) len() int {
+	return len(w.offsets) - 1
+}
+
+func (w wordList) word(i int) []rune {
+	return w.text[w.offsets[i]:w.offsets[i+1]]
+}
+
+func (w wordList) equal(i int, other wordList, j int) bool {
+	a, b := w.word(i), other.word(j)
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k := range a {
+		if a[k] != b[k] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// diffWords finds the longest common subsequence of words using Myers' algorithm
+// and returns it as segments of runes. If there are more than maxEdits edits,
+// no segments are returned.
+func diffWords(old, new wordList) []segment {
+	n, m := old.len(), new.len()
+	if n == 0 || m == 0 {
+		return nil
+	}
+
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	// Only the diagonals that can be reached with d edits are kept for each d.
+	trace := make([][]int, 0)
+
+	for d := 0; d <= n+m && d <= maxEdits; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && old.equal(x, new, y) {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(old, new, trace, d)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backtrack walks the recorded states of the search from the end
+// to find the words that are common to both versions.
+func backtrack(old, new wordList, trace [][]int, d int) []segment {
+	var matches [][2]int
+
+	x, y := old.len(), new.len()
+
+	for ; d > 0; d-- {
+		// The states before the d-th edit, indexed by diagonal from -d.
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || k != d && v[d+k-1] < v[d+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[d+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			matches = append(matches, [2]int{x, y})
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+		matches = append(matches, [2]int{x, y})
+	}
+
+	segments := make([]segment, 0, len(matches))
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		o, n := matches[i][0], matches[i][1]
+		s := segment{old: old.offsets[o], new: new.offsets[n], len: old.offsets[o+1] - old.offsets[o]}
+
+		// Merge consecutive words into a single segment.
+		if l := len(segments) - 1; l >= 0 && segments[l].old+segments[l].len == s.old && segments[l].new+segments[l].len == s.new {
+			segments[l].len += s.len
+		} else {
+			segments = append(segments, s)
+		}
+	}
+
+	return segments
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,121 @@
+package anchor_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/anchor"
+)
+
+// rangeOf returns the rune range of the first occurrence of sub in s.
+func rangeOf(t *testing.T, s, sub string) anchor.Range {
+	t.Helper()
+
+	i := strings.Index(s, sub)
+	if i == -1 {
+		t.Fatalf("%q is not in %q", sub, s)
+	}
+
+	start := len([]rune(s[:i]))
+
+	return anchor.Range{Start: start, End: start + len([]rune(sub))}
+}
+
+func substring(s string, r anchor.Range) string {
+	return string([]rune(s)[r.Start:r.End])
+}
+
+func TestRebase(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Name     string
+		Old      string
+		New      string
+		Passage  string
+		Expected string
+		Lost     bool
+	}
+
+	tests := []testCase{
+		{
+			Name:     "Unchanged text",
+			Old:      "Ion este un roman realist-obiectiv.",
+			New:      "Ion este un roman realist-obiectiv.",
+			Passage:  "roman",
+			Expected: "roman",
+		},
+		{
+			Name:     "Text inserted before",
+			Old:      "Ion este un roman realist-obiectiv.",
+			New:      "Romanul Ion, scris de Liviu Rebreanu, este un roman realist-obiectiv.",
+			Passage:  "realist-obiectiv",
+			Expected: "realist-obiectiv",
+		},
+		{
+			Name:     "Text changed inside the passage",
+			Old:      "Ion este un roman realist-obiectiv, publicat în 1920.",
+			New:      "Ion este un roman realist și obiectiv, publicat în 1920.",
+			Passage:  "roman realist-obiectiv",
+			Expected: "roman realist și obiectiv",
+		},
+		{
+			Name:     "Passage partially removed",
+			Old:      "Personajul principal este un țăran sărac și ambițios.",
+			New:      "Personajul principal este un țăran ambițios.",
+			Passage:  "sărac și ambițios",
+			Expected: "ambițios",
+		},
+		{
+			Name:     "Passage removed",
+			Old:      "Ana este o victimă. Citatul e greșit. Finalul este tragic.",
+			New:      "Ana este o victimă. Finalul este tragic.",
+			Passage:  "Citatul e greșit.",
+			Expected: "",
+			Lost:     true,
+		},
+		{
+			Name:     "Diacritics before the passage",
+			Old:      "Tema: pământul. Viziunea: pământul e totul pentru țăran.",
+			New:      "Tema operei: iubirea pentru pământ. Viziunea: pământul e totul pentru țăran.",
+			Passage:  "totul pentru țăran",
+			Expected: "totul pentru țăran",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			results := anchor.Rebase(test.Old, test.New, []anchor.Range{rangeOf(t, test.Old, test.Passage)})
+			if len(results) != 1 {
+				t.Fatalf("Expected 1 result, got %d", len(results))
+			}
+
+			r := results[0]
+			if r.Lost != test.Lost {
+				t.Fatalf("Expected lost to be %t, got %t", test.Lost, r.Lost)
+			}
+
+			if s := substring(test.New, r.Range); s != test.Expected {
+				t.Fatalf("Expected passage %q, got %q", test.Expected, s)
+			}
+		})
+	}
+}
+
+func TestRebasePosition(t *testing.T) {
+	t.Parallel()
+
+	old := "Ion vrea pământ. Ion o iubește pe Florica."
+	new := "Ion își dorește pământ. Ion o iubește pe Florica."
+
+	p := rangeOf(t, old, "Ion o").Start
+	results := anchor.Rebase(old, new, []anchor.Range{{Start: p, End: p}})
+
+	if expected := rangeOf(t, new, "Ion o").Start; results[0].Start != expected || results[0].Len() != 0 {
+		t.Fatalf("Expected position %d, got %+v", expected, results[0])
+	}
+}
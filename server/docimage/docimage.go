@@ -0,0 +1,327 @@
+/*
+Package docimage extracts the images embedded in DOCX and ODT documents,
+together with the position at which they appear in the document's text.
+
+Positions are expressed in text paragraphs, the same way the client splits
+a work's content: an image with Paragraph equal to 2 is displayed before
+the third non-empty paragraph of the work.
+*/
+package docimage
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/FiveIT/eseuri/server/mime"
+)
+
+var (
+	ErrTooManyImages   = errors.New("docimage: document contains too many images")
+	ErrImageTooLarge   = errors.New("docimage: image is too large")
+	ErrInvalidDocument = errors.New("docimage: invalid document")
+)
+
+// Limits restricts the images that are extracted from a document.
+type Limits struct {
+	// MaxCount is the maximum number of images a document may contain.
+	MaxCount int
+	// MaxSize is the maximum size in bytes of a single image.
+	MaxSize int64
+}
+
+//nolint:gochecknoglobals,gomnd
+var DefaultLimits = Limits{
+	MaxCount: 10,
+	MaxSize:  2 << 20,
+}
+
+// Image is an image embedded in a document.
+type Image struct {
+	// Name is the path of the image inside the document archive.
+	Name        string
+	ContentType string
+	// Paragraph is the number of non-empty text paragraphs that precede the image.
+	Paragraph int
+	Data      []byte
+}
+
+type format struct {
+	content       string
+	textSpace     string
+	paragraphs    []string
+	textElement   string
+	resolveTarget func(*zip.Reader) (func(string) string, error)
+}
+
+const (
+	wordprocessingML = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	relationships    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	openDocumentText = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	openDocumentDraw = "urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+	xlink            = "http://www.w3.org/1999/xlink"
+)
+
+//nolint:gochecknoglobals
+var formats = map[string]format{
+	mime.DOCX: {
+		content:       "word/document.xml",
+		textSpace:     wordprocessingML,
+		paragraphs:    []string{"p"},
+		textElement:   "t",
+		resolveTarget: docxRelationships,
+	},
+	mime.ODT: {
+		content:     "content.xml",
+		textSpace:   openDocumentText,
+		paragraphs:  []string{"p", "h"},
+		textElement: "",
+		resolveTarget: func(*zip.Reader) (func(string) string, error) {
+			return func(href string) string { return href }, nil
+		},
+	},
+}
+
+// Supports reports whether images can be extracted from documents of the given MIME-type.
+func Supports(mimeType string) bool {
+	_, ok := formats[mimeType]
+
+	return ok
+}
+
+// Extract returns the images embedded in the document of the given MIME-type,
+// in the order they appear in the document. Images in formats that can't be
+// displayed by browsers (for example, Windows metafiles) are skipped.
+func Extract(r io.ReaderAt, size int64, mimeType string, limits Limits) ([]Image, error) {
+	f, ok := formats[mimeType]
+	if !ok {
+		return nil, nil
+	}
+
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	resolve, err := f.resolveTarget(z)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := z.Open(f.content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing %s: %v", ErrInvalidDocument, f.content, err)
+	}
+	defer content.Close()
+
+	refs, err := f.references(content, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]Image, 0, len(refs))
+
+	for _, ref := range refs {
+		img, err := readImage(z, ref, limits)
+		if err != nil {
+			return nil, err
+		}
+
+		if img == nil {
+			continue
+		}
+
+		if len(images) == limits.MaxCount {
+			return nil, ErrTooManyImages
+		}
+
+		images = append(images, *img)
+	}
+
+	return images, nil
+}
+
+type reference struct {
+	name      string
+	paragraph int
+}
+
+func (f format) isParagraph(name xml.Name) bool {
+	if name.Space != f.textSpace {
+		return false
+	}
+
+	for _, p := range f.paragraphs {
+		if name.Local == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f format) isText(name xml.Name) bool {
+	return f.textElement == "" || name.Space == f.textSpace && name.Local == f.textElement
+}
+
+func imageReference(el xml.StartElement) string {
+	var space, local string
+
+	switch {
+	case el.Name.Local == "blip":
+		space, local = relationships, "embed"
+	case el.Name.Local == "imagedata":
+		space, local = relationships, "id"
+	case el.Name.Space == openDocumentDraw && el.Name.Local == "image":
+		space, local = xlink, "href"
+	default:
+		return ""
+	}
+
+	for _, attr := range el.Attr {
+		if attr.Name.Space == space && attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+func (f format) references(r io.Reader, resolve func(string) string) ([]reference, error) {
+	var (
+		refs       []reference
+		paragraphs int
+		depth      int
+		hasText    bool
+		textDepth  int
+		d          = xml.NewDecoder(r)
+	)
+
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return refs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if f.isParagraph(el.Name) {
+				if depth == 0 {
+					hasText = false
+				}
+				depth++
+			} else if depth > 0 && f.isText(el.Name) {
+				textDepth++
+			}
+
+			if target := imageReference(el); target != "" {
+				if name := resolve(target); name != "" {
+					refs = append(refs, reference{name: name, paragraph: paragraphs})
+				}
+			}
+		case xml.EndElement:
+			if f.isParagraph(el.Name) {
+				depth--
+				if depth == 0 && hasText {
+					paragraphs++
+				}
+			} else if depth > 0 && f.isText(el.Name) {
+				textDepth--
+			}
+		case xml.CharData:
+			if depth > 0 && (f.textElement == "" || textDepth > 0) && strings.TrimSpace(string(el)) != "" {
+				hasText = true
+			}
+		}
+	}
+}
+
+func docxRelationships(z *zip.Reader) (func(string) string, error) {
+	const name = "word/_rels/document.xml.rels"
+
+	f, err := z.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing %s: %v", ErrInvalidDocument, name, err)
+	}
+	defer f.Close()
+
+	var rels struct {
+		Relationships []struct {
+			ID         string `xml:"Id,attr"`
+			Target     string `xml:"Target,attr"`
+			TargetMode string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if err = xml.NewDecoder(f).Decode(&rels); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDocument, name, err)
+	}
+
+	targets := make(map[string]string, len(rels.Relationships))
+
+	for _, rel := range rels.Relationships {
+		// Linked (not embedded) images can't be extracted.
+		if rel.TargetMode == "External" {
+			continue
+		}
+
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("word", rel.Target)
+		}
+	}
+
+	return func(id string) string { return targets[id] }, nil
+}
+
+func readImage(z *zip.Reader, ref reference, limits Limits) (*Image, error) {
+	f, err := z.Open(ref.name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing image %s: %v", ErrInvalidDocument, ref.name, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limits.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read image %s: %v", ErrInvalidDocument, ref.name, err)
+	}
+
+	if int64(len(data)) > limits.MaxSize {
+		return nil, fmt.Errorf("%w: %s", ErrImageTooLarge, ref.name)
+	}
+
+	switch contentType := http.DetectContentType(data); contentType {
+	case mime.PNG, mime.JPEG, mime.GIF:
+		return &Image{
+			Name:        ref.name,
+			ContentType: contentType,
+			Paragraph:   ref.paragraph,
+			Data:        data,
+		}, nil
+	default:
+		//nolint:nilnil
+		return nil, nil
+	}
+}
+
+// Extension returns the file extension commonly used for the image's format.
+func (i *Image) Extension() string {
+	switch i.ContentType {
+	case mime.PNG:
+		return ".png"
+	case mime.JPEG:
+		return ".jpg"
+	case mime.GIF:
+		return ".gif"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,152 @@
+package docimage_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/docimage"
+	"github.com/FiveIT/eseuri/server/mime"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+//nolint:gochecknoglobals
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+func archive(tb testing.TB, files map[string][]byte) *bytes.Reader {
+	tb.Helper()
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			tb.Fatalf("Failed to create archive entry %q: %v", name, err)
+		}
+
+		if _, err = f.Write(data); err != nil {
+			tb.Fatalf("Failed to write archive entry %q: %v", name, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		tb.Fatalf("Failed to close archive: %v", err)
+	}
+
+	return bytes.NewReader(buf.Bytes())
+}
+
+const docx = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">
+	<w:body>
+		<w:p><w:r><w:t>Ion este personajul principal.</w:t></w:r></w:p>
+		<w:p></w:p>
+		<w:p><w:r><w:drawing><a:blip r:embed="rId5"/></w:drawing></w:r></w:p>
+		<w:p><w:r><w:t>Harta relațiilor dintre personaje.</w:t></w:r></w:p>
+		<w:p><w:r><w:drawing><a:blip r:embed="rId6"/></w:drawing></w:r></w:p>
+	</w:body>
+</w:document>`
+
+const docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rId5" Type="image" Target="media/image1.png"/>
+	<Relationship Id="rId6" Type="image" Target="media/image2.emf"/>
+</Relationships>`
+
+const odt = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+	xmlns:xlink="http://www.w3.org/1999/xlink">
+	<office:body><office:text>
+		<text:h>Caracterizare</text:h>
+		<text:p>Primul paragraf.</text:p>
+		<text:p>Al doilea <draw:frame><draw:image xlink:href="Pictures/timeline.png"/></draw:frame> paragraf.</text:p>
+	</office:text></office:body>
+</office:document-content>`
+
+func TestExtractDOCX(t *testing.T) {
+	t.Parallel()
+
+	r := archive(t, map[string][]byte{
+		"word/document.xml":            []byte(docx),
+		"word/_rels/document.xml.rels": []byte(docxRels),
+		"word/media/image1.png":        png,
+		"word/media/image2.emf":        []byte("not a browser image"),
+	})
+
+	images, err := docimage.Extract(r, r.Size(), mime.DOCX, docimage.DefaultLimits)
+	if err != nil {
+		t.Fatalf("Failed to extract images: %v", err)
+	}
+
+	utils.AssertEqual(t, 1, len(images))
+	utils.AssertEqual(t, "word/media/image1.png", images[0].Name)
+	utils.AssertEqual(t, mime.PNG, images[0].ContentType)
+	utils.AssertEqual(t, 1, images[0].Paragraph)
+	utils.AssertEqual(t, ".png", images[0].Extension())
+}
+
+func TestExtractODT(t *testing.T) {
+	t.Parallel()
+
+	r := archive(t, map[string][]byte{
+		"content.xml":           []byte(odt),
+		"Pictures/timeline.png": png,
+	})
+
+	images, err := docimage.Extract(r, r.Size(), mime.ODT, docimage.DefaultLimits)
+	if err != nil {
+		t.Fatalf("Failed to extract images: %v", err)
+	}
+
+	utils.AssertEqual(t, 1, len(images))
+	utils.AssertEqual(t, 2, images[0].Paragraph)
+}
+
+func TestExtractLimits(t *testing.T) {
+	t.Parallel()
+
+	files := map[string][]byte{
+		"content.xml":           []byte(odt),
+		"Pictures/timeline.png": png,
+	}
+
+	type testCase struct {
+		Name     string
+		Limits   docimage.Limits
+		Expected error
+	}
+
+	tests := []testCase{
+		{
+			Name:     "Count",
+			Limits:   docimage.Limits{MaxCount: 0, MaxSize: docimage.DefaultLimits.MaxSize},
+			Expected: docimage.ErrTooManyImages,
+		},
+		{
+			Name:     "Size",
+			Limits:   docimage.Limits{MaxCount: 1, MaxSize: 8},
+			Expected: docimage.ErrImageTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			r := archive(t, files)
+
+			_, err := docimage.Extract(r, r.Size(), mime.ODT, test.Limits)
+			if !errors.Is(err, test.Expected) {
+				t.Fatalf("Expected error %v, got %v", test.Expected, err)
+			}
+		})
+	}
+}
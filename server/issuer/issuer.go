@@ -0,0 +1,164 @@
+/*
+Package issuer mints the tokens the service is authorized with, without Auth0, so the service
+can be developed and tested offline. The tokens have the same claims as the ones Auth0 issues.
+
+The issuer's key is read from the DEV_JWT_PRIVATE_KEY environment variable, as a PEM encoded
+RSA private key, or it is generated when the process starts. If TRUST_DEV_ISSUER is set to 1, the auth
+middleware trusts the tokens signed with the issuer's key. Hasura must be configured with the
+issuer's JWT secret to accept the tokens too:
+
+	secret, err := issuer.Default().JWTSecret()
+*/
+package issuer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/meta"
+	jwt "github.com/form3tech-oss/jwt-go"
+)
+
+const (
+	// KeyID is the ID of the issuer's key, in the headers of the tokens it issues.
+	KeyID = "eseuri-dev"
+	// Name is the issuer of the tokens.
+	Name = "https://eseuri.com/dev/"
+	// Audience is the audience of the tokens.
+	Audience = "https://eseuri.com/dev"
+	// keyBits is the size of the generated keys.
+	keyBits = 2048
+)
+
+var ErrInvalidKey = errors.New("issuer: invalid private key")
+
+// User is the user a token is issued for.
+type User struct {
+	ID int
+	// Role is the user's default role.
+	Role string
+	// Roles are the other roles the user may select with the X-Hasura-Role header.
+	Roles []string
+	// Registered tells whether the user has completed their registration.
+	Registered bool
+}
+
+// Issuer signs tokens with its private key.
+type Issuer struct {
+	key *rsa.PrivateKey
+}
+
+// New creates an issuer that signs the tokens with the given key.
+func New(key *rsa.PrivateKey) *Issuer {
+	return &Issuer{key: key}
+}
+
+// Generate creates an issuer with a new key.
+func Generate() (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return nil, fmt.Errorf("issuer: failed to generate key: %w", err)
+	}
+
+	return New(key), nil
+}
+
+// FromPEM creates an issuer with the PEM encoded RSA private key.
+func FromPEM(data []byte) (*Issuer, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return New(key), nil
+}
+
+//nolint:gochecknoglobals
+var (
+	defaultIssuer *Issuer
+	defaultErr    error
+	defaultOnce   sync.Once
+)
+
+// Default returns the issuer with the key from the DEV_JWT_PRIVATE_KEY environment variable, or with a key
+// generated once per process, if the variable is empty. It is the issuer the guard trusts.
+func Default() (*Issuer, error) {
+	defaultOnce.Do(func() {
+		if meta.DevJWTKey != "" {
+			defaultIssuer, defaultErr = FromPEM([]byte(meta.DevJWTKey))
+		} else {
+			defaultIssuer, defaultErr = Generate()
+		}
+	})
+
+	return defaultIssuer, defaultErr
+}
+
+// PublicKey returns the key the issued tokens are verified with.
+func (i *Issuer) PublicKey() *rsa.PublicKey {
+	return &i.key.PublicKey
+}
+
+// Token issues a token for the user, which expires after the given duration. It returns
+// the value of the authorization header, like the tokens obtained from Auth0.
+func (i *Issuer) Token(u User, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": Name,
+		"sub": "eseuri-dev|" + strconv.Itoa(u.ID),
+		"aud": Audience,
+		"iat": now.Unix(),
+		"exp": now.Add(expiresIn).Unix(),
+		"https://hasura.io/jwt/claims": map[string]interface{}{
+			"X-Hasura-Default-Role":  u.Role,
+			"X-Hasura-Allowed-Roles": append([]string{"anonymous", u.Role}, u.Roles...),
+			"X-Hasura-User-Id":       strconv.Itoa(u.ID),
+		},
+		"https://eseuri.com": map[string]interface{}{
+			"hasCompletedRegistration": u.Registered,
+		},
+	})
+
+	token.Header["kid"] = KeyID
+
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		return "", fmt.Errorf("issuer: failed to sign token: %w", err)
+	}
+
+	return "Bearer " + signed, nil
+}
+
+// JWTSecret returns the Hasura JWT secret that makes Hasura accept the issued tokens.
+func (i *Issuer) JWTSecret() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(i.PublicKey())
+	if err != nil {
+		return "", fmt.Errorf("issuer: failed to encode public key: %w", err)
+	}
+
+	secret, err := json.Marshal(map[string]string{
+		"type":     "RS256",
+		"key":      string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		"issuer":   Name,
+		"audience": Audience,
+	})
+	if err != nil {
+		return "", fmt.Errorf("issuer: failed to encode JWT secret: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+// PrivateKeyPEM returns the issuer's key, PEM encoded, so it can be set as the DEV_JWT_PRIVATE_KEY.
+func (i *Issuer) PrivateKeyPEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(i.key)}))
+}
@@ -0,0 +1,75 @@
+package issuer_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/issuer"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/middleware/logger"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+func TestToken(t *testing.T) {
+	t.Parallel()
+
+	iss, err := issuer.Generate()
+	if err != nil {
+		t.Fatalf("Failed to create issuer: %v", err)
+	}
+
+	// The issuer is recreated from its key, like it is from the environment.
+	if iss, err = issuer.FromPEM([]byte(iss.PrivateKeyPEM())); err != nil {
+		t.Fatalf("Failed to create issuer from PEM: %v", err)
+	}
+
+	var secret struct {
+		Key      string `json:"key"`
+		Issuer   string `json:"issuer"`
+		Audience string `json:"audience"`
+	}
+
+	data, err := iss.JWTSecret()
+	if err != nil {
+		t.Fatalf("Failed to get JWT secret: %v", err)
+	}
+
+	testhelper.DecodeJSON(t, strings.NewReader(data), &secret)
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(secret.Key))
+	if err != nil {
+		t.Fatalf("Failed to parse the secret's key: %v", err)
+	}
+
+	ch := make(chan auth.CustomClaims, 1)
+	app := testhelper.App(t, logger.Middleware(nil), auth.NewGuard(auth.Config{
+		Keys: auth.NewKeySet("", key),
+		Validator: auth.Validator{
+			Issuers:  []string{secret.Issuer},
+			Audience: []string{secret.Audience},
+			Leeway:   0,
+		},
+	}, nil).User(), func(c *fiber.Ctx) error {
+		ch <- c.Locals("claims").(auth.CustomClaims)
+
+		return c.Next()
+	})
+
+	user := issuer.User{ID: 42, Role: "teacher", Registered: false}
+
+	token, err := iss.Token(user, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	res := testhelper.DoTestRequest(t, app, testhelper.Request(t, http.MethodGet, "/", nil, token))
+	defer res.Body.Close()
+
+	testhelper.AssertSuccess(t, res)
+	utils.AssertEqual(t, auth.CustomClaims{IsRegistered: false, UserID: 42, Role: "teacher", AllowedRoles: []string{"anonymous", "teacher"}}, <-ch)
+}
@@ -2,23 +2,194 @@ package auth0_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
-	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/meta/auth0"
+	"github.com/gofiber/fiber/v2/utils"
 )
 
+// fakeAuth0 serves the OAuth token endpoint, issuing tokens for the given client.
+type fakeAuth0 struct {
+	clientID, clientSecret string
+	// requests is the number of token requests received.
+	requests int32
+	// failures is the number of token requests to fail with a server error before succeeding.
+	failures int32
+	// delay is how long the token requests take.
+	delay time.Duration
+}
+
+func (f *fakeAuth0) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/oauth/token" || r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	atomic.AddInt32(&f.requests, 1)
+	time.Sleep(f.delay)
+
+	if atomic.AddInt32(&f.failures, -1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if r.FormValue("client_id") != f.clientID || r.FormValue("client_secret") != f.clientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"access_denied","error_description":"Unauthorized"}`))
+
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "registered=" + r.FormValue("registerUser") + ";audience=" + r.FormValue("audience"),
+		"token_type":   "Bearer",
+		"expires_in":   86400,
+	})
+}
+
+func newFakeAuth0(tb testing.TB, f *fakeAuth0) *auth0.Auth0 {
+	tb.Helper()
+
+	server := httptest.NewTLSServer(f)
+	tb.Cleanup(server.Close)
+
+	//nolint:exhaustivestruct
+	a := &auth0.Auth0{
+		Domain:       strings.TrimPrefix(server.URL, "https://"),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Audience:     "https://eseuri.com",
+		HTTPClient:   server.Client(),
+	}
+
+	a.SetBackoff(time.Millisecond)
+
+	return a
+}
+
+//nolint:exhaustivestruct
 func TestGetAuthorizationToken(t *testing.T) {
 	t.Parallel()
 
-	token, err := meta.Auth0.AuthorizationToken(context.Background())
-	if err != nil {
+	f := &fakeAuth0{clientID: "client", clientSecret: "secret"}
+	a := newFakeAuth0(t, f)
+
+	for i := 0; i < 2; i++ {
+		token, err := a.AuthorizationToken(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get token: %v", err)
+		}
+
+		utils.AssertEqual(t, "Bearer registered=false;audience=https://eseuri.com", token)
+
+		token, err = a.AuthorizationToken(context.Background(), true)
+		if err != nil {
+			t.Fatalf("Failed to get token: %v", err)
+		}
+
+		utils.AssertEqual(t, "Bearer registered=true;audience=https://eseuri.com", token)
+	}
+
+	utils.AssertEqual(t, int32(2), f.requests, "the tokens are cached per registerUser")
+}
+
+//nolint:exhaustivestruct
+func TestGetAuthorizationTokenConcurrent(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeAuth0{clientID: "client", clientSecret: "secret", delay: 50 * time.Millisecond}
+	a := newFakeAuth0(t, f)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := a.AuthorizationToken(context.Background()); err != nil {
+				t.Errorf("Failed to get token: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	utils.AssertEqual(t, int32(1), f.requests, "the concurrent requests share a token request")
+}
+
+//nolint:exhaustivestruct
+func TestGetAuthorizationTokenCanceled(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeAuth0{clientID: "client", clientSecret: "secret", delay: 50 * time.Millisecond}
+	a := newFakeAuth0(t, f)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := a.AuthorizationToken(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the first request to time out, got %v", err)
+	}
+
+	// The shared request continues after the first caller gave up, so the others still get the token.
+	if _, err := a.AuthorizationToken(context.Background()); err != nil {
 		t.Fatalf("Failed to get token: %v", err)
 	}
 
-	t.Logf(token)
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&f.requests), "the token request is shared")
+}
 
-	if !strings.HasPrefix(token, "Bearer ") {
-		t.Fatal(`Expected "Bearer" prefix`)
+//nolint:exhaustivestruct
+func TestGetAuthorizationTokenRetry(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeAuth0{clientID: "client", clientSecret: "secret", failures: 2}
+	a := newFakeAuth0(t, f)
+
+	if _, err := a.AuthorizationToken(context.Background()); err != nil {
+		t.Fatalf("Failed to get token after retrying: %v", err)
+	}
+
+	utils.AssertEqual(t, int32(3), f.requests)
+
+	f = &fakeAuth0{clientID: "client", clientSecret: "secret", failures: 5}
+	a = newFakeAuth0(t, f)
+
+	var apiErr *auth0.Error
+	if _, err := a.AuthorizationToken(context.Background()); !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an Auth0 error, got %v", err)
 	}
+
+	utils.AssertEqual(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	utils.AssertEqual(t, int32(3), f.requests, "the token is requested at most 3 times")
+}
+
+//nolint:exhaustivestruct
+func TestGetAuthorizationTokenDenied(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeAuth0{clientID: "other", clientSecret: "secret"}
+	a := newFakeAuth0(t, f)
+
+	var apiErr *auth0.Error
+	if _, err := a.AuthorizationToken(context.Background()); !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an Auth0 error for invalid credentials, got %v", err)
+	}
+
+	utils.AssertEqual(t, http.StatusUnauthorized, apiErr.StatusCode)
+	utils.AssertEqual(t, "access_denied", apiErr.Kind)
+	utils.AssertEqual(t, "Unauthorized", apiErr.Description)
+	utils.AssertEqual(t, int32(1), f.requests, "client errors aren't retried")
 }
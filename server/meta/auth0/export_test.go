@@ -0,0 +1,8 @@
+package auth0
+
+import "time"
+
+// SetBackoff sets how long to wait before retrying the first failed token request.
+func (a *Auth0) SetBackoff(d time.Duration) {
+	a.tokens.backoff = d
+}
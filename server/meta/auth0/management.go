@@ -0,0 +1,162 @@
+package auth0
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Error is an error response of Auth0.
+type Error struct {
+	StatusCode int    `json:"statusCode"`
+	Kind       string `json:"error"`
+	Message    string `json:"message"`
+	// Description is the message of the OAuth token endpoint's errors.
+	Description string `json:"error_description"`
+	// Code identifies the error more precisely than the status code, like "inexistent_user".
+	Code string `json:"errorCode"`
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = e.Description
+	}
+
+	return fmt.Sprintf("auth0: %d %s: %s", e.StatusCode, e.Kind, message)
+}
+
+// isTransient tells whether the request failed due to a server error, so it may succeed if retried.
+func isTransient(err error) bool {
+	var apiErr *Error
+
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+func (a *Auth0) apiURL(path string) string {
+	return "https://" + a.Domain + "/api/v2/" + path
+}
+
+// ManagementToken returns a token of the Management API, which the application must be authorized to use
+// with the client credentials grant.
+func (a *Auth0) ManagementToken(ctx context.Context) (string, error) {
+	t, err := a.token(ctx, tokenKey{audience: a.apiURL(""), registerUser: false})
+	if err != nil {
+		return "", fmt.Errorf("auth0: failed to get management token: %w", err)
+	}
+
+	return t.AccessToken, nil
+}
+
+// do sends the request and decodes the response into out, if it's not nil. Error responses are returned as an *Error.
+func (a *Auth0) do(req *http.Request, out interface{}) error {
+	res, err := a.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiErr := &Error{StatusCode: res.StatusCode, Kind: http.StatusText(res.StatusCode), Message: "", Description: "", Code: ""}
+
+		body, err := io.ReadAll(res.Body)
+		if err == nil {
+			_ = json.Unmarshal(body, apiErr)
+			apiErr.StatusCode = res.StatusCode
+
+			if apiErr.Message == "" && apiErr.Description == "" {
+				apiErr.Message = string(body)
+			}
+		}
+
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// call sends an authorized request to the Management API.
+func (a *Auth0) call(ctx context.Context, method, path string, body interface{}) error {
+	token, err := a.ManagementToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("auth0: failed to encode request: %w", err)
+		}
+
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.apiURL(path), r)
+	if err != nil {
+		return fmt.Errorf("auth0: failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if err := a.do(req, nil); err != nil {
+		return fmt.Errorf("auth0: %s %s: %w", method, path, err)
+	}
+
+	return nil
+}
+
+func userPath(userID string) string {
+	return "users/" + url.PathEscape(userID)
+}
+
+// DeleteUser deletes the user with the given Auth0 ID, so they can't log in anymore.
+// Deleting a user that doesn't exist succeeds.
+func (a *Auth0) DeleteUser(ctx context.Context, userID string) error {
+	err := a.call(ctx, http.MethodDelete, userPath(userID), nil)
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// AppMetadata is the data of the user that only the application may change. The fields that are nil are left unchanged.
+type AppMetadata struct {
+	Role                     *string `json:"role,omitempty"`
+	HasCompletedRegistration *bool   `json:"hasCompletedRegistration,omitempty"`
+}
+
+// UpdateAppMetadata merges the given metadata into the user's app_metadata.
+func (a *Auth0) UpdateAppMetadata(ctx context.Context, userID string, metadata AppMetadata) error {
+	return a.call(ctx, http.MethodPatch, userPath(userID), map[string]interface{}{
+		"app_metadata": metadata,
+	})
+}
+
+// BlockUser blocks the user, so they can't log in until they're unblocked.
+func (a *Auth0) BlockUser(ctx context.Context, userID string) error {
+	return a.call(ctx, http.MethodPatch, userPath(userID), map[string]interface{}{
+		"blocked": true,
+	})
+}
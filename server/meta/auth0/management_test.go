@@ -0,0 +1,169 @@
+package auth0_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/meta/auth0"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const managementToken = "management"
+
+// fakeUser is a user of the fake Management API.
+type fakeUser struct {
+	Blocked     bool                   `json:"blocked"`
+	AppMetadata map[string]interface{} `json:"app_metadata"`
+}
+
+// fakeManagement serves the OAuth token endpoint and the users of the Management API.
+type fakeManagement struct {
+	mu sync.Mutex
+	// tokens is the number of management tokens issued.
+	tokens int
+	users  map[string]*fakeUser
+}
+
+func (f *fakeManagement) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/oauth/token" {
+		if r.FormValue("audience") != "https://"+r.Host+"/api/v2/" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		f.tokens++
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": managementToken,
+			"token_type":   "Bearer",
+			"expires_in":   86400,
+		})
+
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+managementToken {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	user, ok := f.users[strings.TrimPrefix(r.URL.Path, "/api/v2/users/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"statusCode":404,"error":"Not Found","message":"The user does not exist.","errorCode":"inexistent_user"}`))
+
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		delete(f.users, strings.TrimPrefix(r.URL.Path, "/api/v2/users/"))
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPatch:
+		var body struct {
+			Blocked     *bool                  `json:"blocked"`
+			AppMetadata map[string]interface{} `json:"app_metadata"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		if body.Blocked != nil {
+			user.Blocked = *body.Blocked
+		}
+
+		for k, v := range body.AppMetadata {
+			user.AppMetadata[k] = v
+		}
+
+		_ = json.NewEncoder(w).Encode(user)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newFakeManagement(tb testing.TB, ids ...string) (*auth0.Auth0, *fakeManagement) {
+	tb.Helper()
+
+	f := &fakeManagement{mu: sync.Mutex{}, tokens: 0, users: make(map[string]*fakeUser)}
+
+	for _, id := range ids {
+		f.users[id] = &fakeUser{Blocked: false, AppMetadata: map[string]interface{}{"role": "student"}}
+	}
+
+	server := httptest.NewTLSServer(f)
+	tb.Cleanup(server.Close)
+
+	//nolint:exhaustivestruct
+	return &auth0.Auth0{
+		Domain:       strings.TrimPrefix(server.URL, "https://"),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Audience:     "https://eseuri.com",
+		HTTPClient:   server.Client(),
+	}, f
+}
+
+func TestManagement(t *testing.T) {
+	t.Parallel()
+
+	const id = "google-oauth2|123"
+
+	a, f := newFakeManagement(t, id)
+	ctx := context.Background()
+
+	role, registered := "teacher", true
+	if err := a.UpdateAppMetadata(ctx, id, auth0.AppMetadata{Role: &role, HasCompletedRegistration: &registered}); err != nil {
+		t.Fatalf("Failed to update app metadata: %v", err)
+	}
+
+	utils.AssertEqual(t, map[string]interface{}{"role": "teacher", "hasCompletedRegistration": true}, f.users[id].AppMetadata)
+
+	if err := a.BlockUser(ctx, id); err != nil {
+		t.Fatalf("Failed to block user: %v", err)
+	}
+
+	utils.AssertEqual(t, true, f.users[id].Blocked)
+
+	if err := a.DeleteUser(ctx, id); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	utils.AssertEqual(t, 0, len(f.users))
+
+	if err := a.DeleteUser(ctx, id); err != nil {
+		t.Fatalf("Deleting a missing user should succeed: %v", err)
+	}
+
+	utils.AssertEqual(t, 1, f.tokens, "the management token is cached")
+}
+
+func TestManagementError(t *testing.T) {
+	t.Parallel()
+
+	a, _ := newFakeManagement(t)
+
+	err := a.BlockUser(context.Background(), "auth0|missing")
+
+	var apiErr *auth0.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an Auth0 error, got %v", err)
+	}
+
+	utils.AssertEqual(t, http.StatusNotFound, apiErr.StatusCode)
+	utils.AssertEqual(t, "inexistent_user", apiErr.Code)
+}
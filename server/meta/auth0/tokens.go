@@ -0,0 +1,162 @@
+package auth0
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenExpiryMargin is how long before their expiry the tokens are renewed,
+	// so they don't expire while a request is sent.
+	tokenExpiryMargin = time.Minute
+	// tokenAttempts is how many times a token is requested when Auth0 fails with a server error.
+	tokenAttempts = 3
+	// defaultBackoff is how long to wait before the first retry. The wait doubles after each retry.
+	defaultBackoff = 200 * time.Millisecond
+	// tokenRequestTimeout is how long a shared token request may take, retries included.
+	tokenRequestTimeout = 30 * time.Second
+)
+
+// token is a response of the OAuth token endpoint.
+type token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// tokenKey identifies the tokens that can be reused for each other.
+type tokenKey struct {
+	audience     string
+	registerUser bool
+}
+
+type cachedToken struct {
+	token     token
+	expiresAt time.Time
+}
+
+// tokenCall is an in-flight token request, which the concurrent requests for the same token wait for.
+type tokenCall struct {
+	done  chan struct{}
+	token token
+	err   error
+}
+
+// tokenSource caches the tokens until shortly before they expire. Concurrent requests for
+// a token that isn't cached share a single request to Auth0. Its zero value is ready to use.
+type tokenSource struct {
+	mu      sync.Mutex
+	tokens  map[tokenKey]cachedToken
+	calls   map[tokenKey]*tokenCall
+	backoff time.Duration
+}
+
+// requestToken exchanges the client credentials for a token, retrying if Auth0 fails with a server error.
+// Error responses are returned as an *Error.
+func (a *Auth0) requestToken(ctx context.Context, key tokenKey) (token, error) {
+	data := url.Values{}
+	data.Set("client_id", a.ClientID)
+	data.Set("client_secret", a.ClientSecret)
+	data.Set("audience", key.audience)
+	data.Set("grant_type", "client_credentials")
+	data.Set("registerUser", strconv.FormatBool(key.registerUser))
+
+	backoff := a.tokens.backoff
+	if backoff == 0 {
+		backoff = defaultBackoff
+	}
+
+	var (
+		t   token
+		err error
+	)
+
+	for attempt := 1; ; attempt++ {
+		req, reqErr := http.NewRequestWithContext(ctx, http.MethodPost, a.oAuthURL(), strings.NewReader(data.Encode()))
+		if reqErr != nil {
+			return t, fmt.Errorf("couldn't create request object: %w", reqErr)
+		}
+
+		req.Header.Add("Cache-Control", "no-cache")
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+		if err = a.do(req, &t); err == nil || !isTransient(err) || attempt == tokenAttempts {
+			return t, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return t, fmt.Errorf("gave up retrying: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// token returns the cached token for the key, or requests a new one.
+func (a *Auth0) token(ctx context.Context, key tokenKey) (token, error) {
+	s := &a.tokens
+
+	s.mu.Lock()
+
+	if cached, ok := s.tokens[key]; ok && time.Now().Before(cached.expiresAt) {
+		s.mu.Unlock()
+
+		return cached.token, nil
+	}
+
+	call, ok := s.calls[key]
+	if !ok {
+		call = &tokenCall{done: make(chan struct{}), token: token{}, err: nil}
+
+		if s.calls == nil {
+			s.calls = make(map[tokenKey]*tokenCall)
+		}
+
+		s.calls[key] = call
+
+		go a.fetchToken(key, call)
+	}
+
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return token{}, fmt.Errorf("auth0: waiting for token: %w", ctx.Err())
+	}
+}
+
+// fetchToken makes the token request the concurrent callers wait for, and caches its result.
+// The request isn't bound to any caller's context, as the others would fail if that caller gave up.
+func (a *Auth0) fetchToken(key tokenKey, call *tokenCall) {
+	s := &a.tokens
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRequestTimeout)
+	defer cancel()
+
+	call.token, call.err = a.requestToken(ctx, key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.calls, key)
+
+	if lifetime := time.Duration(call.token.ExpiresIn)*time.Second - tokenExpiryMargin; call.err == nil && lifetime > 0 {
+		if s.tokens == nil {
+			s.tokens = make(map[tokenKey]cachedToken)
+		}
+
+		s.tokens[key] = cachedToken{token: call.token, expiresAt: time.Now().Add(lifetime)}
+	}
+
+	close(call.done)
+}
@@ -1,15 +1,57 @@
 package gqlqueries
 
+import "encoding/json"
+
 const (
 	WorksByPK = `query($id: Int!) {
 	works_by_pk(id: $id) {
 		id
+		user_id
+		teacher_id
+		status
+	}
+}`
+
+	WorkNotification = `query($id: Int!) {
+	works_by_pk(id: $id) {
+		user_id
 		teacher_id
+		status
+		rejection_reason
+		feedback
+		user {
+			first_name
+			email
+		}
 	}
 }`
 
-	InsertWork = `mutation($content: String!, $status: work_status_enum!, $requestedTeacherID: Int) {
-	insert_works_one(object: {content: $content, status: $status, teacher_id: $requestedTeacherID}) {
+	TeacherAssociations = `query($studentID: Int!) {
+	teacher_student_associations(where: {student_id: {_eq: $studentID}, status: {_eq: "approved"}}) {
+		teacher_id
+	}
+}`
+
+	TransitionWork = `mutation($workID: Int!, $from: work_status_enum!, $set: works_set_input!) {
+	update_works(where: {id: {_eq: $workID}, status: {_eq: $from}}, _set: $set) {
+		returning {
+			id
+			status
+			teacher_id
+			updated_at
+			rejection_reason
+			feedback
+			user {
+				first_name
+				email
+			}
+		}
+	}
+}`
+
+	//nolint:lll
+	InsertWork = `mutation($content: String!, $status: work_status_enum!, $requestedTeacherID: Int, $spellingIssues: Int, $images: [work_images_insert_input!]!) {
+	insert_works_one(object: {content: $content, status: $status, teacher_id: $requestedTeacherID, spelling_issues: $spellingIssues, images: {data: $images}}) {
 		id
 	}
 }	
@@ -28,6 +70,11 @@ const (
   insert_users_one(object: {email: $email, auth0_id: $auth0ID, role: "teacher"}) {
     id
   }
+}`
+	InsertStudent = `mutation($email: citext!, $auth0ID: String!) {
+  insert_users_one(object: {email: $email, auth0_id: $auth0ID, role: "student"}) {
+    id
+  }
 }`
 	//nolint:lll
 	RegisterUser = `mutation($userID: Int!, $firstName: String!, $middleName: String, $lastName: String!, $schoolID: Int!) {
@@ -52,6 +99,489 @@ const (
 	}
 }`
 
+	WorkImage = `query($workID: Int!, $imageID: Int!) {
+	work_images(where: {id: {_eq: $imageID}, work_id: {_eq: $workID}}) {
+		content_type
+		size
+		storage_key
+	}
+}`
+
+	reviewLeaseFields = `
+		work_id
+		teacher_id
+		expires_at
+		teacher {
+			first_name
+			middle_name
+			last_name
+		}
+		work {
+			status
+			updated_at
+		}`
+
+	ReviewLeaseByPK = `query($workID: Int!) {
+	review_leases_by_pk(work_id: $workID) {` + reviewLeaseFields + `
+	}
+}`
+
+	ClaimReviewLease = `mutation($workID: Int!, $teacherID: Int!, $ttl: Int!) {
+	claim_review_lease(args: {workid: $workID, teacherid: $teacherID, ttl: $ttl}) {` + reviewLeaseFields + `
+	}
+}`
+
+	RenewReviewLease = `mutation($workID: Int!, $teacherID: Int!, $ttl: Int!) {
+	renew_review_lease(args: {workid: $workID, teacherid: $teacherID, ttl: $ttl}) {` + reviewLeaseFields + `
+	}
+}`
+
+	ExpireReviewLeases = `mutation {
+	expire_review_leases {
+		work_id
+	}
+}`
+
+	WorkContentByPK = `query($id: Int!) {
+	works_by_pk(id: $id) {
+		version
+		content
+	}
+}`
+
+	//nolint:lll
+	UpdateWorkContent = `mutation($workID: Int!, $version: Int!, $content: String!, $spellingIssues: Int, $images: jsonb!, $annotations: jsonb!) {
+	replace_work_content(args: {workid: $workID, expectedversion: $version, newcontent: $content, spellingissues: $spellingIssues, newimages: $images, newannotations: $annotations}) {
+		storage_key
+	}
+}`
+
+	annotationFields = `
+		id
+		work_id
+		user_id
+		version
+		start_offset
+		end_offset
+		body
+		outdated
+		resolved_by
+		resolved_at
+		created_at
+		updated_at
+		user {
+			first_name
+			middle_name
+			last_name
+		}`
+
+	Annotations = `query($workID: Int!) {
+	annotations(where: {work_id: {_eq: $workID}}, order_by: [{start_offset: asc}, {id: asc}]) {` + annotationFields + `
+	}
+}`
+
+	AnnotationByPK = `query($id: Int!) {
+	annotations_by_pk(id: $id) {` + annotationFields + `
+	}
+}`
+
+	InsertAnnotation = `mutation($object: annotations_insert_input!) {
+	insert_annotations_one(object: $object) {` + annotationFields + `
+	}
+}`
+
+	UpdateAnnotation = `mutation($id: Int!, $set: annotations_set_input!) {
+	update_annotations_by_pk(pk_columns: {id: $id}, _set: $set) {` + annotationFields + `
+	}
+}`
+
+	DeleteAnnotation = `mutation($id: Int!) {
+	delete_annotations_by_pk(id: $id) {
+		id
+	}
+}`
+
+	Rubrics = `query {
+	rubrics(order_by: {id: asc}) {
+		id
+		work_type
+		name
+		criteria(order_by: {position: asc}) {
+			id
+			key
+			name
+			description
+			max_score
+		}
+	}
+}`
+
+	workGradeFields = `
+		work_id
+		rubric_id
+		teacher_id
+		created_at
+		updated_at
+		scores {
+			criterion_id
+			score
+		}
+		work {
+			status
+			essay {
+				title {
+					name
+				}
+			}
+			characterization {
+				character {
+					name
+				}
+			}
+		}`
+
+	WorkGradeByPK = `query($workID: Int!) {
+	work_grades_by_pk(work_id: $workID) {` + workGradeFields + `
+	}
+}`
+
+	//nolint:lll
+	StudentGrades = `query($studentID: Int!, $limit: Int!, $offset: Int!) {
+	work_grades(where: {work: {user_id: {_eq: $studentID}}}, order_by: [{created_at: desc}, {work_id: desc}], limit: $limit, offset: $offset) {` + workGradeFields + `
+	}
+}`
+
+	WorkType = `query($workID: Int!) {
+	works_by_pk(id: $workID) {
+		essay {
+			work_id
+		}
+		characterization {
+			work_id
+		}
+	}
+}`
+
+	//nolint:lll
+	SaveWorkGrade = `mutation($workID: Int!, $grade: work_grades_insert_input!, $scores: [work_grade_scores_insert_input!]!) {
+	insert_work_grades_one(object: $grade, on_conflict: {constraint: work_grades_pkey, update_columns: [rubric_id, teacher_id, updated_at]}) {
+		work_id
+	}
+	delete_work_grade_scores(where: {work_id: {_eq: $workID}}) {
+		affected_rows
+	}
+	insert_work_grade_scores(objects: $scores) {
+		affected_rows
+	}
+}`
+
+	WorkAnalysis = `query($workID: Int!) {
+	works_by_pk(id: $workID) {
+		version
+		content
+	}
+	work_analyses_by_pk(work_id: $workID) {
+		version
+		analyzer_version
+		report
+	}
+}`
+
+	//nolint:lll
+	SaveWorkAnalysis = `mutation($analysis: work_analyses_insert_input!) {
+	insert_work_analyses_one(object: $analysis, on_conflict: {constraint: work_analyses_pkey, update_columns: [version, analyzer_version, report, created_at]}) {
+		work_id
+	}
+}`
+
+	//nolint:lll
+	WorksToSummarize = `query($where: works_bool_exp!, $limit: Int) {
+	works(where: $where, order_by: {id: asc}, limit: $limit) {
+		id
+		version
+		content
+	}
+}`
+
+	//nolint:lll
+	SaveWorkAbstracts = `mutation($abstracts: [work_abstracts_insert_input!]!) {
+	insert_work_abstracts(objects: $abstracts, on_conflict: {constraint: work_abstracts_pkey, update_columns: [version, summarizer_version, sentences, keywords, created_at]}) {
+		affected_rows
+	}
+}`
+
+	Tags = `query {
+	tags(order_by: {name: asc}) {
+		id
+		name
+		works_aggregate(where: {work: {status: {_eq: approved}}}) {
+			aggregate {
+				count
+			}
+		}
+	}
+}`
+
+	TagByPK = `query($id: Int!) {
+	tags_by_pk(id: $id) {
+		id
+		name
+	}
+}`
+
+	InsertTag = `mutation($name: String!) {
+	insert_tags_one(object: {name: $name}, on_conflict: {constraint: tags_name_key, update_columns: []}) {
+		id
+		name
+	}
+}`
+
+	DeleteTag = `mutation($id: Int!) {
+	delete_tags_by_pk(id: $id) {
+		id
+	}
+}`
+
+	WorkTags = `query($workID: Int!) {
+	work_tags(where: {work_id: {_eq: $workID}}, order_by: {tag: {name: asc}}) {
+		tag {
+			id
+			name
+		}
+	}
+}`
+
+	//nolint:lll
+	AddWorkTag = `mutation($workID: Int!, $tagID: Int!, $teacherID: Int!) {
+	insert_work_tags_one(object: {work_id: $workID, tag_id: $tagID, teacher_id: $teacherID}, on_conflict: {constraint: work_tags_pkey, update_columns: []}) {
+		work_id
+	}
+}`
+
+	RemoveWorkTag = `mutation($workID: Int!, $tagID: Int!) {
+	delete_work_tags_by_pk(work_id: $workID, tag_id: $tagID) {
+		work_id
+	}
+}`
+
+	TitleTagCounts = `query($titleID: Int!) {
+	title_tag_counts(where: {title_id: {_eq: $titleID}}, order_by: [{work_count: desc}, {name: asc}]) {
+		tag_id
+		name
+		work_count
+	}
+}`
+
+	ApprovedWorkContent = `query($id: Int!) {
+	works(where: {id: {_eq: $id}, status: {_eq: approved}}) {
+		content
+	}
+}`
+
+	//nolint:lll
+	RecordWorkView = `mutation($workID: Int!, $visitor: String!, $windowStart: timestamp!, $completion: float4!, $readThreshold: float4!) {
+	record_work_view(args: {workid: $workID, visitorid: $visitor, windowstart: $windowStart, newcompletion: $completion, readthreshold: $readThreshold}) {
+		work_id
+	}
+}`
+
+	ExpireWorkViews = `mutation($before: timestamp!) {
+	delete_work_views(where: {window_start: {_lt: $before}}) {
+		affected_rows
+	}
+}`
+
+	//nolint:lll
+	WorkViewStats = `query($where: work_view_stats_bool_exp!, $orderBy: [work_view_stats_order_by!], $limit: Int, $offset: Int) {
+	work_view_stats(where: $where, order_by: $orderBy, limit: $limit, offset: $offset) {
+		work_id
+		views
+		reads
+		week_views
+		week_reads
+		average_completion
+	}
+}`
+
+	TitleViewStats = `query($titleID: Int!) {
+	title_view_stats(where: {title_id: {_eq: $titleID}}) {
+		views
+		reads
+		week_views
+		week_reads
+	}
+}`
+
+	// workDescription selects the fields that describe a work in listings, without its content.
+	workDescription = `fragment workDescription on works {
+	id
+	version
+	user_id
+	teacher_id
+	user {
+		school_id
+	}
+	abstract {
+		sentences
+		keywords
+	}
+	tags {
+		tag_id
+	}
+	essay {
+		title {
+			id
+			name
+		}
+	}
+	characterization {
+		character {
+			id
+			name
+			title {
+				id
+				name
+			}
+		}
+	}
+}`
+
+	SearchDocuments = `query($where: works_bool_exp!, $limit: Int) {
+	works(where: $where, order_by: {id: asc}, limit: $limit) {
+		content
+		...workDescription
+	}
+}
+` + workDescription
+
+	//nolint:lll
+	SearchWorks = `query($args: search_works_args!, $limit: Int!, $offset: Int!) {
+	search_works(args: $args, order_by: [{score: desc}, {work_id: desc}], limit: $limit, offset: $offset) {
+		score
+		headline
+		work {
+			...workDescription
+		}
+	}
+	search_works_aggregate(args: $args) {
+		aggregate {
+			count
+		}
+	}
+}
+` + workDescription
+
+	SimilarWorks = `query($workID: Int!, $limit: Int!) {
+	similar_works(args: {workid: $workID}, order_by: [{score: desc}, {work_id: desc}], limit: $limit) {
+		score
+		work {
+			...workDescription
+		}
+	}
+}
+` + workDescription
+
+	//nolint:lll
+	ReadingHistory = `query($userID: Int!, $limit: Int!, $offset: Int!) {
+	reading_history(where: {user_id: {_eq: $userID}}, order_by: [{updated_at: desc}, {work_id: desc}], limit: $limit, offset: $offset) {
+		version
+		paragraph
+		progress
+		updated_at
+		work {
+			...workDescription
+		}
+	}
+	reading_history_aggregate(where: {user_id: {_eq: $userID}}) {
+		aggregate {
+			count
+		}
+	}
+}
+` + workDescription
+
+	ReadingPosition = `query($userID: Int!, $workID: Int!) {
+	reading_history_by_pk(user_id: $userID, work_id: $workID) {
+		version
+		paragraph
+		progress
+		updated_at
+	}
+}`
+
+	//nolint:lll
+	SaveReadingPosition = `mutation($position: reading_history_insert_input!) {
+	insert_reading_history_one(object: $position, on_conflict: {constraint: reading_history_pkey, update_columns: [version, paragraph, progress, updated_at]}) {
+		version
+		paragraph
+		progress
+		updated_at
+	}
+}`
+
+	ClearReadingHistory = `mutation($where: reading_history_bool_exp!) {
+	delete_reading_history(where: $where) {
+		affected_rows
+	}
+}`
+
+	// accessToken selects the fields the users see about their personal access tokens. The hash is never selected.
+	accessToken = `fragment accessToken on access_tokens {
+	id
+	name
+	prefix
+	scopes
+	created_at
+	expires_at
+	last_used_at
+}`
+
+	//nolint:lll
+	AuthenticateAccessToken = `mutation($hash: String!) {
+	authenticate_access_token(args: {tokenhash: $hash}) {
+		id
+		scopes
+		user {
+			id
+			role
+			updated_at
+		}
+	}
+}`
+
+	InsertAccessToken = `mutation($token: access_tokens_insert_input!) {
+	insert_access_tokens_one(object: $token) {
+		...accessToken
+	}
+}
+` + accessToken
+
+	//nolint:lll
+	AccessTokens = `query($userID: Int!) {
+	access_tokens(where: {user_id: {_eq: $userID}, revoked_at: {_is_null: true}, expires_at: {_gt: "now"}}, order_by: {created_at: desc}) {
+		...accessToken
+	}
+}
+` + accessToken
+
+	RevokeAccessToken = `mutation($id: Int!, $userID: Int!) {
+	update_access_tokens(where: {id: {_eq: $id}, user_id: {_eq: $userID}, revoked_at: {_is_null: true}}, _set: {revoked_at: "now"}) {
+		affected_rows
+	}
+}`
+
+	TokensValidAfter = `query($id: Int!) {
+	users_all_by_pk(id: $id) {
+		tokens_valid_after
+	}
+}`
+
+	Auth0ID = `query($id: Int!) {
+	users_all_by_pk(id: $id) {
+		auth0_id
+	}
+}`
+
 	User = `query getUser($id: Int!) {
 	users(where: {id: {_eq: $id}}) {
 		updated_at
@@ -80,16 +610,454 @@ type InsertTeacherOutput struct {
 	} `json:"insert_users_one"`
 }
 
+type InsertStudentOutput = InsertTeacherOutput
+
 type WorksByPKOutput struct {
 	Query struct {
-		ID        int `json:"id"`
-		TeacherID int `json:"teacher_id"`
+		ID        int    `json:"id"`
+		UserID    int    `json:"user_id"`
+		TeacherID int    `json:"teacher_id"`
+		Status    string `json:"status"`
 	} `json:"works_by_pk"`
 }
 
+type WorkNotificationOutput struct {
+	Query *struct {
+		UserID          int     `json:"user_id"`
+		TeacherID       int     `json:"teacher_id"`
+		Status          string  `json:"status"`
+		RejectionReason *string `json:"rejection_reason"`
+		Feedback        *string `json:"feedback"`
+		User            *struct {
+			FirstName *string `json:"first_name"`
+			Email     *string `json:"email"`
+		} `json:"user"`
+	} `json:"works_by_pk"`
+}
+
+type TeacherAssociationsOutput struct {
+	Query []struct {
+		TeacherID int `json:"teacher_id"`
+	} `json:"teacher_student_associations"`
+}
+
+type TransitionWorkOutput struct {
+	Query struct {
+		Returning []struct {
+			ID              int     `json:"id"`
+			Status          string  `json:"status"`
+			TeacherID       *int    `json:"teacher_id"`
+			UpdatedAt       *string `json:"updated_at"`
+			RejectionReason *string `json:"rejection_reason"`
+			Feedback        *string `json:"feedback"`
+			User            *struct {
+				FirstName *string `json:"first_name"`
+				Email     *string `json:"email"`
+			} `json:"user"`
+		} `json:"returning"`
+	} `json:"update_works"`
+}
+
+type WorkImageOutput struct {
+	Query []struct {
+		ContentType string `json:"content_type"`
+		Size        int    `json:"size"`
+		StorageKey  string `json:"storage_key"`
+	} `json:"work_images"`
+}
+
+type ReviewLease struct {
+	WorkID    int    `json:"work_id"`
+	TeacherID int    `json:"teacher_id"`
+	ExpiresAt string `json:"expires_at"`
+	Teacher   *struct {
+		FirstName  *string `json:"first_name"`
+		MiddleName *string `json:"middle_name"`
+		LastName   *string `json:"last_name"`
+	} `json:"teacher"`
+	Work struct {
+		Status    string  `json:"status"`
+		UpdatedAt *string `json:"updated_at"`
+	} `json:"work"`
+}
+
+type ReviewLeaseByPKOutput struct {
+	Query *ReviewLease `json:"review_leases_by_pk"`
+}
+
+type ClaimReviewLeaseOutput struct {
+	Query []ReviewLease `json:"claim_review_lease"`
+}
+
+type RenewReviewLeaseOutput struct {
+	Query []ReviewLease `json:"renew_review_lease"`
+}
+
+type ExpireReviewLeasesOutput struct {
+	Query []struct {
+		WorkID int `json:"work_id"`
+	} `json:"expire_review_leases"`
+}
+
 type UserOutput struct {
 	Query []struct {
 		UpdatedAt *string `json:"updated_at"`
 		Role      string  `json:"role"`
 	} `json:"users"`
 }
+
+type WorkContent struct {
+	Version int    `json:"version"`
+	Content string `json:"content"`
+}
+
+type WorkContentOutput struct {
+	Query *WorkContent `json:"works_by_pk"`
+}
+
+type UpdateWorkContentOutput struct {
+	// RemovedImages are the images of the previous version of the content.
+	RemovedImages []struct {
+		StorageKey string `json:"storage_key"`
+	} `json:"replace_work_content"`
+}
+
+type Annotation struct {
+	ID          int     `json:"id"`
+	WorkID      int     `json:"work_id"`
+	UserID      int     `json:"user_id"`
+	Version     int     `json:"version"`
+	StartOffset int     `json:"start_offset"`
+	EndOffset   int     `json:"end_offset"`
+	Body        string  `json:"body"`
+	Outdated    bool    `json:"outdated"`
+	ResolvedBy  *int    `json:"resolved_by"`
+	ResolvedAt  *string `json:"resolved_at"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   *string `json:"updated_at"`
+	User        *struct {
+		FirstName  *string `json:"first_name"`
+		MiddleName *string `json:"middle_name"`
+		LastName   *string `json:"last_name"`
+	} `json:"user"`
+}
+
+type AnnotationsOutput struct {
+	Query []Annotation `json:"annotations"`
+}
+
+type AnnotationByPKOutput struct {
+	Query *Annotation `json:"annotations_by_pk"`
+}
+
+type InsertAnnotationOutput struct {
+	Query Annotation `json:"insert_annotations_one"`
+}
+
+type UpdateAnnotationOutput struct {
+	Query *Annotation `json:"update_annotations_by_pk"`
+}
+
+type DeleteAnnotationOutput struct {
+	Query *struct {
+		ID int `json:"id"`
+	} `json:"delete_annotations_by_pk"`
+}
+
+type Rubric struct {
+	ID       int    `json:"id"`
+	WorkType string `json:"work_type"`
+	Name     string `json:"name"`
+	Criteria []struct {
+		ID          int    `json:"id"`
+		Key         string `json:"key"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		MaxScore    int    `json:"max_score"`
+	} `json:"criteria"`
+}
+
+type RubricsOutput struct {
+	Query []Rubric `json:"rubrics"`
+}
+
+type subjectName struct {
+	Name string `json:"name"`
+}
+
+type WorkGrade struct {
+	WorkID    int     `json:"work_id"`
+	RubricID  int     `json:"rubric_id"`
+	TeacherID *int    `json:"teacher_id"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt *string `json:"updated_at"`
+	Scores    []struct {
+		CriterionID int `json:"criterion_id"`
+		Score       int `json:"score"`
+	} `json:"scores"`
+	Work struct {
+		Status string `json:"status"`
+		Essay  *struct {
+			Title subjectName `json:"title"`
+		} `json:"essay"`
+		Characterization *struct {
+			Character subjectName `json:"character"`
+		} `json:"characterization"`
+	} `json:"work"`
+}
+
+type WorkGradeOutput struct {
+	Query *WorkGrade `json:"work_grades_by_pk"`
+}
+
+type StudentGradesOutput struct {
+	Query []WorkGrade `json:"work_grades"`
+}
+
+type WorkTypeOutput struct {
+	Query *struct {
+		Essay *struct {
+			WorkID int `json:"work_id"`
+		} `json:"essay"`
+		Characterization *struct {
+			WorkID int `json:"work_id"`
+		} `json:"characterization"`
+	} `json:"works_by_pk"`
+}
+
+type WorkAnalysisOutput struct {
+	Work     *WorkContent `json:"works_by_pk"`
+	Analysis *struct {
+		Version         int             `json:"version"`
+		AnalyzerVersion int             `json:"analyzer_version"`
+		Report          json.RawMessage `json:"report"`
+	} `json:"work_analyses_by_pk"`
+}
+
+type WorksToSummarizeOutput struct {
+	Query []struct {
+		ID int `json:"id"`
+		WorkContent
+	} `json:"works"`
+}
+
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type TagsOutput struct {
+	Query []struct {
+		Tag
+		Works struct {
+			Aggregate struct {
+				Count int `json:"count"`
+			} `json:"aggregate"`
+		} `json:"works_aggregate"`
+	} `json:"tags"`
+}
+
+type TagByPKOutput struct {
+	Query *Tag `json:"tags_by_pk"`
+}
+
+type InsertTagOutput struct {
+	Query *Tag `json:"insert_tags_one"`
+}
+
+type DeleteTagOutput struct {
+	Query *struct {
+		ID int `json:"id"`
+	} `json:"delete_tags_by_pk"`
+}
+
+type WorkTagsOutput struct {
+	Query []struct {
+		Tag Tag `json:"tag"`
+	} `json:"work_tags"`
+}
+
+type RemoveWorkTagOutput struct {
+	Query *struct {
+		WorkID int `json:"work_id"`
+	} `json:"delete_work_tags_by_pk"`
+}
+
+type TitleTagCountsOutput struct {
+	Query []struct {
+		TagID     int    `json:"tag_id"`
+		Name      string `json:"name"`
+		WorkCount int    `json:"work_count"`
+	} `json:"title_tag_counts"`
+}
+
+type ApprovedWorkContentOutput struct {
+	Query []struct {
+		Content string `json:"content"`
+	} `json:"works"`
+}
+
+type ExpireWorkViewsOutput struct {
+	Query struct {
+		AffectedRows int `json:"affected_rows"`
+	} `json:"delete_work_views"`
+}
+
+type ViewStats struct {
+	Views     int `json:"views"`
+	Reads     int `json:"reads"`
+	WeekViews int `json:"week_views"`
+	WeekReads int `json:"week_reads"`
+}
+
+type WorkViewStat struct {
+	WorkID int `json:"work_id"`
+	ViewStats
+	AverageCompletion float64 `json:"average_completion"`
+}
+
+type WorkViewStatsOutput struct {
+	Query []WorkViewStat `json:"work_view_stats"`
+}
+
+type TitleViewStatsOutput struct {
+	Query []ViewStats `json:"title_view_stats"`
+}
+
+type WorkPosition struct {
+	Version   int     `json:"version"`
+	Paragraph int     `json:"paragraph"`
+	Progress  float64 `json:"progress"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+type ReadingHistoryOutput struct {
+	Query []struct {
+		WorkPosition
+		Work *SearchDocument `json:"work"`
+	} `json:"reading_history"`
+	Aggregate struct {
+		Aggregate struct {
+			Count int `json:"count"`
+		} `json:"aggregate"`
+	} `json:"reading_history_aggregate"`
+}
+
+type ReadingPositionOutput struct {
+	Query *WorkPosition `json:"reading_history_by_pk"`
+}
+
+type SaveReadingPositionOutput struct {
+	Query WorkPosition `json:"insert_reading_history_one"`
+}
+
+type AuthenticateAccessTokenOutput struct {
+	Query []struct {
+		ID     int      `json:"id"`
+		Scopes []string `json:"scopes"`
+		// User is nil if the user deleted their account.
+		User *struct {
+			ID        int     `json:"id"`
+			Role      string  `json:"role"`
+			UpdatedAt *string `json:"updated_at"`
+		} `json:"user"`
+	} `json:"authenticate_access_token"`
+}
+
+type AccessToken struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+}
+
+type InsertAccessTokenOutput struct {
+	Query AccessToken `json:"insert_access_tokens_one"`
+}
+
+type AccessTokensOutput struct {
+	Query []AccessToken `json:"access_tokens"`
+}
+
+type RevokeAccessTokenOutput struct {
+	Query struct {
+		AffectedRows int `json:"affected_rows"`
+	} `json:"update_access_tokens"`
+}
+
+type TokensValidAfterOutput struct {
+	Query *struct {
+		TokensValidAfter *string `json:"tokens_valid_after"`
+	} `json:"users_all_by_pk"`
+}
+
+type Auth0IDOutput struct {
+	Query *struct {
+		Auth0ID string `json:"auth0_id"`
+	} `json:"users_all_by_pk"`
+}
+
+type ClearReadingHistoryOutput struct {
+	Query struct {
+		AffectedRows int `json:"affected_rows"`
+	} `json:"delete_reading_history"`
+}
+
+type subject struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type SearchDocument struct {
+	ID        int    `json:"id"`
+	Version   int    `json:"version"`
+	Content   string `json:"content"`
+	UserID    int    `json:"user_id"`
+	TeacherID *int   `json:"teacher_id"`
+	User      *struct {
+		SchoolID *int `json:"school_id"`
+	} `json:"user"`
+	Abstract *struct {
+		Sentences []string `json:"sentences"`
+		Keywords  []string `json:"keywords"`
+	} `json:"abstract"`
+	Tags []struct {
+		TagID int `json:"tag_id"`
+	} `json:"tags"`
+	Essay *struct {
+		Title subject `json:"title"`
+	} `json:"essay"`
+	Characterization *struct {
+		Character struct {
+			subject
+			Title subject `json:"title"`
+		} `json:"character"`
+	} `json:"characterization"`
+}
+
+type SearchDocumentsOutput struct {
+	Query []SearchDocument `json:"works"`
+}
+
+// WorkSearchResult is a work found by a search, with its relevance.
+type WorkSearchResult struct {
+	Score    float64        `json:"score"`
+	Headline *string        `json:"headline"`
+	Work     SearchDocument `json:"work"`
+}
+
+type SearchWorksOutput struct {
+	Query     []WorkSearchResult `json:"search_works"`
+	Aggregate struct {
+		Aggregate struct {
+			Count int `json:"count"`
+		} `json:"aggregate"`
+	} `json:"search_works_aggregate"`
+}
+
+type SimilarWorksOutput struct {
+	Query []WorkSearchResult `json:"similar_works"`
+}
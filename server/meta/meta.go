@@ -21,14 +21,45 @@ Obtaining the Apache Tika endpoint:
 
 	endpoint := meta.TikaEndpoint
 
+Obtaining the directory where uploaded objects (such as images) are stored, if no object store is configured:
+
+	root := meta.StoragePath
+
+Obtaining the bucket of the object store where uploaded objects are stored (required when deployed):
+
+	bucket, endpoint := meta.S3Bucket, meta.S3Endpoint
+
+Obtaining the path of the Hunspell dictionary used to check the spelling of works,
+without the .aff and .dic extensions (spelling is not checked if it is empty):
+
+	path := meta.SpellingDictionary
+
+Obtaining the directory of the style rules (the default ones are used if it is empty):
+
+	dir := meta.StyleRulesPath
+
+Obtaining the key used to hash the identities of the works' visitors (a random one is used if it is empty):
+
+	secret := meta.AnalyticsSecret
+
+Obtaining the file with the keys the tokens are verified with, if the JWT secret doesn't have a key set URL:
+
+	path := meta.JWKSPath
+
 Obtaining the endpoint of the application's client (for configuring CORS, for example):
 
 	clientURL := meta.URL()
+
+Obtaining the public address of the site, used in the links sent by email:
+
+	link := meta.SiteURL + "/work/1"
 */
 package meta
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/FiveIT/eseuri/server/meta/auth0"
 	"github.com/rs/zerolog/log"
@@ -47,7 +78,10 @@ var (
 	IsDevelopment = context == "development" || !IsProduction
 	// IsDeployPreview specifies if Netlify built the site for pull/merge request preview.
 	IsDeployPreview = context == "preview"
-	// FunctionsBasePath is the location of the function handler when deployed to Netlify.
+	// IsServerless specifies if the app runs as a serverless function, on Netlify or Vercel. The functions'
+	// disks are ephemeral and not shared by their instances.
+	IsServerless = os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != ""
+	// FunctionsBasePath is the location of the function handler when it runs as a serverless function.
 	FunctionsBasePath = "/api"
 	// TikaEndpoint is the endpoint used to connect to the Apache Tika service.
 	TikaEndpoint = os.Getenv("TIKA_URL")
@@ -57,6 +91,41 @@ var (
 	HasuraAdminSecret = os.Getenv("HASURA_GRAPHQL_ADMIN_SECRET")
 	// HasuraJWTSecret is required for verifying the tokens used to authorize to the service.
 	HasuraJWTSecret = os.Getenv("HASURA_GRAPHQL_JWT_SECRET")
+	// JWKSPath is a file with the JSON Web Key Set the tokens are verified with, used
+	// when the JWT secret has no "jwk_url". The secret's "key" is used as a fallback.
+	JWKSPath = os.Getenv("JWKS_PATH")
+	// DevJWTKey is the PEM encoded private key of the offline token issuer used in development and tests.
+	DevJWTKey = os.Getenv("DEV_JWT_PRIVATE_KEY")
+	// TrustDevIssuer specifies if the tokens of the offline issuer are accepted. Anyone can issue them,
+	// so it must be enabled explicitly, and only in development and tests.
+	TrustDevIssuer = os.Getenv("TRUST_DEV_ISSUER") == "1"
+	// StoragePath is the directory in which the filesystem storage backend keeps objects.
+	StoragePath = getenv("STORAGE_PATH", filepath.Join(os.TempDir(), "eseuri"))
+	// S3Bucket is the bucket of the S3-compatible object store the objects are kept in. The filesystem backend
+	// is used if it's empty, which is only allowed outside of production and of serverless deployments.
+	S3Bucket = os.Getenv("S3_BUCKET")
+	// S3Endpoint is the URL of the object store, like "https://s3.eu-central-1.amazonaws.com".
+	S3Endpoint = os.Getenv("S3_ENDPOINT")
+	// S3Region is the region of the bucket, which is part of the requests' signatures.
+	S3Region = getenv("S3_REGION", "us-east-1")
+	// S3AccessKeyID and S3SecretAccessKey are the credentials of the object store.
+	S3AccessKeyID     = os.Getenv("S3_ACCESS_KEY_ID")
+	S3SecretAccessKey = os.Getenv("S3_SECRET_ACCESS_KEY")
+	// SpellingDictionary is the path of the Hunspell dictionary, without the file extensions.
+	SpellingDictionary = os.Getenv("SPELLING_DICTIONARY")
+	// StyleRulesPath is the directory of the style linter's rule files.
+	StyleRulesPath = os.Getenv("STYLE_RULES_PATH")
+	// AnalyticsSecret is the key of the hashes that identify the works' visitors.
+	AnalyticsSecret = os.Getenv("ANALYTICS_SECRET")
+	// TrustedProxies are the comma separated addresses or CIDR ranges of the proxies whose X-Forwarded-For
+	// header is trusted. The serverless platforms' proxies are always trusted.
+	TrustedProxies = os.Getenv("TRUSTED_PROXIES")
+	// RedisURL is the Redis-compatible server the rate limits are kept in, shared by all the instances
+	// of the service. If it's empty, each instance keeps its own rate limits in memory.
+	RedisURL = os.Getenv("REDIS_URL")
+	// SiteURL is the public address of the site, with its scheme, which the links sent by email point to.
+	// The deployments' own address is used if it's empty, which may be a preview deployment's one.
+	SiteURL = strings.TrimSuffix(getenv("SITE_URL", defaultSiteURL()), "/")
 	// Sendgrid API key to send emails.
 	SendgridKey = os.Getenv("SENDGRID_KEY")
 	// Auth0 holds the required credentials to use the Auth0 authentication service.
@@ -65,9 +134,26 @@ var (
 		ClientID:     os.Getenv("VITE_AUTH0_CLIENT_ID"),
 		ClientSecret: os.Getenv("AUTH0_CLIENT_SECRET"),
 		Audience:     os.Getenv("VITE_AUTH0_AUDIENCE"),
+		HTTPClient:   nil,
 	}
 )
 
+func getenv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func defaultSiteURL() string {
+	if IsNetlify {
+		return "https://" + url
+	}
+
+	return "http://localhost:3000"
+}
+
 // URL returns the addres at which the client app exists.
 func URL() string {
 	ret := "http://localhost:3000"
@@ -78,5 +164,5 @@ func URL() string {
 }
 
 func init() {
-	log.Info().Str("context", context).Str("client_url", URL()).Msg("Meta")
+	log.Info().Str("context", context).Str("client_url", URL()).Str("site_url", SiteURL).Msg("Meta")
 }
@@ -10,6 +10,7 @@ const (
 
 	DOC  = a + "msword"
 	DOCX = a + "vnd.openxmlformats-officedocument.wordprocessingml.document"
+	GIF  = i + "gif"
 	JPEG = i + "jpeg"
 	ODT  = a + "vnd.oasis.opendocument.text"
 	PNG  = i + "png"
@@ -0,0 +1,343 @@
+/*
+Package redis is a minimal client for Redis and the servers compatible with it, which speak RESP.
+
+It implements only what the service needs: sending commands, reading their replies, and running
+Lua scripts. Connections are reused across commands, and replies are decoded to strings, integers,
+slices of replies or nil.
+*/
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultTimeout is how long a command may take, if its context has no deadline.
+	defaultTimeout = 5 * time.Second
+	// maxIdle is the number of connections kept open between commands.
+	maxIdle = 8
+	// maxBulkLength is the largest bulk string accepted in a reply, as in Redis.
+	maxBulkLength = 512 << 20
+)
+
+var (
+	ErrClosed   = errors.New("redis: client is closed")
+	ErrURL      = errors.New("redis: invalid URL")
+	ErrProtocol = errors.New("redis: invalid reply")
+)
+
+// Error is an error reply sent by the server. The connection can still be used after one.
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Prefix returns the first word of the error, which is its kind, like "NOSCRIPT" or "WRONGTYPE".
+func (e Error) Prefix() string {
+	if i := strings.IndexByte(string(e), ' '); i >= 0 {
+		return string(e[:i])
+	}
+
+	return string(e)
+}
+
+// Client sends commands to a server. It is safe for concurrent use.
+type Client struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      *tls.Config
+	idle     chan *conn
+	closed   chan struct{}
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// New creates a client for the server at the given URL, of the form redis://[[user]:password@]host[:port][/db].
+// TLS is used for the rediss scheme. No connection is made until the first command is sent.
+func New(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrURL, err)
+	}
+
+	if u.Scheme != "redis" && u.Scheme != "rediss" || u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: %q", ErrURL, rawURL)
+	}
+
+	c := &Client{
+		addr:     u.Host,
+		username: u.User.Username(),
+		password: "",
+		db:       0,
+		tls:      nil,
+		idle:     make(chan *conn, maxIdle),
+		closed:   make(chan struct{}),
+	}
+
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	if password, ok := u.User.Password(); ok {
+		c.password = password
+	}
+
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil || c.db < 0 {
+			return nil, fmt.Errorf("%w: invalid database %q", ErrURL, db)
+		}
+	}
+
+	if u.Scheme == "rediss" {
+		//nolint:exhaustivestruct
+		c.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+
+	return c, nil
+}
+
+// Do sends the command and returns its reply: a string, an int64, a []interface{} of replies, or nil.
+// Error replies are returned as an Error.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(ctx, args)
+
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		_ = cn.Close()
+
+		return nil, err
+	}
+
+	c.put(cn)
+
+	return reply, err
+}
+
+// Close closes the idle connections. The commands sent afterwards fail.
+func (c *Client) Close() error {
+	select {
+	case <-c.closed:
+		return nil
+	default:
+		close(c.closed)
+	}
+
+	for {
+		select {
+		case cn := <-c.idle:
+			_ = cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// get returns an idle connection, or opens a new one.
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case <-c.closed:
+		return nil, ErrClosed
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+		return c.dial(ctx)
+	}
+}
+
+// put keeps the connection for the next commands, or closes it if enough are kept already.
+func (c *Client) put(cn *conn) {
+	select {
+	case <-c.closed:
+		_ = cn.Close()
+	case c.idle <- cn:
+	default:
+		_ = cn.Close()
+	}
+}
+
+// dial opens a connection, authenticates and selects the database.
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	var (
+		nc  net.Conn
+		err error
+	)
+
+	//nolint:exhaustivestruct
+	dialer := &net.Dialer{Timeout: defaultTimeout}
+
+	if c.tls != nil {
+		//nolint:exhaustivestruct
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: c.tls}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.addr)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("redis: failed to connect to %s: %w", c.addr, err)
+	}
+
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	var setup [][]string
+
+	if c.password != "" {
+		if c.username != "" {
+			setup = append(setup, []string{"AUTH", c.username, c.password})
+		} else {
+			setup = append(setup, []string{"AUTH", c.password})
+		}
+	}
+
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+
+	for _, args := range setup {
+		if _, err := cn.do(ctx, args); err != nil {
+			_ = cn.Close()
+
+			return nil, fmt.Errorf("redis: failed to %s: %w", strings.ToLower(args[0]), err)
+		}
+	}
+
+	return cn, nil
+}
+
+// do sends a command on the connection and reads its reply.
+func (cn *conn) do(ctx context.Context, args []string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("redis: failed to set deadline: %w", err)
+	}
+
+	if err := writeCommand(cn.w, args); err != nil {
+		return nil, fmt.Errorf("redis: failed to send command: %w", err)
+	}
+
+	return readReply(cn.r)
+}
+
+// writeCommand writes the command as an array of bulk strings.
+func writeCommand(w *bufio.Writer, args []string) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	return w.Flush()
+}
+
+// readReply reads a reply. Error replies are returned as errors, after the whole reply is read.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if line == "" {
+		return nil, ErrProtocol
+	}
+
+	switch kind, rest := line[0], line[1:]; kind {
+	case '+':
+		return rest, nil
+	case '-':
+		return nil, Error(rest)
+	case ':':
+		n, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid integer %q", ErrProtocol, rest)
+		}
+
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < -1 || n > maxBulkLength {
+			return nil, fmt.Errorf("%w: invalid bulk length %q", ErrProtocol, rest)
+		}
+
+		if n == -1 {
+			return nil, nil
+		}
+
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, fmt.Errorf("redis: failed to read reply: %w", err)
+		}
+
+		return string(b[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("%w: invalid array length %q", ErrProtocol, rest)
+		}
+
+		if n == -1 {
+			return nil, nil
+		}
+
+		return readArray(r, n)
+	}
+
+	return nil, fmt.Errorf("%w: unknown type %q", ErrProtocol, line[0])
+}
+
+// readArray reads the elements of an array reply. Error replies inside of it are kept as elements.
+func readArray(r *bufio.Reader, n int) ([]interface{}, error) {
+	values := make([]interface{}, 0, n)
+
+	for i := 0; i < n; i++ {
+		v, err := readReply(r)
+
+		var replyErr Error
+		if errors.As(err, &replyErr) {
+			v = replyErr
+		} else if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("redis: failed to read reply: %w", err)
+	}
+
+	if !strings.HasSuffix(line, "\r\n") {
+		return "", fmt.Errorf("%w: line doesn't end with CRLF", ErrProtocol)
+	}
+
+	return line[:len(line)-2], nil
+}
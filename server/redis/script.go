@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+// Script is a Lua script that runs atomically on the server. It is sent only if the server
+// doesn't have it cached already.
+type Script struct {
+	src  string
+	hash string
+}
+
+// NewScript creates a script with the given source.
+func NewScript(src string) *Script {
+	//nolint:gosec
+	sum := sha1.Sum([]byte(src))
+
+	return &Script{src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Run runs the script with the given keys and arguments, and returns its reply.
+func (s *Script) Run(ctx context.Context, c *Client, keys []string, args ...string) (interface{}, error) {
+	reply, err := c.Do(ctx, s.command("EVALSHA", s.hash, keys, args)...)
+
+	var replyErr Error
+	if errors.As(err, &replyErr) && replyErr.Prefix() == "NOSCRIPT" {
+		return c.Do(ctx, s.command("EVAL", s.src, keys, args)...)
+	}
+
+	return reply, err
+}
+
+func (s *Script) command(name, script string, keys, args []string) []string {
+	cmd := make([]string, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, name, script, strconv.Itoa(len(keys)))
+	cmd = append(cmd, keys...)
+
+	return append(cmd, args...)
+}
+
+// Hash returns the SHA1 hash the server caches the script by.
+func (s *Script) Hash() string {
+	return s.hash
+}
@@ -0,0 +1,94 @@
+/*
+Package rubric implements the grading of works against rubrics, like the
+ones used at the Romanian language and literature BAC exam. Each work type
+has a rubric, made of criteria that are scored independently, up to a
+maximum score. The grade of a work is the sum of its criteria scores.
+*/
+package rubric
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMissingScore     = errors.New("rubric: criterion not scored")
+	ErrUnknownCriterion = errors.New("rubric: unknown criterion")
+	ErrScoreOutOfRange  = errors.New("rubric: score out of range")
+)
+
+// Criterion is a single aspect of a work that is scored, such as the use of quotations.
+type Criterion struct {
+	ID int
+	// Key identifies the criterion in its rubric. It doesn't change if the criterion is renamed.
+	Key         string
+	Name        string
+	Description string
+	MaxScore    int
+}
+
+// Rubric is the grading template of a work type.
+type Rubric struct {
+	ID       int
+	WorkType string
+	Name     string
+	Criteria []Criterion
+}
+
+// Scores maps the keys of the rubric's criteria to their scores.
+type Scores map[string]int
+
+// MaxScore returns the highest total score a work can get.
+func (r Rubric) MaxScore() int {
+	total := 0
+	for _, c := range r.Criteria {
+		total += c.MaxScore
+	}
+
+	return total
+}
+
+// Criterion returns the criterion with the given key, if the rubric has it.
+func (r Rubric) Criterion(key string) (Criterion, bool) {
+	for _, c := range r.Criteria {
+		if c.Key == key {
+			return c, true
+		}
+	}
+
+	return Criterion{}, false
+}
+
+// Validate checks that all the rubric's criteria are scored, that there
+// are no scores for other criteria and that all the scores are in range.
+func (r Rubric) Validate(s Scores) error {
+	for key, score := range s {
+		c, ok := r.Criterion(key)
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCriterion, key)
+		}
+
+		if score < 0 || score > c.MaxScore {
+			return fmt.Errorf("%w: %q must be between 0 and %d, got %d", ErrScoreOutOfRange, key, c.MaxScore, score)
+		}
+	}
+
+	for _, c := range r.Criteria {
+		if _, ok := s[c.Key]; !ok {
+			return fmt.Errorf("%w: %q", ErrMissingScore, c.Key)
+		}
+	}
+
+	return nil
+}
+
+// Total returns the sum of the scores of the rubric's criteria.
+// Scores for criteria that aren't in the rubric are ignored.
+func (r Rubric) Total(s Scores) int {
+	total := 0
+	for _, c := range r.Criteria {
+		total += s[c.Key]
+	}
+
+	return total
+}
@@ -0,0 +1,82 @@
+package rubric_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/rubric"
+)
+
+//nolint:gochecknoglobals
+var essay = rubric.Rubric{
+	ID:       1,
+	WorkType: "essay",
+	Name:     "Eseu",
+	Criteria: []rubric.Criterion{
+		{ID: 1, Key: "argumentStructure", Name: "Structura argumentării", MaxScore: 6},
+		{ID: 2, Key: "quotations", Name: "Citate", MaxScore: 4},
+		{ID: 3, Key: "wordCount", Name: "Numărul de cuvinte", MaxScore: 1},
+	},
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Name     string
+		Scores   rubric.Scores
+		Expected error
+	}
+
+	tests := []testCase{
+		{
+			Name:   "All criteria scored",
+			Scores: rubric.Scores{"argumentStructure": 5, "quotations": 0, "wordCount": 1},
+		},
+		{
+			Name:     "Criterion not scored",
+			Scores:   rubric.Scores{"argumentStructure": 5, "quotations": 4},
+			Expected: rubric.ErrMissingScore,
+		},
+		{
+			Name:     "Unknown criterion",
+			Scores:   rubric.Scores{"argumentStructure": 5, "quotations": 4, "wordCount": 1, "style": 2},
+			Expected: rubric.ErrUnknownCriterion,
+		},
+		{
+			Name:     "Score too high",
+			Scores:   rubric.Scores{"argumentStructure": 7, "quotations": 4, "wordCount": 1},
+			Expected: rubric.ErrScoreOutOfRange,
+		},
+		{
+			Name:     "Negative score",
+			Scores:   rubric.Scores{"argumentStructure": 5, "quotations": -1, "wordCount": 1},
+			Expected: rubric.ErrScoreOutOfRange,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := essay.Validate(test.Scores)
+			if test.Expected == nil && err != nil || !errors.Is(err, test.Expected) {
+				t.Fatalf("Expected error %v, got %v", test.Expected, err)
+			}
+		})
+	}
+}
+
+func TestTotal(t *testing.T) {
+	t.Parallel()
+
+	if max := essay.MaxScore(); max != 11 {
+		t.Fatalf("Expected maximum score 11, got %d", max)
+	}
+
+	if total := essay.Total(rubric.Scores{"argumentStructure": 5, "quotations": 3, "wordCount": 1}); total != 9 {
+		t.Fatalf("Expected total 9, got %d", total)
+	}
+}
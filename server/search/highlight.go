@@ -0,0 +1,73 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// The marks of the headlines built by the database's search_works function.
+const (
+	// MatchStart and MatchEnd enclose the matches of the search in a headline.
+	MatchStart = "\ue000"
+	MatchEnd   = "\ue001"
+	// FragmentDelimiter separates the fragments of a headline.
+	FragmentDelimiter = "\ue002"
+)
+
+// Match is a part of a highlight that matches the query, at the rune offsets [Start, End) of the highlight.
+type Match struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Highlight is a fragment of a document's content that contains matches of the query.
+type Highlight struct {
+	Text    string  `json:"text"`
+	Matches []Match `json:"matches"`
+}
+
+// Highlights returns the fragments of the headline the database built for a found work. The matches are
+// the words the database matched the search by, so they're highlighted exactly as they were searched.
+// Fragments without matches are skipped.
+func Highlights(headline string) []Highlight {
+	highlights := make([]Highlight, 0)
+
+	for _, fragment := range strings.Split(headline, FragmentDelimiter) {
+		var (
+			sb strings.Builder
+			h  Highlight
+		)
+
+		for offset := 0; fragment != ""; {
+			i := strings.Index(fragment, MatchStart)
+			if i == -1 {
+				sb.WriteString(fragment)
+
+				break
+			}
+
+			sb.WriteString(fragment[:i])
+			offset += utf8.RuneCountInString(fragment[:i])
+			fragment = fragment[i+len(MatchStart):]
+
+			j := strings.Index(fragment, MatchEnd)
+			if j == -1 {
+				j = len(fragment)
+			}
+
+			match := Match{Start: offset, End: offset + utf8.RuneCountInString(fragment[:j])}
+			h.Matches = append(h.Matches, match)
+
+			sb.WriteString(fragment[:j])
+			offset = match.End
+			fragment = strings.TrimPrefix(fragment[j:], MatchEnd)
+		}
+
+		if len(h.Matches) != 0 {
+			h.Text = sb.String()
+			highlights = append(highlights, h)
+		}
+	}
+
+	return highlights
+}
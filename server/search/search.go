@@ -0,0 +1,36 @@
+/*
+Package search describes the approved works that are searched and presents the results of the searches.
+
+The works are searched by the database, which keeps their full-text index, so that all the instances
+of the service see the same works. Works are matched by the stems of their words, without diacritics,
+so that searching for "pământul" finds works that contain "pamantului", and matches in the work's title
+or character name weigh more than matches in the content.
+
+The database also finds the works similar to a given one, by the cosine similarity of the TF-IDF
+vectors of their contents. The terms of the works are updated when the works are approved, so the
+vectors aren't recomputed for all the works.
+
+This package presents the highlights of the matches the database found, and picks the similar
+works of a work so that they aren't all by the same author.
+*/
+package search
+
+// Document is a work, as it is searched.
+type Document struct {
+	ID       int
+	Type     string
+	AuthorID int
+	// TitleID is the title of an essay, or the title the character of a characterization is from.
+	TitleID     int
+	Title       string
+	CharacterID int
+	Character   string
+	SchoolID    int
+	TeacherID   int
+	// Tags are the IDs of the work's tags.
+	Tags    []int
+	Content string
+	// Summary and Keywords are the work's abstract.
+	Summary  []string
+	Keywords []string
+}
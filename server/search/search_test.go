@@ -0,0 +1,92 @@
+package search_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/search"
+)
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestHighlights(t *testing.T) {
+	t.Parallel()
+
+	headline := "lupta țăranului pentru \ue000pământ\ue001. Ion iubește \ue000pământul\ue001 mai mult" +
+		search.FragmentDelimiter + "Ion nu are zestre" +
+		search.FragmentDelimiter + "\ue000Pământul\ue001"
+
+	highlights := search.Highlights(headline)
+	if len(highlights) != 2 || len(highlights[0].Matches) != 2 || len(highlights[1].Matches) != 1 {
+		t.Fatalf("Expected two highlights with matches, got %+v", highlights)
+	}
+
+	for _, h := range highlights {
+		if strings.ContainsAny(h.Text, search.MatchStart+search.MatchEnd) {
+			t.Fatalf("Expected the marks to be removed, got %q", h.Text)
+		}
+
+		for _, m := range h.Matches {
+			if got := strings.ToLower(string([]rune(h.Text)[m.Start:m.End])); got != "pământ" && got != "pământul" {
+				t.Fatalf("Expected match of %q, got %q", "pământ", got)
+			}
+		}
+	}
+
+	if highlights := search.Highlights(""); len(highlights) != 0 {
+		t.Fatalf("Expected no highlights, got %+v", highlights)
+	}
+}
+
+func TestDiversify(t *testing.T) {
+	t.Parallel()
+
+	candidates := []search.Similar{
+		{Document: search.Document{ID: 1, AuthorID: 1}, Score: 0.9},
+		{Document: search.Document{ID: 2, AuthorID: 1}, Score: 0.8},
+		{Document: search.Document{ID: 3, AuthorID: 2}, Score: 0.6},
+		{Document: search.Document{ID: 4, AuthorID: 3}, Score: 0.3},
+	}
+
+	tests := []struct {
+		Name     string
+		Limit    int
+		Expected []int
+	}{
+		{Name: "Most similar", Limit: 1, Expected: []int{1}},
+		{Name: "Author diversity", Limit: 2, Expected: []int{1, 3}},
+		{Name: "Penalized author still picked", Limit: 3, Expected: []int{1, 3, 2}},
+		{Name: "All candidates", Limit: 10, Expected: []int{1, 3, 2, 4}},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			c := append([]search.Similar(nil), candidates...)
+			actual := make([]int, 0, test.Limit)
+
+			for _, s := range search.Diversify(c, test.Limit) {
+				actual = append(actual, s.Document.ID)
+			}
+
+			if !equal(actual, test.Expected) {
+				t.Fatalf("Expected %v, got %v", test.Expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"math"
+)
+
+const (
+	// authorPenalty multiplies the similarity of a work once for every more similar
+	// work of the same author, so that the results aren't all by the same student.
+	authorPenalty = 0.5
+	// CandidatesPerResult is the number of candidates that should be considered for each returned work.
+	CandidatesPerResult = 5
+)
+
+// Similar is a document similar to another one.
+type Similar struct {
+	Document Document
+	// Score is the cosine similarity of the documents' TF-IDF vectors, plus the boosts
+	// of the documents about the same title or character.
+	Score float64
+}
+
+// Diversify picks at most limit of the candidates, which are sorted by their scores, penalizing
+// the candidates whose authors already had works picked. The scores of the picked works are not changed.
+func Diversify(candidates []Similar, limit int) []Similar {
+	picked := make([]Similar, 0, limit)
+	authors := make(map[int]int)
+
+	for len(picked) < limit && len(candidates) != 0 {
+		best, bestScore := 0, -1.0
+
+		for i, c := range candidates {
+			score := c.Score * math.Pow(authorPenalty, float64(authors[c.Document.AuthorID]))
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		picked = append(picked, candidates[best])
+		authors[candidates[best].Document.AuthorID]++
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+
+	return picked
+}
@@ -0,0 +1,201 @@
+package server_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/issuer"
+	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/server"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	"github.com/gofiber/fiber/v2"
+)
+
+type access int
+
+const (
+	public access = iota
+	optional
+	user
+	registered
+	teacher
+	admin
+)
+
+// routeAccess is who may access each route of the server.
+//
+//nolint:gochecknoglobals
+var routeAccess = map[string]access{
+	"POST /leases/expire":        admin,
+	"POST /summaries/regenerate": admin,
+	"POST /tags":                 admin,
+	"DELETE /tags/:id":           admin,
+	"POST /analytics/expire":     admin,
+	"POST /auth0/users/deleted":  admin,
+	"POST /auth0/users/promoted": admin,
+
+	"GET /search":                  public,
+	"POST /works/:id/view":         optional,
+	"GET /works/trending":          public,
+	"GET /works/most-read":         public,
+	"GET /works/:id/stats":         public,
+	"GET /titles/:id/stats":        public,
+	"GET /tags":                    public,
+	"GET /titles/:id/tags":         public,
+	"GET /user":                    user,
+	"GET /works/:id/images/:image": user,
+
+	"POST /upload":                                      registered,
+	"POST /works/:id/transition":                        registered,
+	"POST /works/:id/notify":                            teacher,
+	"GET /works/:id/lease":                              teacher,
+	"POST /works/:id/lease":                             teacher,
+	"POST /works/:id/content":                           registered,
+	"GET /works/:id/annotations":                        registered,
+	"POST /works/:id/annotations":                       registered,
+	"PATCH /works/:id/annotations/:annotation":          registered,
+	"DELETE /works/:id/annotations/:annotation":         registered,
+	"POST /works/:id/annotations/:annotation/resolve":   registered,
+	"DELETE /works/:id/annotations/:annotation/resolve": registered,
+	"GET /rubrics":                                      registered,
+	"GET /works/:id/grade":                              registered,
+	"PUT /works/:id/grade":                              teacher,
+	"GET /students/:id/grades":                          registered,
+	"GET /works/:id/analysis":                           registered,
+	"GET /works/:id/spelling":                           registered,
+	"GET /works/:id/style":                              registered,
+	"GET /style/rules":                                  registered,
+	"GET /works/:id/similar":                            registered,
+	"GET /works/:id/tags":                               registered,
+	"GET /works/:id/tags/suggestions":                   teacher,
+	"PUT /works/:id/tags/:tag":                          teacher,
+	"DELETE /works/:id/tags/:tag":                       teacher,
+	"GET /works/:id/position":                           registered,
+	"PUT /works/:id/position":                           registered,
+	"GET /user/history":                                 registered,
+	"DELETE /user/history":                              registered,
+	"DELETE /user/history/:work":                        registered,
+	"GET /user/tokens":                                  registered,
+	"POST /user/tokens":                                 registered,
+	"DELETE /user/tokens/:id":                           registered,
+}
+
+// guardErrors are the errors sent when the guards deny access.
+//
+//nolint:gochecknoglobals
+var guardErrors = map[string]bool{
+	"missing or malformed token":                    true,
+	"invalid or expired token":                      true,
+	"nu ești autorizat":                             true,
+	"nu ai permisiunea de a accesa această resursă": true,
+}
+
+// allowed tells whether the identity may access a route with the given access.
+func allowed(a access, role string, isAdmin bool) bool {
+	switch a {
+	case public, optional:
+		return true
+	case user, registered:
+		return role != ""
+	case teacher:
+		return role == "teacher"
+	case admin:
+		return isAdmin
+	}
+
+	return false
+}
+
+// TestRouteAccess checks that every route is annotated with the policy it's meant to have, by requesting
+// each route as each kind of user and checking whether the request got past the route's guard.
+func TestRouteAccess(t *testing.T) {
+	t.Parallel()
+
+	app := server.New()
+
+	identities := []struct {
+		name  string
+		role  string
+		admin bool
+	}{
+		{name: "anonymous"},
+		{name: "student", role: "student"},
+		{name: "teacher", role: "teacher"},
+		{name: "admin", admin: true},
+	}
+
+	seen := make(map[string]bool)
+
+	for _, routes := range app.Stack() {
+		for _, route := range routes {
+			if route.Method == fiber.MethodHead || route.Method == fiber.MethodConnect || route.Path == "/" {
+				continue
+			}
+
+			key := route.Method + " " + route.Path
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			a, ok := routeAccess[key]
+			if !ok {
+				t.Errorf("Route %s has no access policy in the test", key)
+
+				continue
+			}
+
+			path := strings.NewReplacer(":id", "1", ":image", "1", ":annotation", "1", ":tag", "1", ":work", "1").Replace(route.Path)
+
+			for _, id := range identities {
+				if id.admin && meta.HasuraAdminSecret == "" {
+					continue
+				}
+
+				method, id, expected := route.Method, id, allowed(a, id.role, id.admin)
+
+				t.Run(key+" as "+id.name, func(t *testing.T) {
+					t.Parallel()
+
+					var token string
+					if id.role != "" {
+						token = testhelper.JWT(t, issuer.User{ID: 1, Role: id.role, Registered: true})
+					}
+
+					req := testhelper.Request(t, method, path, nil, token)
+					if id.admin {
+						req.Header.Set("X-Hasura-Admin-Secret", meta.HasuraAdminSecret)
+					}
+
+					res, err := app.Test(req, -1)
+					if err != nil {
+						t.Fatalf("Failed to do test request: %v", err)
+					}
+					defer res.Body.Close()
+
+					var body struct {
+						Error string
+					}
+
+					denied := false
+					if res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+						testhelper.DecodeJSON(t, res.Body, &body)
+						denied = guardErrors[body.Error]
+					}
+
+					if denied == expected {
+						t.Fatalf("Expected allowed=%t, got status %d: %q", expected, res.StatusCode, body.Error)
+					}
+				})
+			}
+		}
+	}
+
+	for key := range routeAccess {
+		if !seen[key] {
+			t.Errorf("Route %s isn't registered", key)
+		}
+	}
+}
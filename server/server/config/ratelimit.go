@@ -0,0 +1,43 @@
+package config
+
+import (
+	"time"
+
+	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/redis"
+	"github.com/FiveIT/eseuri/server/server/middleware/ratelimit"
+	"github.com/rs/zerolog/log"
+)
+
+// The rate limits of the routes that are expensive or that can be abused.
+//
+//nolint:gochecknoglobals
+var (
+	// UploadLimit throttles the uploads, whose documents are all parsed by Tika.
+	UploadLimit = ratelimit.Limit{Burst: 10, Every: time.Minute}
+	// EmailLimit throttles the emails sent to the students about their works.
+	EmailLimit = ratelimit.Limit{Burst: 10, Every: time.Minute}
+	// SearchLimit throttles the searches.
+	SearchLimit = ratelimit.Limit{Burst: 30, Every: time.Second}
+	// ViewLimit throttles the views recorded for the works.
+	ViewLimit = ratelimit.Limit{Burst: 60, Every: time.Second}
+	// AccessTokenLimit throttles the creation of personal access tokens.
+	AccessTokenLimit = ratelimit.Limit{Burst: 5, Every: 10 * time.Minute}
+)
+
+// RateLimiter creates the limiter of the routes, which keeps its buckets in the configured Redis-compatible
+// server. If none is configured or its URL is invalid, the buckets are kept in memory.
+func RateLimiter() *ratelimit.Limiter {
+	if meta.RedisURL == "" {
+		return ratelimit.New(ratelimit.NewMemory())
+	}
+
+	client, err := redis.New(meta.RedisURL)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create Redis client, keeping rate limits in memory")
+
+		return ratelimit.New(ratelimit.NewMemory())
+	}
+
+	return ratelimit.New(ratelimit.NewRedis(client))
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"os"
+
+	"github.com/FiveIT/eseuri/server/analytics"
+	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/spelling"
+	"github.com/FiveIT/eseuri/server/storage"
+	"github.com/FiveIT/eseuri/server/style"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	errMissingS3Credentials = errors.New("the object store's endpoint and credentials are required")
+	errMissingObjectStore   = errors.New("an object store is required when deployed, set S3_BUCKET and its credentials")
+)
+
+// newStorage creates the configured object store, or the local filesystem in development. The deployments
+// must have an object store, as the serverless functions' disks are ephemeral and not shared.
+func newStorage() (storage.Storage, error) {
+	if meta.S3Bucket != "" {
+		if meta.S3Endpoint == "" || meta.S3AccessKeyID == "" || meta.S3SecretAccessKey == "" {
+			return nil, errMissingS3Credentials
+		}
+
+		return storage.NewS3(meta.S3Endpoint, meta.S3Bucket, meta.S3Region, meta.S3AccessKeyID, meta.S3SecretAccessKey), nil
+	}
+
+	if meta.IsServerless || meta.IsProduction {
+		return nil, errMissingObjectStore
+	}
+
+	return storage.NewFilesystem(meta.StoragePath), nil
+}
+
+// Storage returns the backend the uploaded objects are kept in. It is created when it is first used, so only
+// the handlers that store or read objects need it. If it is misconfigured, the error is logged, and every
+// operation fails with storage.ErrUnavailable.
+func Storage() storage.Storage {
+	return storage.NewLazy(func() (storage.Storage, error) {
+		s, err := newStorage()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to create storage")
+		}
+
+		return s, err
+	})
+}
+
+// SpellingChecker loads the configured dictionary. Spelling is not checked
+// if no dictionary is configured or if it can't be loaded.
+func SpellingChecker() *spelling.Checker {
+	if meta.SpellingDictionary == "" {
+		return nil
+	}
+
+	dict, err := spelling.Load(meta.SpellingDictionary)
+	if err != nil {
+		log.Error().Err(err).Str("path", meta.SpellingDictionary).Msg("failed to load spelling dictionary")
+
+		return nil
+	}
+
+	return spelling.NewChecker(dict)
+}
+
+// StyleLinter loads the configured style rules, or the default ones if none are configured.
+// Style is not checked if the rules can't be loaded.
+func StyleLinter() *style.Linter {
+	var (
+		linter *style.Linter
+		err    error
+	)
+
+	if meta.StyleRulesPath == "" {
+		linter, err = style.Default()
+	} else {
+		linter, err = style.Load(os.DirFS(meta.StyleRulesPath))
+	}
+
+	if err != nil {
+		log.Error().Err(err).Str("path", meta.StyleRulesPath).Msg("failed to load style rules")
+
+		return nil
+	}
+
+	return linter
+}
+
+// ViewHasher creates the hasher of the works' visitors. If no secret is configured, the views are
+// deduplicated only until the server restarts, and if no random key can be generated, views aren't recorded.
+func ViewHasher() *analytics.Hasher {
+	if meta.AnalyticsSecret == "" {
+		log.Warn().Msg("no analytics secret configured, using a random one")
+	}
+
+	hasher, err := analytics.NewHasher(meta.AnalyticsSecret)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create analytics hasher")
+
+		return nil
+	}
+
+	return hasher
+}
@@ -1,14 +1,62 @@
 package helpers
 
 import (
+	"net"
 	"net/http"
+	"strings"
 
+	"github.com/FiveIT/eseuri/server/meta"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// ClientIP returns the IP address of the client that made the request. Behind the serverless platforms'
+// proxies or a trusted proxy, it is the rightmost address of the X-Forwarded-For header, the one the proxy
+// added: the other addresses are sent by the client, which may forge them. Otherwise, it is the address of
+// the connection, as the client may send the header itself.
+func ClientIP(c *fiber.Ctx) string {
+	if !meta.IsServerless && !isTrustedProxy(c.IP()) {
+		return c.IP()
+	}
+
+	if ips := c.IPs(); len(ips) > 0 && ips[len(ips)-1] != "" {
+		return ips[len(ips)-1]
+	}
+
+	return c.IP()
+}
+
+// isTrustedProxy checks if the address is one of the configured trusted proxies, which may be
+// given as single addresses or as CIDR ranges.
+func isTrustedProxy(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range strings.Split(meta.TrustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if trusted := net.ParseIP(proxy); trusted != nil && trusted.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func SendError(c *fiber.Ctx, statusCode int, message string, err error) error {
+	return SendErrorWithData(c, statusCode, message, err, nil)
+}
+
+// SendErrorWithData works like SendError, but it also adds the given fields
+// to the response body, so the client can find out more about the error.
+func SendErrorWithData(c *fiber.Ctx, statusCode int, message string, err error, data fiber.Map) error {
 	logger, ok := c.Locals("logger").(zerolog.Logger)
 	if !ok {
 		logger = log.Logger
@@ -24,7 +72,13 @@ func SendError(c *fiber.Ctx, statusCode int, message string, err error) error {
 
 	ev.Err(err).Int("code", statusCode).Msg(message)
 
-	return c.Status(statusCode).JSON(&fiber.Map{
+	body := fiber.Map{
 		"error": message,
-	})
+	}
+
+	for k, v := range data {
+		body[k] = v
+	}
+
+	return c.Status(statusCode).JSON(body)
 }
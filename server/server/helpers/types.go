@@ -1,14 +1,76 @@
 package helpers
 
+import "encoding/json"
+
 type WorkFormInput struct {
 	Type               string `form:"type"`
 	SubjectID          int    `form:"subject"`
 	RequestedTeacherID int    `form:"requestedTeacher"`
 }
 
-type StudentUploaderInfo struct {
-	StatusLucrare  string `form:"status"`
-	NumeleElevului string `form:"name"`
-	EmailElev      string `form:"email"`
-	URL            string `form:"url"`
+type WorkTransitionInput struct {
+	Status string `form:"status"`
+	// RejectionReason is required when rejecting a work, and Feedback is optional.
+	// Neither may be given for other transitions.
+	RejectionReason string `form:"rejectionReason"`
+	Feedback        string `form:"feedback"`
+}
+
+type AnnotationInput struct {
+	// Version is the version of the work's content the offsets refer to.
+	Version int    `form:"version"`
+	Start   int    `form:"start"`
+	End     int    `form:"end"`
+	Body    string `form:"body"`
+}
+
+type AnnotationEditInput struct {
+	Body string `form:"body"`
+}
+
+type GradeInput struct {
+	// Scores maps the keys of the rubric's criteria to their scores.
+	Scores map[string]int `json:"scores"`
+}
+
+type TagInput struct {
+	Name string `form:"name"`
+}
+
+type ViewInput struct {
+	// Progress is how far the reader scrolled through the work, as a fraction.
+	Progress float64 `form:"progress"`
+	// Seconds is for how long the reader had the work open.
+	Seconds float64 `form:"seconds"`
+}
+
+type PositionInput struct {
+	// Version is the version of the work's content the position refers to.
+	Version int `form:"version"`
+	// Paragraph is the index of the first paragraph on the reader's screen.
+	Paragraph int `form:"paragraph"`
+	// Progress is how far the reader scrolled through the work, as a fraction.
+	Progress float64 `form:"progress"`
+}
+
+type AccessTokenInput struct {
+	// Name helps the user remember what the token is used for.
+	Name   string   `form:"name"`
+	Scopes []string `form:"scopes"`
+	// Days is for how many days the token is valid.
+	Days int `form:"days"`
+}
+
+// HasuraEvent is the payload Hasura sends to the webhooks of the event triggers.
+type HasuraEvent struct {
+	Event struct {
+		// Op is the operation that triggered the event: INSERT, UPDATE, DELETE or MANUAL.
+		Op   string `json:"op"`
+		Data struct {
+			// Old is the row before the operation, and null for insertions.
+			Old json.RawMessage `json:"old"`
+			// New is the row after the operation, and null for deletions.
+			New json.RawMessage `json:"new"`
+		} `json:"data"`
+	} `json:"event"`
 }
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+	"github.com/rs/zerolog"
+)
+
+// The scopes of the personal access tokens. A token may access only the routes annotated with one of its scopes.
+const (
+	ScopeReadWorks = "read-works"
+	ScopeReview    = "review"
+	ScopeUpload    = "upload"
+)
+
+const (
+	// accessTokenPrefix starts every personal access token, so they can be told apart from JWTs.
+	accessTokenPrefix = "eseuri_pat_"
+	// accessTokenSize is the number of random bytes of a personal access token.
+	accessTokenSize = 32
+	// accessTokenHintLength is the number of characters of the token that are stored as its prefix.
+	accessTokenHintLength = len(accessTokenPrefix) + 6
+
+	msgMissingScope = "tokenul de acces nu permite această acțiune"
+)
+
+var ErrInvalidAccessToken = errors.New("auth: access token is unknown, revoked or expired")
+
+// Scopes are all the scopes a personal access token may have.
+//
+//nolint:gochecknoglobals
+var Scopes = []string{ScopeReadWorks, ScopeReview, ScopeUpload}
+
+// NewAccessToken generates a personal access token. Only its hash and its prefix, which helps the users tell
+// their tokens apart, should be stored.
+func NewAccessToken() (token, prefix string, err error) {
+	b := make([]byte, accessTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	token = accessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return token, token[:accessTokenHintLength], nil
+}
+
+// HashAccessToken hashes a personal access token for storage. The tokens are random, so they
+// don't need a slow hash.
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// isAccessToken checks if the bearer token is a personal access token instead of a JWT.
+func isAccessToken(raw string) bool {
+	return strings.HasPrefix(raw, accessTokenPrefix)
+}
+
+// verifyAccessToken looks up the personal access token and returns the claims of the user that created it.
+// It records that the token was used, too, at most once a minute.
+func verifyAccessToken(c *fiber.Ctx, client *graphql.Client, raw string) (*CustomClaims, error) {
+	if client == nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	var resp gqlqueries.AuthenticateAccessTokenOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.AuthenticateAccessToken, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"hash": HashAccessToken(raw),
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Query) == 0 || resp.Query[0].User == nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	token := resp.Query[0]
+
+	logger := c.Locals("logger").(zerolog.Logger)
+	logger.Debug().Int("accessTokenID", token.ID).Msg("authenticated with personal access token")
+
+	return &CustomClaims{
+		IsRegistered: token.User.UpdatedAt != nil,
+		UserID:       token.User.ID,
+		Role:         token.User.Role,
+		AllowedRoles: []string{token.User.Role},
+		Scopes:       token.Scopes,
+	}, nil
+}
+
+// hasScope checks if the user may access a route annotated with the given scope. Only the personal access tokens
+// are limited by scopes, and the routes without a scope can't be accessed with them.
+func hasScope(c *fiber.Ctx, scope string) (bool, error) {
+	claims := c.Locals("claims").(CustomClaims)
+
+	if claims.Scopes == nil || (scope != "" && contains(claims.Scopes, scope)) {
+		return true, nil
+	}
+
+	return false, helpers.SendErrorWithData(c, fiber.StatusForbidden, msgMissingScope, nil, fiber.Map{
+		"scope": scope,
+	})
+}
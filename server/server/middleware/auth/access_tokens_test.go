@@ -0,0 +1,232 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/issuer"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/middleware/logger"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/machinebox/graphql"
+)
+
+type storedAccessToken struct {
+	userID  int
+	role    string
+	scopes  []string
+	deleted bool
+}
+
+// fakeAccessTokens answers the lookups of the personal access tokens, which are stored by their hashes.
+func fakeAccessTokens(tb testing.TB, tokens map[string]storedAccessToken) *graphql.Client {
+	tb.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				Hash string `json:"hash"`
+			} `json:"variables"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		returning := []map[string]interface{}{}
+
+		if token, ok := tokens[req.Variables.Hash]; ok {
+			var user interface{}
+			if !token.deleted {
+				user = map[string]interface{}{"id": token.userID, "role": token.role, "updated_at": "2021-05-17T12:00:00"}
+			}
+
+			returning = append(returning, map[string]interface{}{"id": 1, "scopes": token.scopes, "user": user})
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"authenticate_access_token": returning,
+			},
+		})
+	}))
+
+	tb.Cleanup(server.Close)
+
+	return graphql.NewClient(server.URL)
+}
+
+func newAccessToken(tb testing.TB) string {
+	tb.Helper()
+
+	token, prefix, err := auth.NewAccessToken()
+	if err != nil {
+		tb.Fatalf("Failed to generate access token: %v", err)
+	}
+
+	if !strings.HasPrefix(token, prefix) {
+		tb.Fatalf("Token %q doesn't start with its prefix %q", token, prefix)
+	}
+
+	return token
+}
+
+func TestNewAccessToken(t *testing.T) {
+	t.Parallel()
+
+	a, b := newAccessToken(t), newAccessToken(t)
+
+	if a == b {
+		t.Fatal("Generated the same token twice")
+	}
+
+	utils.AssertEqual(t, auth.HashAccessToken(a), auth.HashAccessToken(a))
+
+	if auth.HashAccessToken(a) == auth.HashAccessToken(b) || strings.Contains(auth.HashAccessToken(a), a) {
+		t.Fatal("The hashes don't hide the tokens")
+	}
+}
+
+//nolint:funlen
+func TestAccessTokens(t *testing.T) {
+	t.Parallel()
+
+	iss, err := issuer.Generate()
+	if err != nil {
+		t.Fatalf("Failed to create issuer: %v", err)
+	}
+
+	keys := auth.NewKeySet("", nil)
+	keys.Trust(issuer.KeyID, iss.PublicKey())
+
+	jwt, err := iss.Token(issuer.User{ID: 1, Role: workstatus.RoleTeacher, Registered: true}, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	reader, reviewer, deleted, unknown := newAccessToken(t), newAccessToken(t), newAccessToken(t), newAccessToken(t)
+
+	client := fakeAccessTokens(t, map[string]storedAccessToken{
+		auth.HashAccessToken(reader):   {userID: 1, role: workstatus.RoleStudent, scopes: []string{auth.ScopeReadWorks}},
+		auth.HashAccessToken(reviewer): {userID: 2, role: workstatus.RoleTeacher, scopes: []string{auth.ScopeReadWorks, auth.ScopeReview}},
+		auth.HashAccessToken(deleted):  {userID: 3, role: workstatus.RoleTeacher, scopes: []string{auth.ScopeReview}, deleted: true},
+	})
+
+	reader, reviewer, deleted, unknown = "Bearer "+reader, "Bearer "+reviewer, "Bearer "+deleted, "Bearer "+unknown
+
+	guard := auth.NewGuard(auth.Config{Keys: keys, Validator: auth.Validator{}}, client)
+
+	tests := []struct {
+		name     string
+		handler  fiber.Handler
+		token    string
+		expected int
+		claims   *auth.CustomClaims
+	}{
+		{
+			name:     "scope allowed",
+			handler:  guard.Scoped(auth.ScopeReadWorks).Registered(),
+			token:    reader,
+			expected: http.StatusNoContent,
+			claims:   &auth.CustomClaims{IsRegistered: true, UserID: 1, Role: workstatus.RoleStudent, AllowedRoles: []string{workstatus.RoleStudent}, Scopes: []string{auth.ScopeReadWorks}},
+		},
+		{
+			name:     "one of the scopes allowed",
+			handler:  guard.Scoped(auth.ScopeReview).Registered(workstatus.RoleTeacher),
+			token:    reviewer,
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "scope missing",
+			handler:  guard.Scoped(auth.ScopeReview).Registered(),
+			token:    reader,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "route without scope",
+			handler:  guard.Registered(),
+			token:    reviewer,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "optional route without scope",
+			handler:  guard.Optional(),
+			token:    reader,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "role still checked",
+			handler:  guard.Scoped(auth.ScopeReadWorks).Registered(workstatus.RoleTeacher),
+			token:    reader,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "unknown, revoked or expired",
+			handler:  guard.Scoped(auth.ScopeReadWorks).User(),
+			token:    unknown,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "deleted user",
+			handler:  guard.Scoped(auth.ScopeReview).User(),
+			token:    deleted,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "JWT not limited by scopes",
+			handler:  guard.Scoped(auth.ScopeUpload).Registered(),
+			token:    jwt,
+			expected: http.StatusNoContent,
+			claims:   &auth.CustomClaims{IsRegistered: true, UserID: 1, Role: workstatus.RoleTeacher, AllowedRoles: []string{"anonymous", workstatus.RoleTeacher}, Scopes: nil},
+		},
+		{
+			name:     "rejected without client",
+			handler:  auth.NewGuard(auth.Config{Keys: keys, Validator: auth.Validator{}}, nil).User(),
+			token:    reader,
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ch := make(chan auth.CustomClaims, 1)
+			app := testhelper.App(t, logger.Middleware(nil), test.handler, func(c *fiber.Ctx) error {
+				ch <- c.Locals("claims").(auth.CustomClaims)
+
+				return c.Next()
+			})
+
+			res := testhelper.DoTestRequest(t, app, testhelper.Request(t, http.MethodGet, "/", nil, test.token))
+			defer res.Body.Close()
+
+			utils.AssertEqual(t, test.expected, res.StatusCode)
+
+			if test.claims != nil {
+				utils.AssertEqual(t, *test.claims, <-ch)
+			}
+
+			if test.expected == http.StatusUnauthorized {
+				var response struct {
+					Reason string
+				}
+
+				testhelper.DecodeJSON(t, res.Body, &response)
+
+				utils.AssertEqual(t, "access_token", response.Reason)
+			}
+		})
+	}
+}
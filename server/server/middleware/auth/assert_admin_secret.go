@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/subtle"
+
+	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/gofiber/fiber/v2"
+)
+
+const headerAdminSecret = "X-Hasura-Admin-Secret"
+
+// AssertAdminSecret creates a middleware that lets through only the requests that
+// have the Hasura admin secret set. It is used for routes called by Hasura itself,
+// such as the webhooks of the cron triggers.
+func AssertAdminSecret() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !hasAdminSecret(c) {
+			return helpers.SendError(c, fiber.StatusUnauthorized, "nu ești autorizat", nil)
+		}
+
+		return c.Next()
+	}
+}
+
+func hasAdminSecret(c *fiber.Ctx) bool {
+	secret := c.Get(headerAdminSecret)
+
+	return meta.HasuraAdminSecret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(meta.HasuraAdminSecret)) == 1
+}
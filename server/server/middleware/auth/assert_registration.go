@@ -7,34 +7,35 @@ import (
 	"github.com/machinebox/graphql"
 )
 
-func AssertRegistration(client *graphql.Client) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		claims := c.Locals("claims").(CustomClaims)
+// assertRegistered checks if the authenticated user has completed their registration. Tokens issued before the
+// registration was completed say otherwise, so the database is checked for those. It returns false if the user
+// isn't registered, in which case the response was sent.
+func assertRegistered(c *fiber.Ctx, client *graphql.Client) (bool, error) {
+	claims := c.Locals("claims").(CustomClaims)
 
-		if claims.IsRegistered {
-			return c.Next()
-		}
-
-		var resp gqlqueries.UserOutput
+	if claims.IsRegistered {
+		return true, nil
+	}
 
-		//nolint:exhaustivestruct
-		if err := helpers.GraphQLRequest(client, gqlqueries.User, helpers.GraphQLRequestOptions{
-			Output:  &resp,
-			Context: c.Context(),
-			Headers: map[string]string{
-				fiber.HeaderAuthorization: c.Get(fiber.HeaderAuthorization),
-			},
-			Vars: map[string]interface{}{
-				"id": claims.UserID,
-			},
-		}); err != nil {
-			return helpers.HandleGraphQLError(c, err)
-		}
+	var resp gqlqueries.UserOutput
 
-		if resp.Query[0].UpdatedAt == nil {
-			return helpers.SendError(c, fiber.StatusUnauthorized, "nu ești înregistrat", nil)
-		}
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.User, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Headers: map[string]string{
+			fiber.HeaderAuthorization: c.Get(fiber.HeaderAuthorization),
+		},
+		Vars: map[string]interface{}{
+			"id": claims.UserID,
+		},
+	}); err != nil {
+		return false, helpers.HandleGraphQLError(c, err)
+	}
 
-		return c.Next()
+	if len(resp.Query) == 0 || resp.Query[0].UpdatedAt == nil {
+		return false, helpers.SendError(c, fiber.StatusUnauthorized, "nu ești înregistrat", nil)
 	}
+
+	return true, nil
 }
@@ -1,89 +1,315 @@
 package auth
 
 import (
+	"crypto/rsa"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/FiveIT/eseuri/server/issuer"
 	"github.com/FiveIT/eseuri/server/meta"
 	"github.com/FiveIT/eseuri/server/server/helpers"
 	jwt "github.com/form3tech-oss/jwt-go"
 	"github.com/gofiber/fiber/v2"
-	jwtware "github.com/gofiber/jwt/v2"
+	"github.com/machinebox/graphql"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// jwtCredentials is the JWT secret configured for Hasura. The tokens are verified with the keys
+// from the key set at JWKURL, if given, and with the PEM encoded Key otherwise.
 type jwtCredentials struct {
-	Key string `json:"key"`
+	Type     string   `json:"type"`
+	Key      string   `json:"key"`
+	JWKURL   string   `json:"jwk_url"`
+	Issuer   string   `json:"issuer"`
+	Audience audience `json:"audience"`
+	// AllowedSkew is the leeway of the tokens' times, in seconds.
+	AllowedSkew *int `json:"allowed_skew"`
 }
 
 type CustomClaims struct {
 	IsRegistered bool
 	UserID       int
-	Role         string
+	// Role is the role the request is made with: the one selected with the X-Hasura-Role header,
+	// or the user's default role.
+	Role string
+	// AllowedRoles are the roles the user may select.
+	AllowedRoles []string
+	// Scopes limit what the user may do when authenticated with a personal access token.
+	// They're nil for the other tokens, which may do anything the user may.
+	Scopes []string
 }
 
 func (c *CustomClaims) MarshalZerologObject(z *zerolog.Event) {
 	z.Dict("claims", zerolog.Dict().
 		Bool("isRegistered", c.IsRegistered).
 		Int("userID", c.UserID).
-		Str("role", c.Role))
+		Str("role", c.Role).
+		Strs("allowedRoles", c.AllowedRoles).
+		Strs("scopes", c.Scopes))
+}
+
+// Headers returns the Hasura session variables of the user, so the promoted GraphQL requests
+// act with the request's role.
+func (c *CustomClaims) Headers() map[string]string {
+	return map[string]string{
+		HeaderRole:         c.Role,
+		"X-Hasura-User-Id": strconv.Itoa(c.UserID),
+	}
 }
 
 const (
-	hasuraNamespace = "https://hasura.io/jwt/claims"
-	eseuriNamespace = "https://eseuri.com"
+	authScheme = "Bearer"
+
+	// HeaderRole selects one of the user's allowed roles to make the request with, like it does for Hasura.
+	HeaderRole = "X-Hasura-Role"
+
+	msgMalformedToken = "missing or malformed token"
+	msgInvalidToken   = "invalid or expired token"
 )
 
-// Middleware creates a fiber middleware that checks if the request is authorized.
-//
-//nolint:exhaustivestruct
-func Middleware() func(*fiber.Ctx) error {
-	var (
-		creds jwtCredentials
-		err   error
-	)
+var ErrMalformedToken = errors.New("auth: missing or malformed token")
 
-	if err = json.NewDecoder(strings.NewReader(meta.HasuraJWTSecret)).Decode(&creds); err != nil {
-		log.Fatal().Err(err).Msg("failed to get auth middleware due to JWT credentials unmarshal error")
+// Config configures how the guard authorizes the requests.
+type Config struct {
+	// Keys are the keys the tokens are verified with.
+	Keys *KeySet
+	// Validator checks the claims of the verified tokens.
+	Validator Validator
+	// Client looks up the personal access tokens. They're rejected if it's nil.
+	Client *graphql.Client
+	// Revocations tells which tokens were revoked. The tokens aren't checked if it's nil.
+	Revocations *Revocations
+}
+
+// configFromSecret creates the guard's configuration from the JWT secret. The key set at the secret's
+// URL, or else at meta.JWKSPath, is used if given, and the secret's key is the fallback.
+func configFromSecret(secret string) (Config, error) {
+	var creds jwtCredentials
+
+	if err := json.NewDecoder(strings.NewReader(secret)).Decode(&creds); err != nil {
+		return Config{}, fmt.Errorf("failed to decode JWT credentials: %w", err)
 	}
 
-	key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(creds.Key))
-	if err != nil {
-		log.Fatal().Err(err).Msg("failed to get auth middleware due to failure in parsing public key from PEM")
+	if creds.Type != "" && creds.Type != "RS256" {
+		return Config{}, fmt.Errorf("unsupported JWT signing method %q", creds.Type)
 	}
 
-	return jwtware.New(jwtware.Config{
-		SigningMethod: "RS256",
-		SigningKey:    key,
-		SuccessHandler: func(c *fiber.Ctx) error {
-			logger := c.Locals("logger").(zerolog.Logger)
+	source := creds.JWKURL
+	if source == "" {
+		source = meta.JWKSPath
+	}
 
-			user := c.Locals("user").(*jwt.Token).Claims.(jwt.MapClaims)
+	var static *rsa.PublicKey
 
-			logger.Debug().Fields(user).Msg("claims")
+	if creds.Key != "" {
+		key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(creds.Key))
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to parse public key from PEM: %w", err)
+		}
 
-			eseuri := user[eseuriNamespace].(map[string]interface{})
-			hasura := user[hasuraNamespace].(map[string]interface{})
+		static = key
+	}
 
-			custom := CustomClaims{}
-			custom.Role = hasura["X-Hasura-Default-Role"].(string)
-			custom.UserID, _ = strconv.Atoi(hasura["X-Hasura-User-Id"].(string))
-			custom.IsRegistered = eseuri["hasCompletedRegistration"].(bool)
+	if source == "" && static == nil {
+		return Config{}, ErrNoKeys
+	}
 
-			logger.Debug().EmbedObject(&custom).Msg("unmarshaled custom claims")
+	leeway := defaultLeeway
+	if creds.AllowedSkew != nil {
+		leeway = time.Duration(*creds.AllowedSkew) * time.Second
+	}
 
-			c.Locals("claims", custom)
+	var issuers []string
+	if creds.Issuer != "" {
+		issuers = []string{creds.Issuer}
+	}
 
-			return c.Next()
+	//nolint:exhaustivestruct
+	return Config{
+		Keys: NewKeySet(source, static),
+		Validator: Validator{
+			Issuers:  issuers,
+			Audience: creds.Audience,
+			Leeway:   leeway,
 		},
-		ErrorHandler: func(c *fiber.Ctx, e error) error {
-			if e.Error() == "Missing or malformed JWT" {
-				return helpers.SendError(c, http.StatusBadRequest, "missing or malformed token", e)
+	}, nil
+}
+
+// config creates the guard's configuration. If the offline issuer is explicitly trusted,
+// its tokens are accepted too, and the JWT secret is optional.
+func config() (Config, error) {
+	if !meta.TrustDevIssuer {
+		return configFromSecret(meta.HasuraJWTSecret)
+	}
+
+	log.Warn().Msg("TRUST_DEV_ISSUER is set: tokens signed by the offline issuer are accepted, never enable it when deployed")
+
+	cfg := Config{
+		Keys:        NewKeySet("", nil),
+		Validator:   Validator{Issuers: nil, Audience: nil, Leeway: defaultLeeway},
+		Client:      nil,
+		Revocations: nil,
+	}
+
+	if meta.HasuraJWTSecret != "" {
+		var err error
+		if cfg, err = configFromSecret(meta.HasuraJWTSecret); err != nil {
+			return cfg, err
+		}
+	}
+
+	dev, err := issuer.Default()
+	if err != nil {
+		return cfg, fmt.Errorf("failed to create the offline token issuer: %w", err)
+	}
+
+	cfg.Keys.Trust(issuer.KeyID, dev.PublicKey())
+
+	if len(cfg.Validator.Issuers) != 0 {
+		cfg.Validator.Issuers = append(cfg.Validator.Issuers, issuer.Name)
+	}
+
+	if len(cfg.Validator.Audience) != 0 {
+		cfg.Validator.Audience = append(cfg.Validator.Audience, issuer.Audience)
+	}
+
+	return cfg, nil
+}
+
+// DefaultConfig creates the guard's configuration from the Hasura JWT secret.
+func DefaultConfig() Config {
+	cfg, err := config()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to configure the guard due to invalid JWT credentials")
+	}
+
+	return cfg
+}
+
+// bearerToken extracts the token from the request's authorization header.
+func bearerToken(c *fiber.Ctx) (string, error) {
+	header := c.Get(fiber.HeaderAuthorization)
+	l := len(authScheme)
+
+	if len(header) <= l+1 || !strings.EqualFold(header[:l], authScheme) || header[l] != ' ' {
+		return "", ErrMalformedToken
+	}
+
+	return header[l+1:], nil
+}
+
+// selectRole sets the role the request is made with, if one was requested. It returns false if the user
+// may not use the requested role, in which case the response was sent.
+func selectRole(c *fiber.Ctx, claims *CustomClaims) (bool, error) {
+	role := c.Get(HeaderRole)
+	if role == "" {
+		return true, nil
+	}
+
+	if !contains(claims.AllowedRoles, role) {
+		return false, helpers.SendErrorWithData(c, http.StatusForbidden, "nu poți folosi rolul cerut", nil, fiber.Map{
+			"allowedRoles": claims.AllowedRoles,
+		})
+	}
+
+	claims.Role = role
+
+	return true, nil
+}
+
+// verify parses the token, checks its signature and validates its claims.
+func verify(c *fiber.Ctx, cfg Config, raw string) (*Claims, error) {
+	var claims Claims
+
+	if _, err := parser.ParseWithClaims(raw, &claims, cfg.Keys.Keyfunc(c.Context())); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validator.Validate(&claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// authenticate verifies the request's token and sets its claims. It returns false if the token
+// is invalid, in which case the response was sent.
+func authenticate(c *fiber.Ctx, cfg Config) (bool, error) {
+	raw, err := bearerToken(c)
+	if err != nil {
+		return false, helpers.SendError(c, http.StatusBadRequest, msgMalformedToken, err)
+	}
+
+	if isAccessToken(raw) {
+		custom, err := verifyAccessToken(c, cfg.Client, raw)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidAccessToken) {
+				return false, helpers.HandleGraphQLError(c, err)
 			}
-			return helpers.SendError(c, http.StatusUnauthorized, "invalid or expired token", e)
-		},
-	})
+
+			return false, helpers.SendErrorWithData(c, http.StatusUnauthorized, msgInvalidToken, err, fiber.Map{
+				"reason": Reason(err),
+			})
+		}
+
+		if ok, err := selectRole(c, custom); !ok {
+			return false, err
+		}
+
+		c.Locals("claims", *custom)
+
+		return true, nil
+	}
+
+	claims, err := verify(c, cfg, raw)
+	if err != nil {
+		return false, helpers.SendErrorWithData(c, http.StatusUnauthorized, msgInvalidToken, err, fiber.Map{
+			"reason": Reason(err),
+		})
+	}
+
+	custom := claims.Custom()
+
+	if ok, err := selectRole(c, &custom); !ok {
+		return false, err
+	}
+
+	logger := c.Locals("logger").(zerolog.Logger)
+
+	logger.Debug().Str("subject", claims.Subject).EmbedObject(&custom).Msg("unmarshaled custom claims")
+
+	c.Locals("claims", custom)
+	c.Locals("issuedAt", claims.IssuedAt)
+
+	return true, nil
+}
+
+// checkRevocation rejects the authenticated JWTs that were revoked. The personal access tokens are
+// looked up on each request, so they are always up to date. It returns false if the token was revoked,
+// in which case the response was sent.
+func checkRevocation(c *fiber.Ctx, cfg Config) (bool, error) {
+	issuedAt, ok := c.Locals("issuedAt").(int64)
+	if !ok || cfg.Revocations == nil {
+		return true, nil
+	}
+
+	claims := c.Locals("claims").(CustomClaims)
+
+	if err := cfg.Revocations.Check(c.Context(), claims.UserID, issuedAt); err != nil {
+		if !errors.Is(err, ErrRevoked) {
+			return false, helpers.HandleGraphQLError(c, err)
+		}
+
+		return false, helpers.SendErrorWithData(c, http.StatusUnauthorized, msgInvalidToken, err, fiber.Map{
+			"reason": Reason(err),
+		})
+	}
+
+	return true, nil
 }
@@ -2,8 +2,11 @@ package auth_test
 
 import (
 	"net/http"
+	"os"
 	"testing"
 
+	"github.com/FiveIT/eseuri/server/issuer"
+	"github.com/FiveIT/eseuri/server/meta"
 	"github.com/FiveIT/eseuri/server/server/middleware/auth"
 	"github.com/FiveIT/eseuri/server/server/middleware/logger"
 	"github.com/FiveIT/eseuri/server/testhelper"
@@ -11,7 +14,14 @@ import (
 	"github.com/gofiber/fiber/v2/utils"
 )
 
-// App returns a Fiber App with the auth middleware applied,
+func TestMain(m *testing.M) {
+	// The tests authenticate with the tokens of the offline issuer.
+	meta.TrustDevIssuer = true
+
+	os.Exit(m.Run())
+}
+
+// App returns a Fiber App whose routes let through any authenticated user,
 // so it can be used for testing purposes.
 func App(tb testing.TB, customClaims ...chan auth.CustomClaims) *fiber.App {
 	tb.Helper()
@@ -27,7 +37,7 @@ func App(tb testing.TB, customClaims ...chan auth.CustomClaims) *fiber.App {
 		return c.Next()
 	}
 
-	return testhelper.App(tb, logger.Middleware(nil), auth.Middleware(), claimsMiddleware)
+	return testhelper.App(tb, logger.Middleware(nil), auth.NewGuard(auth.DefaultConfig(), nil).User(), claimsMiddleware)
 }
 
 func TestInvalidJWT(t *testing.T) {
@@ -52,7 +62,7 @@ func TestValidJWT(t *testing.T) {
 	ch := make(chan auth.CustomClaims, 1)
 	app := App(t, ch)
 
-	jwt := testhelper.JWT(t, true)
+	jwt := testhelper.JWT(t, issuer.User{ID: 1, Role: "student", Registered: true})
 	req := testhelper.Request(t, http.MethodGet, "/", nil, jwt)
 
 	res := testhelper.DoTestRequest(t, app, req)
@@ -64,3 +74,52 @@ func TestValidJWT(t *testing.T) {
 		t.Fatalf("Invalid custom claims: %+v", claims)
 	}
 }
+
+func TestRoleSelection(t *testing.T) {
+	t.Parallel()
+
+	user := issuer.User{ID: 1, Role: "teacher", Roles: []string{"moderator"}, Registered: true}
+
+	tests := []struct {
+		name   string
+		header string
+		role   string
+		status int
+	}{
+		{name: "Default role", header: "", role: "teacher", status: http.StatusNoContent},
+		{name: "Allowed role", header: "moderator", role: "moderator", status: http.StatusNoContent},
+		{name: "Anonymous role", header: "anonymous", role: "anonymous", status: http.StatusNoContent},
+		{name: "Forbidden role", header: "admin", role: "", status: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ch := make(chan auth.CustomClaims, 1)
+			app := App(t, ch)
+
+			req := testhelper.Request(t, http.MethodGet, "/", nil, testhelper.JWT(t, user))
+			if test.header != "" {
+				req.Header.Set(auth.HeaderRole, test.header)
+			}
+
+			res := testhelper.DoTestRequest(t, app, req)
+			defer res.Body.Close()
+
+			utils.AssertEqual(t, test.status, res.StatusCode)
+
+			if test.status != http.StatusNoContent {
+				return
+			}
+
+			claims := <-ch
+
+			utils.AssertEqual(t, test.role, claims.Role)
+			utils.AssertEqual(t, []string{"anonymous", "teacher", "moderator"}, claims.AllowedRoles)
+			utils.AssertEqual(t, map[string]string{"X-Hasura-Role": test.role, "X-Hasura-User-Id": "1"}, claims.Headers())
+		})
+	}
+}
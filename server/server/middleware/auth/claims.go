@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+)
+
+// defaultLeeway is the clock skew tolerated when checking the token's times, if none is configured.
+const defaultLeeway = 30 * time.Second
+
+var (
+	ErrExpired     = errors.New("auth: token is expired")
+	ErrNotYetValid = errors.New("auth: token is not valid yet")
+	ErrIssuer      = errors.New("auth: token has an unexpected issuer")
+	ErrAudience    = errors.New("auth: token has an unexpected audience")
+	ErrClaims      = errors.New("auth: token has invalid claims")
+)
+
+// audience is the "aud" claim, which is either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("audience must be a string or an array of strings: %w", err)
+	}
+
+	*a = multiple
+
+	return nil
+}
+
+// HasuraClaims are the claims Hasura authorizes the requests with. Their names are case insensitive.
+type HasuraClaims struct {
+	DefaultRole  string
+	AllowedRoles []string
+	UserID       string
+}
+
+func (h *HasuraClaims) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to decode Hasura claims: %w", err)
+	}
+
+	fields := map[string]interface{}{
+		"x-hasura-default-role":  &h.DefaultRole,
+		"x-hasura-allowed-roles": &h.AllowedRoles,
+		"x-hasura-user-id":       &h.UserID,
+	}
+
+	for name, value := range raw {
+		field, ok := fields[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+
+		if err := json.Unmarshal(value, field); err != nil {
+			return fmt.Errorf("invalid Hasura claim %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// EseuriClaims are the claims added to the tokens by the application's Auth0 rules.
+type EseuriClaims struct {
+	HasCompletedRegistration bool `json:"hasCompletedRegistration"`
+}
+
+// Claims are the claims of the tokens the service is authorized with.
+type Claims struct {
+	Issuer    string        `json:"iss"`
+	Subject   string        `json:"sub"`
+	Audience  audience      `json:"aud"`
+	ExpiresAt int64         `json:"exp"`
+	NotBefore int64         `json:"nbf"`
+	IssuedAt  int64         `json:"iat"`
+	Hasura    *HasuraClaims `json:"https://hasura.io/jwt/claims"`
+	Eseuri    EseuriClaims  `json:"https://eseuri.com"`
+}
+
+// Valid implements jwt.Claims. The claims are checked by a Validator instead, after the token is parsed.
+func (c *Claims) Valid() error {
+	return nil
+}
+
+// Custom returns the claims the routes use. The request is made with the default role, and
+// if no roles are allowed explicitly, the default role is the only one allowed.
+func (c *Claims) Custom() CustomClaims {
+	userID, _ := strconv.Atoi(c.Hasura.UserID)
+
+	allowed := c.Hasura.AllowedRoles
+	if len(allowed) == 0 {
+		allowed = []string{c.Hasura.DefaultRole}
+	}
+
+	return CustomClaims{
+		IsRegistered: c.Eseuri.HasCompletedRegistration,
+		UserID:       userID,
+		Role:         c.Hasura.DefaultRole,
+		AllowedRoles: allowed,
+		Scopes:       nil,
+	}
+}
+
+// Validator checks the claims of the tokens.
+type Validator struct {
+	// Issuers holds the issuers the tokens may have. It isn't checked if it's empty.
+	Issuers []string
+	// Audience holds the audiences of which the tokens must have at least one. It isn't checked if it's empty.
+	Audience []string
+	// Leeway is the clock skew tolerated when checking the token's times.
+	Leeway time.Duration
+}
+
+func unix(t int64) time.Time {
+	return time.Unix(t, 0)
+}
+
+func (v Validator) hasAudience(aud []string) bool {
+	if len(v.Audience) == 0 {
+		return true
+	}
+
+	for _, a := range aud {
+		if contains(v.Audience, a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks that the claims are valid at the given time.
+func (v Validator) Validate(c *Claims, now time.Time) error {
+	switch {
+	case c.ExpiresAt == 0:
+		return fmt.Errorf("%w: the expiration time is missing", ErrClaims)
+	case now.After(unix(c.ExpiresAt).Add(v.Leeway)):
+		return ErrExpired
+	case c.NotBefore != 0 && now.Before(unix(c.NotBefore).Add(-v.Leeway)):
+		return ErrNotYetValid
+	case c.IssuedAt != 0 && now.Before(unix(c.IssuedAt).Add(-v.Leeway)):
+		return fmt.Errorf("%w: token was issued in the future", ErrNotYetValid)
+	case len(v.Issuers) != 0 && !contains(v.Issuers, c.Issuer):
+		return ErrIssuer
+	case !v.hasAudience(c.Audience):
+		return ErrAudience
+	case c.Hasura == nil:
+		return fmt.Errorf("%w: the Hasura claims are missing", ErrClaims)
+	case c.Hasura.DefaultRole == "":
+		return fmt.Errorf("%w: the default role is missing", ErrClaims)
+	case len(c.Hasura.AllowedRoles) != 0 && !contains(c.Hasura.AllowedRoles, c.Hasura.DefaultRole):
+		return fmt.Errorf("%w: the default role is not allowed", ErrClaims)
+	}
+
+	if id, err := strconv.Atoi(c.Hasura.UserID); err != nil || id <= 0 {
+		return fmt.Errorf("%w: the user ID is invalid", ErrClaims)
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parser parses the tokens without validating their claims, as the Validator does that.
+//
+//nolint:exhaustivestruct,gochecknoglobals
+var parser = &jwt.Parser{
+	ValidMethods:         []string{jwt.SigningMethodRS256.Alg()},
+	SkipClaimsValidation: true,
+}
+
+// Reason returns a short code that tells why the token was rejected, for the clients to act upon.
+func Reason(err error) string {
+	var validation *jwt.ValidationError
+
+	switch {
+	case errors.Is(err, ErrMalformedToken):
+		return "malformed"
+	case errors.Is(err, ErrExpired):
+		return "expired"
+	case errors.Is(err, ErrNotYetValid):
+		return "not_yet_valid"
+	case errors.Is(err, ErrIssuer):
+		return "issuer"
+	case errors.Is(err, ErrAudience):
+		return "audience"
+	case errors.Is(err, ErrClaims):
+		return "claims"
+	case errors.Is(err, ErrInvalidAccessToken):
+		return "access_token"
+	case errors.Is(err, ErrRevoked):
+		return "revoked"
+	case errors.As(err, &validation):
+		switch {
+		case validation.Errors&jwt.ValidationErrorMalformed != 0:
+			return "malformed"
+		case validation.Errors&jwt.ValidationErrorUnverifiable != 0:
+			return "unknown_key"
+		case validation.Errors&jwt.ValidationErrorSignatureInvalid != 0:
+			return "signature"
+		}
+	}
+
+	return "invalid"
+}
@@ -0,0 +1,207 @@
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/middleware/logger"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+func withClaims(claims jwt.MapClaims, changes map[string]interface{}) jwt.MapClaims {
+	for k, v := range changes {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+
+	return claims
+}
+
+//nolint:funlen
+func TestClaimsValidation(t *testing.T) {
+	t.Parallel()
+
+	key, other := newSigningKey(t, ""), newSigningKey(t, "")
+	now := time.Now()
+
+	validator := auth.Validator{
+		Issuers:  []string{"https://eseuri.eu.auth0.com/"},
+		Audience: []string{"https://api.eseuri.com", "https://eseuri.com"},
+		Leeway:   time.Minute,
+	}
+
+	valid := func() jwt.MapClaims {
+		return withClaims(studentClaims(time.Hour), map[string]interface{}{
+			"iss": validator.Issuers[0],
+			"aud": []string{"https://eseuri.com", "https://eseuri.eu.auth0.com/userinfo"},
+		})
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		reason string
+		claims auth.CustomClaims
+	}{
+		{
+			name:   "valid",
+			token:  key.sign(t, valid()),
+			claims: auth.CustomClaims{IsRegistered: true, UserID: 1, Role: "student", AllowedRoles: []string{"student"}},
+		},
+		{
+			name: "string audience and lowercase Hasura claims",
+			token: key.sign(t, withClaims(valid(), map[string]interface{}{
+				"aud": "https://api.eseuri.com",
+				"https://hasura.io/jwt/claims": map[string]interface{}{
+					"x-hasura-default-role":  "teacher",
+					"x-hasura-allowed-roles": []string{"teacher"},
+					"x-hasura-user-id":       "7",
+				},
+			})),
+			claims: auth.CustomClaims{IsRegistered: true, UserID: 7, Role: "teacher", AllowedRoles: []string{"teacher"}},
+		},
+		{
+			name:   "unregistered user",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"https://eseuri.com": nil})),
+			claims: auth.CustomClaims{IsRegistered: false, UserID: 1, Role: "student", AllowedRoles: []string{"student"}},
+		},
+		{
+			name:   "expired within leeway",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()})),
+			claims: auth.CustomClaims{IsRegistered: true, UserID: 1, Role: "student", AllowedRoles: []string{"student"}},
+		},
+		{
+			name:   "issued slightly in the future",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"iat": now.Add(3 * time.Second).Unix()})),
+			claims: auth.CustomClaims{IsRegistered: true, UserID: 1, Role: "student", AllowedRoles: []string{"student"}},
+		},
+		{
+			name:   "expired",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()})),
+			reason: "expired",
+		},
+		{
+			name:   "no expiration",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"exp": nil})),
+			reason: "claims",
+		},
+		{
+			name:   "not valid yet",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"nbf": now.Add(2 * time.Minute).Unix()})),
+			reason: "not_yet_valid",
+		},
+		{
+			name:   "wrong issuer",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"iss": "https://evil.auth0.com/"})),
+			reason: "issuer",
+		},
+		{
+			name:   "wrong audience",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"aud": "https://evil.com"})),
+			reason: "audience",
+		},
+		{
+			name:   "no audience",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"aud": nil})),
+			reason: "audience",
+		},
+		{
+			name:   "no Hasura claims",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"https://hasura.io/jwt/claims": nil})),
+			reason: "claims",
+		},
+		{
+			name: "invalid user ID",
+			token: key.sign(t, withClaims(valid(), map[string]interface{}{
+				"https://hasura.io/jwt/claims": map[string]interface{}{
+					"X-Hasura-Default-Role": "student",
+					"X-Hasura-User-Id":      "auth0|test",
+				},
+			})),
+			reason: "claims",
+		},
+		{
+			name: "role not allowed",
+			token: key.sign(t, withClaims(valid(), map[string]interface{}{
+				"https://hasura.io/jwt/claims": map[string]interface{}{
+					"X-Hasura-Default-Role":  "teacher",
+					"X-Hasura-Allowed-Roles": []string{"student"},
+					"X-Hasura-User-Id":       "1",
+				},
+			})),
+			reason: "claims",
+		},
+		{
+			name: "mistyped claims",
+			token: key.sign(t, withClaims(valid(), map[string]interface{}{
+				"https://hasura.io/jwt/claims": map[string]interface{}{
+					"X-Hasura-Default-Role": 1,
+					"X-Hasura-User-Id":      1,
+				},
+			})),
+			reason: "malformed",
+		},
+		{
+			name:   "mistyped registration",
+			token:  key.sign(t, withClaims(valid(), map[string]interface{}{"https://eseuri.com": "yes"})),
+			reason: "malformed",
+		},
+		{
+			name:   "wrong key",
+			token:  other.sign(t, valid()),
+			reason: "signature",
+		},
+		{
+			name:   "not a token",
+			token:  "Bearer amSarmale",
+			reason: "malformed",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ch := make(chan auth.CustomClaims, 1)
+			app := testhelper.App(t, logger.Middleware(nil), auth.NewGuard(auth.Config{
+				Keys:      auth.NewKeySet("", &key.key.PublicKey),
+				Validator: validator,
+			}, nil).User(), func(c *fiber.Ctx) error {
+				ch <- c.Locals("claims").(auth.CustomClaims)
+
+				return c.Next()
+			})
+
+			res := testhelper.DoTestRequest(t, app, testhelper.Request(t, http.MethodGet, "/", nil, test.token))
+			defer res.Body.Close()
+
+			if test.reason == "" {
+				testhelper.AssertSuccess(t, res)
+				utils.AssertEqual(t, test.claims, <-ch)
+
+				return
+			}
+
+			var response struct {
+				Error  string
+				Reason string
+			}
+
+			testhelper.DecodeJSON(t, res.Body, &response)
+
+			utils.AssertEqual(t, http.StatusUnauthorized, res.StatusCode)
+			utils.AssertEqual(t, "invalid or expired token", response.Error)
+			utils.AssertEqual(t, test.reason, response.Reason)
+		})
+	}
+}
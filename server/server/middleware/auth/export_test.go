@@ -0,0 +1,13 @@
+package auth
+
+import "time"
+
+// SetMinRefreshInterval changes how often the key set may be fetched, so tests don't have to wait.
+func (s *KeySet) SetMinRefreshInterval(d time.Duration) {
+	s.minRefresh = d
+}
+
+// SetClock makes the cache count the time with the given clock.
+func (r *Revocations) SetClock(now func() time.Time) {
+	r.now = now
+}
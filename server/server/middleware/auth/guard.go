@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+const msgForbiddenRole = "nu ai permisiunea de a accesa această resursă"
+
+// hasRole checks if the authenticated user has one of the roles. Any role is accepted if none are given.
+func hasRole(c *fiber.Ctx, roles []string) (bool, error) {
+	claims, ok := c.Locals("claims").(CustomClaims)
+	if !ok {
+		return false, helpers.SendError(c, fiber.StatusUnauthorized, "nu ești autentificat", nil)
+	}
+
+	if len(roles) == 0 || contains(roles, claims.Role) {
+		return true, nil
+	}
+
+	return false, helpers.SendErrorWithData(c, fiber.StatusForbidden, msgForbiddenRole, nil, fiber.Map{
+		"roles": roles,
+	})
+}
+
+// Guard creates the middlewares that enforce who may access each route. Every route is
+// annotated with one of them, so its policy is stated where the route is registered.
+type Guard struct {
+	config Config
+	client *graphql.Client
+	// scope is the scope a personal access token must have to access the guarded routes.
+	// If it's empty, the routes can't be accessed with personal access tokens.
+	scope string
+}
+
+// NewGuard creates a guard that authenticates the users with the given configuration, and that checks the
+// registration of the users whose tokens don't say they're registered. The personal access tokens and,
+// unless the configuration has its own, the revoked tokens are looked up with the client too.
+func NewGuard(cfg Config, client *graphql.Client) *Guard {
+	cfg.Client = client
+
+	if cfg.Revocations == nil && client != nil {
+		cfg.Revocations = NewRevocations(client, RevocationsTTL)
+	}
+
+	return &Guard{config: cfg, client: client, scope: ""}
+}
+
+// Scoped creates a guard whose routes may be accessed with the personal access tokens that have the given scope.
+func (g *Guard) Scoped(scope string) *Guard {
+	scoped := *g
+	scoped.scope = scope
+
+	return &scoped
+}
+
+// authorize authenticates the user and checks if they have one of the roles, and the guard's scope
+// if they used a personal access token. Revoked tokens are looked up last, as the requests that are
+// denied anyway don't need to wait for it.
+func (g *Guard) authorize(c *fiber.Ctx, roles []string) (bool, error) {
+	if ok, err := authenticate(c, g.config); !ok {
+		return false, err
+	}
+
+	if ok, err := hasRole(c, roles); !ok {
+		return false, err
+	}
+
+	if ok, err := hasScope(c, g.scope); !ok {
+		return false, err
+	}
+
+	return checkRevocation(c, g.config)
+}
+
+// Public lets anyone access the route.
+func (g *Guard) Public() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.Next()
+	}
+}
+
+// Optional lets anyone access the route, but it authenticates the users that send a token,
+// so the route can tell the logged in users apart.
+func (g *Guard) Optional() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get(fiber.HeaderAuthorization) == "" {
+			return c.Next()
+		}
+
+		if ok, err := g.authorize(c, nil); !ok {
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// User lets through the authenticated users that have one of the given roles, or any role if none are given,
+// even if they haven't completed their registration.
+func (g *Guard) User(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if ok, err := g.authorize(c, roles); !ok {
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// Registered lets through the registered users that have one of the given roles, or any role if none are given.
+func (g *Guard) Registered(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if ok, err := g.authorize(c, roles); !ok {
+			return err
+		}
+
+		if ok, err := assertRegistered(c, g.client); !ok {
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// Admin lets through only the requests that have the Hasura admin secret, which are made by Hasura itself.
+func (g *Guard) Admin() fiber.Handler {
+	return AssertAdminSecret()
+}
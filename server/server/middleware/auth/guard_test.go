@@ -0,0 +1,139 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/issuer"
+	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/middleware/logger"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/machinebox/graphql"
+)
+
+// registeredUserID is the ID of the user that completed their registration after their token was issued.
+const registeredUserID = 2
+
+// fakeHasura answers the queries for the users' registration.
+func fakeHasura(tb testing.TB) *graphql.Client {
+	tb.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables struct {
+				ID int `json:"id"`
+			} `json:"variables"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var updatedAt interface{}
+		if req.Variables.ID == registeredUserID {
+			updatedAt = "2021-05-17T12:00:00"
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"users": []map[string]interface{}{{"updated_at": updatedAt, "role": "student"}},
+			},
+		})
+	}))
+
+	tb.Cleanup(server.Close)
+
+	return graphql.NewClient(server.URL)
+}
+
+type identity struct {
+	name  string
+	token string
+	admin bool
+}
+
+//nolint:funlen
+func TestGuard(t *testing.T) {
+	if meta.HasuraAdminSecret == "" {
+		meta.HasuraAdminSecret = "test"
+	}
+
+	t.Parallel()
+
+	iss, err := issuer.Generate()
+	if err != nil {
+		t.Fatalf("Failed to create issuer: %v", err)
+	}
+
+	keys := auth.NewKeySet("", nil)
+	keys.Trust(issuer.KeyID, iss.PublicKey())
+
+	guard := auth.NewGuard(auth.Config{Keys: keys, Validator: auth.Validator{Leeway: time.Minute}}, fakeHasura(t))
+
+	token := func(id int, role string, registered bool) string {
+		token, err := iss.Token(issuer.User{ID: id, Role: role, Registered: registered}, time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to issue token: %v", err)
+		}
+
+		return token
+	}
+
+	identities := []identity{
+		{name: "anonymous"},
+		{name: "unregistered", token: token(1, workstatus.RoleStudent, false)},
+		{name: "newly registered", token: token(registeredUserID, workstatus.RoleStudent, false)},
+		{name: "student", token: token(3, workstatus.RoleStudent, true)},
+		{name: "teacher", token: token(4, workstatus.RoleTeacher, true)},
+		{name: "admin", admin: true},
+	}
+
+	const ok = http.StatusNoContent
+
+	policies := []struct {
+		name    string
+		handler fiber.Handler
+		// statuses are the expected statuses for each identity, in order.
+		statuses []int
+	}{
+		{"public", guard.Public(), []int{ok, ok, ok, ok, ok, ok}},
+		{"optional", guard.Optional(), []int{ok, ok, ok, ok, ok, ok}},
+		{"user", guard.User(), []int{400, ok, ok, ok, ok, 400}},
+		{"teacher user", guard.User(workstatus.RoleTeacher), []int{400, 403, 403, 403, ok, 400}},
+		{"registered", guard.Registered(), []int{400, 401, ok, ok, ok, 400}},
+		{"registered student", guard.Registered(workstatus.RoleStudent), []int{400, 401, ok, ok, 403, 400}},
+		{"registered teacher", guard.Registered(workstatus.RoleTeacher), []int{400, 403, 403, 403, ok, 400}},
+		{"admin", guard.Admin(), []int{401, 401, 401, 401, 401, ok}},
+	}
+
+	for _, policy := range policies {
+		app := testhelper.App(t, logger.Middleware(nil), policy.handler)
+
+		for i, id := range identities {
+			policy, id, expected := policy, id, policy.statuses[i]
+
+			t.Run(policy.name+"/"+id.name, func(t *testing.T) {
+				t.Parallel()
+
+				req := testhelper.Request(t, http.MethodGet, "/", nil, id.token)
+				if id.admin {
+					req.Header.Set("X-Hasura-Admin-Secret", meta.HasuraAdminSecret)
+				}
+
+				res := testhelper.DoTestRequest(t, app, req)
+				defer res.Body.Close()
+
+				utils.AssertEqual(t, expected, res.StatusCode)
+			})
+		}
+	}
+}
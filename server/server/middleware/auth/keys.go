@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// keysMaxAge is for how long the fetched keys are used before they are fetched again,
+	// so keys removed from the set stop being trusted.
+	keysMaxAge = time.Hour
+	// minRefreshInterval is the minimum time between two fetches of the key set, so tokens
+	// with unknown key IDs can't be used to flood the key set's source with requests.
+	minRefreshInterval = 30 * time.Second
+	// fetchTimeout is for how long fetching the key set may take.
+	fetchTimeout = 10 * time.Second
+)
+
+var (
+	ErrUnknownKey  = errors.New("auth: unknown signing key")
+	ErrNoKeys      = errors.New("auth: the key set has no RSA signing keys")
+	ErrKeySetFetch = errors.New("auth: failed to fetch key set")
+)
+
+// jwk is a JSON Web Key, as described in RFC 7517. Only RSA keys are supported.
+type jwk struct {
+	Kty string   `json:"kty"`
+	Kid string   `json:"kid"`
+	Use string   `json:"use"`
+	Alg string   `json:"alg"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	X5c []string `json:"x5c"`
+}
+
+func (k jwk) publicKey() (*rsa.PublicKey, error) {
+	if k.N != "" && k.E != "" {
+		n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.N, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.E, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("exponent is too large")
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	}
+
+	if len(k.X5c) != 0 {
+		der, err := base64.StdEncoding.DecodeString(k.X5c[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("certificate doesn't have an RSA key")
+		}
+
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("key has neither parameters nor certificates")
+}
+
+// parseKeySet returns the RSA signing keys of a JSON Web Key Set, by their IDs.
+// The keys that aren't used for signing tokens with RS256 are ignored.
+func parseKeySet(r io.Reader) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode key set: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	return keys, nil
+}
+
+// KeySet holds the public keys the tokens are verified with. The keys are fetched from a JSON Web Key Set,
+// which is either at an URL or in a file, and they are selected by the ID in the tokens' headers. The set
+// is fetched again when it's too old or when a token has an unknown key ID, so the signing keys can be rotated,
+// but not more often than once every 30 seconds. The static key, if any, verifies the tokens without a key ID,
+// and all the tokens if the set has no source or can't be fetched.
+type KeySet struct {
+	source string
+	static *rsa.PublicKey
+	client *http.Client
+	// minRefresh is the minimum time between two fetches of the set.
+	minRefresh time.Duration
+
+	mu sync.RWMutex
+	// trusted holds the keys that are always used, whatever the fetched set is.
+	trusted   map[string]*rsa.PublicKey
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	// attemptedAt is when the set was last fetched, even if unsuccessfully.
+	attemptedAt time.Time
+	// refreshing serializes the fetches of the set.
+	refreshing sync.Mutex
+}
+
+// NewKeySet creates a key set that fetches its keys from the given source, an URL or a file path, and falls back
+// to the static key. The source is optional, and so is the static key, but at least one of them must be given.
+func NewKeySet(source string, static *rsa.PublicKey) *KeySet {
+	//nolint:exhaustivestruct
+	return &KeySet{
+		source:     source,
+		static:     static,
+		client:     &http.Client{Timeout: fetchTimeout},
+		minRefresh: minRefreshInterval,
+	}
+}
+
+func (s *KeySet) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		f, err := os.Open(strings.TrimPrefix(s.source, "file://"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrKeySetFetch, err)
+		}
+		defer f.Close()
+
+		return parseKeySet(f)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeySetFetch, err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeySetFetch, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrKeySetFetch, res.StatusCode)
+	}
+
+	return parseKeySet(res.Body)
+}
+
+// Refresh fetches the key set again, unless it was fetched less than 30 seconds ago.
+// If fetching fails, the previously fetched keys are kept.
+func (s *KeySet) Refresh(ctx context.Context) error {
+	if s.source == "" {
+		return nil
+	}
+
+	s.refreshing.Lock()
+	defer s.refreshing.Unlock()
+
+	s.mu.RLock()
+	attemptedAt := s.attemptedAt
+	s.mu.RUnlock()
+
+	if !attemptedAt.IsZero() && time.Since(attemptedAt) < s.minRefresh {
+		return nil
+	}
+
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attemptedAt = time.Now()
+
+	if err != nil {
+		return err
+	}
+
+	s.keys, s.fetchedAt = keys, s.attemptedAt
+
+	return nil
+}
+
+// Trust makes the key set verify the tokens with the given key ID using the key,
+// even if the key isn't in the fetched set.
+func (s *KeySet) Trust(kid string, key *rsa.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.trusted == nil {
+		s.trusted = make(map[string]*rsa.PublicKey)
+	}
+
+	s.trusted[kid] = key
+}
+
+func (s *KeySet) trustedKey(kid string) (*rsa.PublicKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.trusted[kid]
+
+	return key, ok
+}
+
+func (s *KeySet) lookup(kid string) (key *rsa.PublicKey, ok, stale bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok = s.keys[kid]
+
+	return key, ok, s.fetchedAt.IsZero() || time.Since(s.fetchedAt) > keysMaxAge
+}
+
+// Key returns the key with the given ID. The trusted keys are looked up first. The static key is returned for tokens without a key ID,
+// or if the key set can't be fetched.
+func (s *KeySet) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, ok := s.trustedKey(kid); ok {
+		return key, nil
+	}
+
+	if kid == "" || s.source == "" {
+		if s.static == nil {
+			return nil, ErrUnknownKey
+		}
+
+		return s.static, nil
+	}
+
+	key, ok, stale := s.lookup(kid)
+	if ok && !stale {
+		return key, nil
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		log.Warn().Err(err).Str("source", s.source).Msg("failed to refresh the signing keys")
+	}
+
+	s.mu.RLock()
+	key, ok = s.keys[kid]
+	fetched := s.keys != nil
+	s.mu.RUnlock()
+
+	switch {
+	case ok:
+		return key, nil
+	case !fetched && s.static != nil:
+		return s.static, nil
+	default:
+		return nil, ErrUnknownKey
+	}
+}
+
+// Keyfunc returns the key the token must be verified with.
+func (s *KeySet) Keyfunc(ctx context.Context) jwt.Keyfunc {
+	return func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jwt.SigningMethodRS256.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+
+		kid, _ := t.Header["kid"].(string)
+
+		return s.Key(ctx, kid)
+	}
+}
@@ -0,0 +1,249 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/middleware/logger"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	jwt "github.com/form3tech-oss/jwt-go"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+type signingKey struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+func newSigningKey(tb testing.TB, id string) signingKey {
+	tb.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		tb.Fatalf("Failed to generate key: %v", err)
+	}
+
+	return signingKey{id: id, key: key}
+}
+
+// studentClaims are the claims of a registered student's token that expires after the given duration.
+func studentClaims(expiresIn time.Duration) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "auth0|test",
+		"exp": time.Now().Add(expiresIn).Unix(),
+		"https://hasura.io/jwt/claims": map[string]interface{}{
+			"X-Hasura-Default-Role": "student",
+			"X-Hasura-User-Id":      "1",
+		},
+		"https://eseuri.com": map[string]interface{}{
+			"hasCompletedRegistration": true,
+		},
+	}
+}
+
+// sign signs a token with the given claims and the key's ID in its header.
+func (k signingKey) sign(tb testing.TB, claims jwt.MapClaims) string {
+	tb.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+
+	if k.id != "" {
+		token.Header["kid"] = k.id
+	}
+
+	signed, err := token.SignedString(k.key)
+	if err != nil {
+		tb.Fatalf("Failed to sign token: %v", err)
+	}
+
+	return "Bearer " + signed
+}
+
+// token signs a token for a registered student.
+func (k signingKey) token(tb testing.TB, expiresIn time.Duration) string {
+	tb.Helper()
+
+	return k.sign(tb, studentClaims(expiresIn))
+}
+
+func keySetJSON(tb testing.TB, keys ...signingKey) []byte {
+	tb.Helper()
+
+	set := make([]map[string]string, 0, len(keys))
+	for _, k := range keys {
+		set = append(set, map[string]string{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": k.id,
+			"n":   base64.RawURLEncoding.EncodeToString(k.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.key.E)).Bytes()),
+		})
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"keys": set})
+	if err != nil {
+		tb.Fatalf("Failed to encode key set: %v", err)
+	}
+
+	return data
+}
+
+// keyServer serves a key set that can be changed, counting how many times it was fetched.
+type keyServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	set     []byte
+	status  int
+	fetches int32
+}
+
+func newKeyServer(tb testing.TB, set []byte) *keyServer {
+	tb.Helper()
+
+	s := &keyServer{set: set, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.fetches, 1)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		w.WriteHeader(s.status)
+		_, _ = w.Write(s.set)
+	}))
+
+	tb.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *keyServer) serve(status int, set []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status, s.set = status, set
+}
+
+func statusWithKeys(tb testing.TB, keys *auth.KeySet, token string) int {
+	tb.Helper()
+
+	app := testhelper.App(tb, logger.Middleware(nil), auth.NewGuard(auth.Config{Keys: keys, Validator: auth.Validator{}}, nil).User())
+
+	res := testhelper.DoTestRequest(tb, app, testhelper.Request(tb, http.MethodGet, "/", nil, token))
+	defer res.Body.Close()
+
+	return res.StatusCode
+}
+
+func TestKeySetURL(t *testing.T) {
+	t.Parallel()
+
+	first, second := newSigningKey(t, "first"), newSigningKey(t, "second")
+	server := newKeyServer(t, keySetJSON(t, first))
+	keys := auth.NewKeySet(server.URL, nil)
+
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, keys, first.token(t, time.Hour)))
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, keys, first.token(t, time.Hour)))
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&server.fetches), "keys are cached")
+
+	// Unknown keys cause the set to be fetched again, but not right after it was fetched.
+	utils.AssertEqual(t, http.StatusUnauthorized, statusWithKeys(t, keys, second.token(t, time.Hour)))
+	utils.AssertEqual(t, http.StatusUnauthorized, statusWithKeys(t, keys, second.token(t, time.Hour)))
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&server.fetches), "refreshes are rate limited")
+
+	utils.AssertEqual(t, http.StatusUnauthorized, statusWithKeys(t, keys, first.token(t, -time.Minute)), "expired token")
+	utils.AssertEqual(t, http.StatusBadRequest, statusWithKeys(t, keys, "Basic amSarmale"), "malformed header")
+	utils.AssertEqual(t, http.StatusUnauthorized, statusWithKeys(t, keys, "Bearer amSarmale"), "malformed token")
+}
+
+func TestKeySetRotation(t *testing.T) {
+	t.Parallel()
+
+	first, second := newSigningKey(t, "first"), newSigningKey(t, "second")
+	server := newKeyServer(t, keySetJSON(t, first))
+	keys := auth.NewKeySet(server.URL, nil)
+	keys.SetMinRefreshInterval(0)
+
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, keys, first.token(t, time.Hour)))
+
+	// The new key is published alongside the old one, then the old one is removed.
+	server.serve(http.StatusOK, keySetJSON(t, first, second))
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, keys, second.token(t, time.Hour)))
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, keys, first.token(t, time.Hour)))
+
+	server.serve(http.StatusOK, keySetJSON(t, second))
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, keys, second.token(t, time.Hour)))
+
+	// Failing to fetch the set keeps the previous keys.
+	server.serve(http.StatusInternalServerError, nil)
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, keys, second.token(t, time.Hour)))
+
+	// The removed key is no longer known once the set is fetched again.
+	server.serve(http.StatusOK, keySetJSON(t, second))
+
+	if err := keys.Refresh(context.Background()); err != nil {
+		t.Fatalf("Failed to refresh keys: %v", err)
+	}
+
+	utils.AssertEqual(t, http.StatusUnauthorized, statusWithKeys(t, keys, first.token(t, time.Hour)))
+}
+
+func TestKeySetFile(t *testing.T) {
+	t.Parallel()
+
+	key := newSigningKey(t, "file")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+
+	if err := os.WriteFile(path, keySetJSON(t, key), 0o600); err != nil {
+		t.Fatalf("Failed to write key set: %v", err)
+	}
+
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, auth.NewKeySet(path, nil), key.token(t, time.Hour)))
+	utils.AssertEqual(t, http.StatusNoContent, statusWithKeys(t, auth.NewKeySet("file://"+path, nil), key.token(t, time.Hour)))
+}
+
+//nolint:lll
+func TestKeySetStaticFallback(t *testing.T) {
+	t.Parallel()
+
+	static, other := newSigningKey(t, ""), newSigningKey(t, "other")
+	server := newKeyServer(t, nil)
+	server.serve(http.StatusServiceUnavailable, nil)
+
+	tests := []struct {
+		name   string
+		keys   *auth.KeySet
+		token  string
+		status int
+	}{
+		{"no source", auth.NewKeySet("", &static.key.PublicKey), static.token(t, time.Hour), http.StatusNoContent},
+		{"no key ID", auth.NewKeySet(server.URL, &static.key.PublicKey), static.token(t, time.Hour), http.StatusNoContent},
+		{"unavailable set", auth.NewKeySet(server.URL, &static.key.PublicKey), signingKey{id: "x", key: static.key}.token(t, time.Hour), http.StatusNoContent},
+		{"wrong key", auth.NewKeySet(server.URL, &static.key.PublicKey), other.token(t, time.Hour), http.StatusUnauthorized},
+		{"no static key", auth.NewKeySet(server.URL, nil), static.token(t, time.Hour), http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			utils.AssertEqual(t, test.status, statusWithKeys(t, test.keys, test.token))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/machinebox/graphql"
+)
+
+const (
+	// RevocationsTTL is for how long the revocation watermarks are cached, so it's how long
+	// revoked tokens may still be used on instances that had the user's watermark cached.
+	RevocationsTTL = 30 * time.Second
+	// maxRevocations is the number of cached watermarks after which the expired ones are forgotten.
+	maxRevocations = 10000
+)
+
+var ErrRevoked = errors.New("auth: token was revoked")
+
+type revocation struct {
+	validAfter time.Time
+	fetchedAt  time.Time
+}
+
+// Revocations caches the users' revocation watermarks: the tokens issued before a user's watermark are revoked.
+// The database updates the watermark when the user deletes their account or their role changes. The instances
+// don't learn about the updates, so the revoked tokens are still accepted for up to the cache's TTL by the
+// instances that have the user's previous watermark cached.
+type Revocations struct {
+	client  *graphql.Client
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[int]revocation
+}
+
+// NewRevocations creates a cache of the watermarks, which are fetched with the client and kept for the given time.
+func NewRevocations(client *graphql.Client, ttl time.Duration) *Revocations {
+	return &Revocations{
+		client:  client,
+		ttl:     ttl,
+		now:     time.Now,
+		mu:      sync.Mutex{},
+		entries: make(map[int]revocation),
+	}
+}
+
+// fetch gets the user's watermark from the database. It's the zero time if the user's tokens were never revoked.
+func (r *Revocations) fetch(ctx context.Context, userID int) (time.Time, error) {
+	var resp gqlqueries.TokensValidAfterOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(r.client, gqlqueries.TokensValidAfter, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: ctx,
+		Vars: map[string]interface{}{
+			"id": userID,
+		},
+		Promote: true,
+	}); err != nil {
+		return time.Time{}, err
+	}
+
+	if resp.Query == nil || resp.Query.TokensValidAfter == nil {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, *resp.Query.TokensValidAfter)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("auth: invalid revocation watermark: %w", err)
+	}
+
+	return t, nil
+}
+
+// ValidAfter returns the user's watermark, from the cache if it was fetched recently.
+func (r *Revocations) ValidAfter(ctx context.Context, userID int) (time.Time, error) {
+	now := r.now()
+
+	r.mu.Lock()
+	entry, ok := r.entries[userID]
+	r.mu.Unlock()
+
+	if ok && now.Sub(entry.fetchedAt) < r.ttl {
+		return entry.validAfter, nil
+	}
+
+	validAfter, err := r.fetch(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) >= maxRevocations {
+		for id, e := range r.entries {
+			if now.Sub(e.fetchedAt) >= r.ttl {
+				delete(r.entries, id)
+			}
+		}
+	}
+
+	r.entries[userID] = revocation{validAfter: validAfter, fetchedAt: now}
+
+	return validAfter, nil
+}
+
+// Check returns ErrRevoked if the token issued at the given Unix time was revoked. The times of the tokens
+// are in seconds, so the watermark is rounded up to the next second: the tokens issued during the second
+// the watermark was set in are rejected, as they may have been issued before the revocation.
+func (r *Revocations) Check(ctx context.Context, userID int, issuedAt int64) error {
+	validAfter, err := r.ValidAfter(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if validAfter.IsZero() {
+		return nil
+	}
+
+	if truncated := validAfter.Truncate(time.Second); !truncated.Equal(validAfter) {
+		validAfter = truncated.Add(time.Second)
+	}
+
+	if issuedAt < validAfter.Unix() {
+		return ErrRevoked
+	}
+
+	return nil
+}
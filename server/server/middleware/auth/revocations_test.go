@@ -0,0 +1,165 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/issuer"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/middleware/logger"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/machinebox/graphql"
+)
+
+// fakeWatermarks answers the queries for the users' revocation watermarks, and counts them.
+type fakeWatermarks struct {
+	mu         sync.Mutex
+	watermarks map[int]time.Time
+	requests   int32
+}
+
+func (f *fakeWatermarks) set(userID int, t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.watermarks[userID] = t
+}
+
+func (f *fakeWatermarks) client(tb testing.TB) *graphql.Client {
+	tb.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&f.requests, 1)
+
+		var req struct {
+			Variables struct {
+				ID int `json:"id"`
+			} `json:"variables"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		f.mu.Lock()
+		watermark, ok := f.watermarks[req.Variables.ID]
+		f.mu.Unlock()
+
+		var validAfter interface{}
+		if ok {
+			validAfter = watermark.Format("2006-01-02T15:04:05.999999-07:00")
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"users_all_by_pk": map[string]interface{}{"tokens_valid_after": validAfter},
+			},
+		})
+	}))
+
+	tb.Cleanup(server.Close)
+
+	return graphql.NewClient(server.URL)
+}
+
+func TestRevocations(t *testing.T) {
+	t.Parallel()
+
+	watermark := time.Date(2021, 5, 19, 12, 0, 0, 500000000, time.UTC)
+	fake := &fakeWatermarks{watermarks: map[int]time.Time{1: watermark}}
+
+	now := watermark.Add(time.Minute)
+	revocations := auth.NewRevocations(fake.client(t), 10*time.Second)
+	revocations.SetClock(func() time.Time { return now })
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		userID   int
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"issued before", 1, watermark.Add(-time.Hour), true},
+		{"issued during the same second", 1, watermark, true},
+		{"issued right after", 1, watermark.Add(time.Second / 2), false},
+		{"issued after", 1, watermark.Add(time.Second), false},
+		{"never revoked", 2, watermark.Add(-time.Hour), false},
+	}
+
+	for _, test := range tests {
+		err := revocations.Check(ctx, test.userID, test.issuedAt.Unix())
+		utils.AssertEqual(t, test.revoked, errors.Is(err, auth.ErrRevoked), test.name)
+
+		if err != nil && !test.revoked {
+			t.Fatalf("%s: failed to check revocation: %v", test.name, err)
+		}
+	}
+
+	utils.AssertEqual(t, int32(2), atomic.LoadInt32(&fake.requests), "watermarks are cached")
+
+	// a newer watermark is seen once the cached one expires
+	fake.set(1, watermark.Add(time.Hour))
+	issuedAt := watermark.Add(time.Second).Unix()
+
+	utils.AssertEqual(t, nil, revocations.Check(ctx, 1, issuedAt))
+
+	now = now.Add(10 * time.Second)
+
+	utils.AssertEqual(t, auth.ErrRevoked, revocations.Check(ctx, 1, issuedAt))
+
+	utils.AssertEqual(t, int32(3), atomic.LoadInt32(&fake.requests))
+}
+
+func TestRevokedToken(t *testing.T) {
+	t.Parallel()
+
+	iss, err := issuer.Generate()
+	if err != nil {
+		t.Fatalf("Failed to create issuer: %v", err)
+	}
+
+	keys := auth.NewKeySet("", nil)
+	keys.Trust(issuer.KeyID, iss.PublicKey())
+
+	fake := &fakeWatermarks{watermarks: map[int]time.Time{1: time.Now().Add(time.Minute)}}
+
+	app := testhelper.App(t, logger.Middleware(nil), auth.NewGuard(auth.Config{
+		Keys:        keys,
+		Validator:   auth.Validator{},
+		Revocations: auth.NewRevocations(fake.client(t), time.Minute),
+	}, nil).User())
+
+	for id, expected := range map[int]int{1: http.StatusUnauthorized, 2: http.StatusNoContent} {
+		token, err := iss.Token(issuer.User{ID: id, Role: "teacher", Registered: true}, time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to issue token: %v", err)
+		}
+
+		res := testhelper.DoTestRequest(t, app, testhelper.Request(t, http.MethodGet, "/", nil, token))
+
+		utils.AssertEqual(t, expected, res.StatusCode)
+
+		if expected == http.StatusUnauthorized {
+			var response struct {
+				Reason string
+			}
+
+			testhelper.DecodeJSON(t, res.Body, &response)
+
+			utils.AssertEqual(t, "revoked", response.Reason)
+		}
+
+		res.Body.Close()
+	}
+}
@@ -0,0 +1,8 @@
+package ratelimit
+
+import "time"
+
+// SetClock makes the limiter count the time with the given clock.
+func SetClock(l *Limiter, now func() time.Time) {
+	l.now = now
+}
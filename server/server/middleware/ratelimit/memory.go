@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store forgets the buckets that are full.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// Memory keeps the buckets in memory, so each instance of the service has its own.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemory creates an empty memory store.
+func NewMemory() *Memory {
+	return &Memory{mu: sync.Mutex{}, buckets: make(map[string]*bucket), lastSweep: time.Time{}}
+}
+
+// Take implements Store.
+func (m *Memory) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now, limit: limit}
+		m.buckets[key] = b
+	}
+
+	tokens, result := take(b.tokens, b.last, now, limit)
+	b.tokens, b.limit = tokens, limit
+
+	if now.After(b.last) {
+		b.last = now
+	}
+
+	return result, nil
+}
+
+// sweep forgets the buckets that would be full by now, as they are the same as new ones.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		if b.tokens+float64(now.Sub(b.last))/float64(b.limit.Every) >= float64(b.limit.Burst) {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Package ratelimit throttles the requests of each user, or of each client IP address for anonymous requests.
+
+Every route has its own token buckets, one for each user: a request takes a token, and the tokens are
+refilled at a steady rate, up to the bucket's capacity. The buckets are kept in a Store, either in memory
+or in a Redis-compatible server, which is shared by all the instances of the service.
+*/
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+)
+
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+
+	// keyPrefix starts the keys of the buckets, so they don't collide with other keys in a shared store.
+	keyPrefix = "ratelimit:"
+)
+
+// Limit is the token bucket of a route.
+type Limit struct {
+	// Burst is the capacity of the bucket, the number of requests that may be made at once.
+	Burst int
+	// Every is how often a token is added to the bucket.
+	Every time.Duration
+}
+
+// Result is the state of a bucket after a request tried to take a token from it.
+type Result struct {
+	Allowed bool
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+	// RetryAfter is how long until a token is added, if the request wasn't allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the token buckets.
+type Store interface {
+	// Take takes a token from the bucket with the given key, if it has one.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// take updates a bucket that had the given tokens at the given time, and takes a token from it if it has one.
+// It returns the tokens left, which are fractional, and the result of the request.
+func take(tokens float64, last, now time.Time, limit Limit) (float64, Result) {
+	if elapsed := now.Sub(last); elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+float64(elapsed)/float64(limit.Every))
+	}
+
+	result := Result{Allowed: tokens >= 1, Remaining: 0, RetryAfter: 0, Reset: 0}
+
+	if result.Allowed {
+		tokens--
+	} else {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(limit.Every))
+	}
+
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration((float64(limit.Burst) - tokens) * float64(limit.Every))
+
+	return tokens, result
+}
+
+// Limiter creates the middlewares that throttle the routes.
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+// New creates a limiter that keeps its buckets in the given store.
+func New(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// identity returns what the request's bucket is keyed by: the authenticated user, or the client's IP address.
+// The middleware must run after the guard to key the buckets by user.
+func identity(c *fiber.Ctx) string {
+	if claims, ok := c.Locals("claims").(auth.CustomClaims); ok && claims.UserID != 0 {
+		return "user:" + strconv.Itoa(claims.UserID)
+	}
+
+	return "ip:" + helpers.ClientIP(c)
+}
+
+// seconds rounds the duration up to whole seconds, as the headers require.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// retryMessage tells the user when they may retry, in Romanian. The numerals whose last two digits
+// aren't between 1 and 19 need "de" before the noun.
+func retryMessage(retryAfter int) string {
+	const prefix = "ai trimis prea multe cereri, încearcă din nou peste "
+
+	switch n := retryAfter % 100; {
+	case retryAfter == 1:
+		return prefix + "o secundă"
+	case n >= 1 && n <= 19:
+		return prefix + strconv.Itoa(retryAfter) + " secunde"
+	default:
+		return prefix + strconv.Itoa(retryAfter) + " de secunde"
+	}
+}
+
+// Route creates a middleware that throttles the requests to the routes with the given name. Routes with the same
+// name share their buckets. If the store fails, the requests are let through.
+func (l *Limiter) Route(name string, limit Limit) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := keyPrefix + name + ":" + identity(c)
+
+		result, err := l.store.Take(c.Context(), key, limit, l.now())
+		if err != nil {
+			logger := c.Locals("logger").(zerolog.Logger)
+			logger.Error().Err(err).Str("route", name).Msg("failed to check rate limit")
+
+			return c.Next()
+		}
+
+		c.Set(HeaderLimit, strconv.Itoa(limit.Burst))
+		c.Set(HeaderRemaining, strconv.Itoa(result.Remaining))
+		c.Set(HeaderReset, strconv.Itoa(seconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := seconds(result.RetryAfter)
+
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+			return helpers.SendErrorWithData(c, fiber.StatusTooManyRequests, retryMessage(retryAfter), nil, fiber.Map{
+				"retryAfter": retryAfter,
+			})
+		}
+
+		return c.Next()
+	}
+}
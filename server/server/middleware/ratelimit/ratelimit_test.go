@@ -0,0 +1,342 @@
+package ratelimit_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/redis"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/middleware/logger"
+	"github.com/FiveIT/eseuri/server/server/middleware/ratelimit"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// testConnectionIP is the address the test requests come from.
+const testConnectionIP = "0.0.0.0"
+
+func TestMain(m *testing.M) {
+	// The test requests come through a proxy that adds the X-Forwarded-For header.
+	meta.TrustedProxies = testConnectionIP
+
+	os.Exit(m.Run())
+}
+
+// clock is a fake clock that moves only when told to.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	store := ratelimit.NewMemory()
+	limit := ratelimit.Limit{Burst: 2, Every: 10 * time.Second}
+	now := time.Now()
+
+	take := func(key string, at time.Time) ratelimit.Result {
+		result, err := store.Take(context.Background(), key, limit, at)
+		if err != nil {
+			t.Fatalf("Failed to take token: %v", err)
+		}
+
+		return result
+	}
+
+	utils.AssertEqual(t, ratelimit.Result{Allowed: true, Remaining: 1, Reset: 10 * time.Second}, take("a", now))
+	utils.AssertEqual(t, ratelimit.Result{Allowed: true, Remaining: 0, Reset: 20 * time.Second}, take("a", now))
+	utils.AssertEqual(t, ratelimit.Result{Allowed: false, RetryAfter: 10 * time.Second, Reset: 20 * time.Second}, take("a", now))
+	utils.AssertEqual(t, ratelimit.Result{Allowed: false, RetryAfter: 6 * time.Second, Reset: 16 * time.Second}, take("a", now.Add(4*time.Second)))
+	utils.AssertEqual(t, true, take("a", now.Add(10*time.Second)).Allowed)
+	utils.AssertEqual(t, true, take("b", now).Allowed)
+	// the bucket is full again after a while, and is forgotten
+	utils.AssertEqual(t, ratelimit.Result{Allowed: true, Remaining: 1, Reset: 10 * time.Second}, take("a", now.Add(time.Hour)))
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit, time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store is down")
+}
+
+// withUser sets the claims of the user with the given ID from the X-User header, as the auth middleware would.
+func withUser(c *fiber.Ctx) error {
+	if id := c.Get("X-User"); id != "" {
+		c.Locals("claims", auth.CustomClaims{IsRegistered: true, UserID: int(id[0] - '0'), Role: "student", Scopes: nil})
+	}
+
+	return c.Next()
+}
+
+//nolint:funlen
+func TestLimiter(t *testing.T) {
+	t.Parallel()
+
+	clock := &clock{now: time.Now()}
+	limiter := ratelimit.New(ratelimit.NewMemory())
+	ratelimit.SetClock(limiter, clock.Now)
+
+	app := testhelper.App(t, logger.Middleware(nil), withUser, limiter.Route("upload", ratelimit.Limit{Burst: 2, Every: 30 * time.Second}))
+
+	request := func(user, ip string) *http.Response {
+		req := testhelper.Request(t, http.MethodGet, "/", nil)
+		if user != "" {
+			req.Header.Set("X-User", user)
+		}
+
+		req.Header.Set(fiber.HeaderXForwardedFor, ip)
+
+		return testhelper.DoTestRequest(t, app, req)
+	}
+
+	type expected struct {
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}
+
+	tests := []struct {
+		name    string
+		user    string
+		ip      string
+		advance time.Duration
+		expected
+	}{
+		{"first", "1", "10.0.0.1", 0, expected{http.StatusNoContent, "1", "30", ""}},
+		{"second", "1", "10.0.0.2", 0, expected{http.StatusNoContent, "0", "60", ""}},
+		{"throttled", "1", "10.0.0.3", 0, expected{http.StatusTooManyRequests, "0", "60", "30"}},
+		{"other user", "2", "10.0.0.1", 0, expected{http.StatusNoContent, "1", "30", ""}},
+		{"anonymous by IP", "", "10.0.0.1", 0, expected{http.StatusNoContent, "1", "30", ""}},
+		{"still throttled", "1", "10.0.0.1", 15 * time.Second, expected{http.StatusTooManyRequests, "0", "45", "15"}},
+		{"refilled", "1", "10.0.0.1", 15 * time.Second, expected{http.StatusNoContent, "0", "60", ""}},
+	}
+
+	for _, test := range tests {
+		clock.Advance(test.advance)
+
+		res := request(test.user, test.ip)
+
+		utils.AssertEqual(t, test.status, res.StatusCode, test.name)
+		utils.AssertEqual(t, "2", res.Header.Get(ratelimit.HeaderLimit), test.name)
+		utils.AssertEqual(t, test.remaining, res.Header.Get(ratelimit.HeaderRemaining), test.name)
+		utils.AssertEqual(t, test.reset, res.Header.Get(ratelimit.HeaderReset), test.name)
+		utils.AssertEqual(t, test.retryAfter, res.Header.Get(fiber.HeaderRetryAfter), test.name)
+
+		if test.status == http.StatusTooManyRequests {
+			var body struct {
+				Error      string
+				RetryAfter int
+			}
+
+			testhelper.DecodeJSON(t, res.Body, &body)
+
+			if !strings.HasSuffix(body.Error, "peste "+test.retryAfter+" de secunde") && !strings.HasSuffix(body.Error, "peste "+test.retryAfter+" secunde") {
+				t.Fatalf("%s: unexpected error %q", test.name, body.Error)
+			}
+
+			utils.AssertEqual(t, test.retryAfter, fmt.Sprint(body.RetryAfter), test.name)
+		}
+
+		res.Body.Close()
+	}
+}
+
+func TestLimiterSpoofedIP(t *testing.T) {
+	t.Parallel()
+
+	limiter := ratelimit.New(ratelimit.NewMemory())
+	app := testhelper.App(t, logger.Middleware(nil), limiter.Route("search", ratelimit.Limit{Burst: 1, Every: time.Minute}))
+
+	request := func(forwardedFor string) int {
+		req := testhelper.Request(t, http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderXForwardedFor, forwardedFor)
+
+		res := testhelper.DoTestRequest(t, app, req)
+		defer res.Body.Close()
+
+		return res.StatusCode
+	}
+
+	// The proxy appends the address it received the request from, the client may send any other.
+	utils.AssertEqual(t, http.StatusNoContent, request("1.1.1.1, 10.0.0.1"))
+	utils.AssertEqual(t, http.StatusTooManyRequests, request("2.2.2.2, 10.0.0.1"), "spoofed address")
+	utils.AssertEqual(t, http.StatusTooManyRequests, request("10.0.0.1"), "no spoofed address")
+	utils.AssertEqual(t, http.StatusNoContent, request("1.1.1.1, 10.0.0.2"), "other client")
+}
+
+//nolint:paralleltest
+func TestLimiterUntrustedProxy(t *testing.T) {
+	trustedProxies := meta.TrustedProxies
+	defer func() { meta.TrustedProxies = trustedProxies }()
+
+	meta.TrustedProxies = "10.0.0.0/8"
+
+	limiter := ratelimit.New(ratelimit.NewMemory())
+	app := testhelper.App(t, logger.Middleware(nil), limiter.Route("search", ratelimit.Limit{Burst: 1, Every: time.Minute}))
+
+	request := func(forwardedFor string) int {
+		req := testhelper.Request(t, http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderXForwardedFor, forwardedFor)
+
+		res := testhelper.DoTestRequest(t, app, req)
+		defer res.Body.Close()
+
+		return res.StatusCode
+	}
+
+	// The request doesn't come from a trusted proxy, so the client sent the header itself.
+	utils.AssertEqual(t, http.StatusNoContent, request("10.0.0.1"))
+	utils.AssertEqual(t, http.StatusTooManyRequests, request("10.0.0.2"), "forged address")
+}
+
+func TestLimiterStoreFailure(t *testing.T) {
+	t.Parallel()
+
+	limiter := ratelimit.New(failingStore{})
+	app := testhelper.App(t, logger.Middleware(nil), limiter.Route("email", ratelimit.Limit{Burst: 1, Every: time.Hour}))
+
+	for i := 0; i < 3; i++ {
+		res := testhelper.DoTestRequest(t, app, testhelper.Request(t, http.MethodGet, "/", nil))
+		res.Body.Close()
+
+		testhelper.AssertSuccess(t, res)
+	}
+}
+
+// fakeRedis answers the scripts with the given reply, and records the commands it gets.
+func fakeRedis(tb testing.TB, reply string) (*redis.Client, <-chan []string) {
+	tb.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatalf("Failed to listen: %v", err)
+	}
+
+	tb.Cleanup(func() { _ = l.Close() })
+
+	commands := make(chan []string, 10)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		for {
+			var n int
+			if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+				return
+			}
+
+			args := make([]string, n)
+
+			for i := range args {
+				var size int
+				if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+					return
+				}
+
+				b := make([]byte, size+2)
+				if _, err := io.ReadFull(r, b); err != nil {
+					return
+				}
+
+				args[i] = string(b[:size])
+			}
+
+			commands <- args
+
+			if args[0] == "EVALSHA" {
+				fmt.Fprint(conn, "-NOSCRIPT No matching script. Please use EVAL.\r\n")
+			} else {
+				fmt.Fprint(conn, reply)
+			}
+		}
+	}()
+
+	client, err := redis.New("redis://" + l.Addr().String())
+	if err != nil {
+		tb.Fatalf("Failed to create client: %v", err)
+	}
+
+	tb.Cleanup(func() { _ = client.Close() })
+
+	return client, commands
+}
+
+func TestRedis(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		reply    string
+		expected ratelimit.Result
+	}{
+		{"allowed", "*2\r\n:1\r\n$3\r\n1.5\r\n", ratelimit.Result{Allowed: true, Remaining: 1, Reset: 15 * time.Second}},
+		{"throttled", "*2\r\n:0\r\n$4\r\n0.25\r\n", ratelimit.Result{Allowed: false, RetryAfter: 7500 * time.Millisecond, Reset: 27500 * time.Millisecond}},
+		{"unexpected reply", "+OK\r\n", ratelimit.Result{}},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, commands := fakeRedis(t, test.reply)
+			now := time.Unix(1621339997, 263000000)
+
+			result, err := ratelimit.NewRedis(client).Take(context.Background(), "ratelimit:upload:user:1", ratelimit.Limit{Burst: 3, Every: 10 * time.Second}, now)
+			if test.expected == (ratelimit.Result{}) {
+				if !errors.Is(err, ratelimit.ErrRedisReply) {
+					t.Fatalf("Expected reply error, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Failed to take token: %v", err)
+			}
+
+			utils.AssertEqual(t, test.expected, result)
+
+			<-commands
+			eval := <-commands
+			utils.AssertEqual(t, "EVAL", eval[0])
+			utils.AssertEqual(t, []string{"1", "ratelimit:upload:user:1", "3", "10000", "1621339997263"}, eval[2:])
+		})
+	}
+}
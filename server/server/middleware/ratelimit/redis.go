@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/redis"
+)
+
+var ErrRedisReply = errors.New("ratelimit: unexpected reply from Redis")
+
+// takeScript updates the bucket and takes a token from it atomically, the same way take does. The bucket
+// is a hash of its tokens and of the time they were counted, in milliseconds, which expires once the
+// bucket would be full. It returns whether a token was taken and the tokens left, as a string, because
+// Lua numbers are truncated to integers in replies.
+//
+//nolint:gochecknoglobals
+var takeScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local every = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(bucket[1]) or burst
+local last = tonumber(bucket[2]) or now
+
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) / every)
+	last = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', tostring(last))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * every))
+
+return {allowed, tostring(tokens)}
+`)
+
+// Redis keeps the buckets in a Redis-compatible server, so all the instances of the service share them.
+// The instances' clocks should be synchronized, as they count the time for the buckets.
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis creates a store that keeps the buckets in the server of the given client.
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Take implements Store.
+func (r *Redis) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	reply, err := takeScript.Run(ctx, r.client, []string{key},
+		strconv.Itoa(limit.Burst),
+		strconv.FormatInt(limit.Every.Milliseconds(), 10),
+		strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10))
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: failed to take token: %w", err)
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("%w: %v", ErrRedisReply, reply)
+	}
+
+	allowed, ok := values[0].(int64)
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %v", ErrRedisReply, reply)
+	}
+
+	s, ok := values[1].(string)
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %v", ErrRedisReply, reply)
+	}
+
+	tokens, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrRedisReply, reply)
+	}
+
+	// the bucket is already updated, so the result is computed as if no time passed and no token was taken
+	if allowed == 1 {
+		tokens++
+	}
+
+	_, result := take(tokens, now, now, limit)
+
+	return result, nil
+}
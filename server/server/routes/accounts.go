@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"encoding/json"
+
+	"github.com/FiveIT/eseuri/server/meta/auth0"
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+	"github.com/rs/zerolog"
+)
+
+type deletedUserRow struct {
+	Auth0ID   string  `json:"auth0_id"`
+	DeletedAt *string `json:"deleted_at"`
+}
+
+type teacherRequestRow struct {
+	UserID int    `json:"user_id"`
+	Status string `json:"status"`
+}
+
+// parseEvent decodes the rows of the Hasura event in the request's body. It returns false if the body is invalid,
+// in which case the response was sent.
+func parseEvent(c *fiber.Ctx, before, after interface{}) (bool, error) {
+	var event helpers.HasuraEvent
+
+	if err := c.BodyParser(&event); err != nil {
+		return false, helpers.SendError(c, fiber.StatusBadRequest, "evenimentul este invalid", err)
+	}
+
+	for _, row := range []struct {
+		data json.RawMessage
+		out  interface{}
+	}{{event.Event.Data.Old, before}, {event.Event.Data.New, after}} {
+		if len(row.data) == 0 {
+			continue
+		}
+
+		if err := json.Unmarshal(row.data, row.out); err != nil {
+			return false, helpers.SendError(c, fiber.StatusBadRequest, "evenimentul este invalid", err)
+		}
+	}
+
+	return true, nil
+}
+
+// sendAuth0Error responds with the Management API's error. The Hasura event triggers retry the failed requests.
+func sendAuth0Error(c *fiber.Ctx, err error) error {
+	return helpers.SendError(c, fiber.StatusBadGateway, "nu s-a putut actualiza contul Auth0", err)
+}
+
+// DeleteAuth0User deletes the Auth0 identity of the user whose account was deleted, so they can't log in again.
+// Otherwise, logging in would restore the account. It is called by a Hasura event trigger.
+func DeleteAuth0User(a *auth0.Auth0) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var before, after deletedUserRow
+
+		if ok, err := parseEvent(c, &before, &after); !ok {
+			return err
+		}
+
+		if before.DeletedAt != nil || after.DeletedAt == nil {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		if err := a.DeleteUser(c.Context(), after.Auth0ID); err != nil {
+			return sendAuth0Error(c, err)
+		}
+
+		logger := c.Locals("logger").(zerolog.Logger)
+		logger.Info().Str("auth0ID", after.Auth0ID).Msg("deleted auth0 user")
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// PromoteAuth0User updates the Auth0 metadata of the user whose teacher request was approved.
+// It is called by a Hasura event trigger.
+func PromoteAuth0User(client *graphql.Client, a *auth0.Auth0) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var before, after teacherRequestRow
+
+		if ok, err := parseEvent(c, &before, &after); !ok {
+			return err
+		}
+
+		if before.Status == after.Status || after.Status != "approved" {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		var resp gqlqueries.Auth0IDOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.Auth0ID, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"id": after.UserID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if resp.Query == nil {
+			return helpers.SendError(c, fiber.StatusNotFound, "utilizatorul nu există", nil)
+		}
+
+		role, registered := workstatus.RoleTeacher, true
+
+		if err := a.UpdateAppMetadata(c.Context(), resp.Query.Auth0ID, auth0.AppMetadata{
+			Role:                     &role,
+			HasCompletedRegistration: &registered,
+		}); err != nil {
+			return sendAuth0Error(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"encoding/json"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/text/analysis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+	"github.com/rs/zerolog"
+)
+
+// WorkAnalysis sends the analysis report of the work's content to the users that are allowed to see the work.
+// Reports are cached for each version of the content, and recomputed when the content or the heuristics change.
+func WorkAnalysis(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		if ok, err := isWorkVisible(c, client, workID); !ok {
+			return err
+		}
+
+		var resp gqlqueries.WorkAnalysisOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.WorkAnalysis, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID": workID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if resp.Work == nil {
+			return helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu există", nil)
+		}
+
+		if a := resp.Analysis; a != nil && a.Version == resp.Work.Version && a.AnalyzerVersion == analysis.Version {
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			return c.Send(a.Report)
+		}
+
+		report := analysis.Analyze(resp.Work.Content)
+
+		if err := saveWorkAnalysis(c, client, workID, resp.Work.Version, report); err != nil {
+			logger := c.Locals("logger").(zerolog.Logger)
+			logger.Warn().Err(err).Int("work", workID).Msg("failed to cache work analysis")
+		}
+
+		return c.JSON(report)
+	}
+}
+
+func saveWorkAnalysis(c *fiber.Ctx, client *graphql.Client, workID, version int, report analysis.Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	//nolint:exhaustivestruct
+	return helpers.GraphQLRequest(client, gqlqueries.SaveWorkAnalysis, helpers.GraphQLRequestOptions{
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"analysis": map[string]interface{}{
+				"work_id":          workID,
+				"version":          version,
+				"analyzer_version": analysis.Version,
+				"report":           json.RawMessage(data),
+				"created_at":       timestampNow(),
+			},
+		},
+		Promote: true,
+	})
+}
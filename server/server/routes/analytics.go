@@ -0,0 +1,283 @@
+package routes
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/analytics"
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/search"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/text"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+// timestampLayout is the format of the timestamps sent to the database.
+const timestampLayout = "2006-01-02T15:04:05"
+
+// timestampNow returns the current time in UTC, formatted for the database. The timestamps are sent
+// by the server, instead of letting the database use its own time zone, so they are all in UTC.
+func timestampNow() string {
+	return time.Now().UTC().Format(timestampLayout)
+}
+
+type viewStats struct {
+	Views     int `json:"views"`
+	Reads     int `json:"reads"`
+	WeekViews int `json:"weekViews"`
+	WeekReads int `json:"weekReads"`
+}
+
+func newViewStats(s gqlqueries.ViewStats) viewStats {
+	return viewStats{
+		Views:     s.Views,
+		Reads:     s.Reads,
+		WeekViews: s.WeekViews,
+		WeekReads: s.WeekReads,
+	}
+}
+
+type workStats struct {
+	viewStats
+	// AverageCompletion is the average fraction of the work that was read.
+	AverageCompletion float64 `json:"averageCompletion"`
+}
+
+func newWorkStats(s gqlqueries.WorkViewStat) workStats {
+	return workStats{viewStats: newViewStats(s.ViewStats), AverageCompletion: s.AverageCompletion}
+}
+
+type rankedWork struct {
+	searchWork
+	Stats workStats `json:"stats"`
+}
+
+// RecordView records that the current visitor, be it a logged in user or an anonymous one, read the
+// approved work. Readers should send the view when they open the work, and then periodically, with
+// how far they scrolled and how long they read, so the completion of their view is updated.
+func RecordView(client *graphql.Client, hasher *analytics.Hasher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if hasher == nil {
+			return helpers.SendError(c, fiber.StatusServiceUnavailable, "statisticile nu sunt disponibile", nil)
+		}
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		var input helpers.ViewInput
+
+		if err := c.BodyParser(&input); err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "vizualizarea este invalidă", err)
+		}
+
+		var work gqlqueries.ApprovedWorkContentOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.ApprovedWorkContent, helpers.GraphQLRequestOptions{
+			Output:  &work,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"id": workID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if len(work.Query) == 0 {
+			return helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu există", nil)
+		}
+
+		identity := "ip:" + helpers.ClientIP(c)
+		if claims, ok := c.Locals("claims").(auth.CustomClaims); ok {
+			identity = "user:" + strconv.Itoa(claims.UserID)
+		}
+
+		now := time.Now()
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.RecordWorkView, helpers.GraphQLRequestOptions{
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID":        workID,
+				"visitor":       hasher.Visitor(identity, workID, now),
+				"windowStart":   analytics.WindowStart(now).Format(timestampLayout),
+				"completion":    analytics.Completion(input.Progress, input.Seconds, len(text.Words(work.Query[0].Content))),
+				"readThreshold": analytics.ReadThreshold,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// ExpireViews forgets the visitors of the windows that ended. Their views remain counted.
+func ExpireViews(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var resp gqlqueries.ExpireWorkViewsOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.ExpireWorkViews, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"before": analytics.WindowStart(time.Now()).Format(timestampLayout),
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"expired": resp.Query.AffectedRows,
+		})
+	}
+}
+
+func fetchWorkViewStats(c *fiber.Ctx, client *graphql.Client, vars map[string]interface{}) ([]gqlqueries.WorkViewStat, error) {
+	var resp gqlqueries.WorkViewStatsOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.WorkViewStats, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars:    vars,
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	return resp.Query, nil
+}
+
+// rankWorks sends a page of the approved works, ordered by the given statistic.
+func rankWorks(client *graphql.Client, orderBy string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, offset, err := pagination(c)
+		if limit == 0 {
+			return err
+		}
+
+		stats, err := fetchWorkViewStats(c, client, map[string]interface{}{
+			"where": map[string]interface{}{
+				"work": map[string]interface{}{
+					"status": map[string]interface{}{"_eq": workstatus.Approved},
+				},
+				orderBy: map[string]interface{}{"_gt": 0},
+			},
+			"orderBy": []map[string]interface{}{{orderBy: "desc"}, {"work_id": "desc"}},
+			"limit":   limit,
+			"offset":  offset,
+		})
+		if stats == nil {
+			return err
+		}
+
+		ids := make([]int, 0, len(stats))
+		for _, s := range stats {
+			ids = append(ids, s.WorkID)
+		}
+
+		docs, err := fetchSearchDocuments(c.Context(), client, map[string]interface{}{
+			"id": map[string]interface{}{"_in": ids},
+		}, len(ids))
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[int]search.Document, len(docs))
+		for _, d := range docs {
+			byID[d.ID] = d
+		}
+
+		works := make([]rankedWork, 0, len(stats))
+
+		for _, s := range stats {
+			if d, ok := byID[s.WorkID]; ok {
+				works = append(works, rankedWork{searchWork: newSearchWork(d, 0), Stats: newWorkStats(s)})
+			}
+		}
+
+		return c.JSON(works)
+	}
+}
+
+// TrendingWorks sends the approved works that were viewed the most in the last 7 days.
+func TrendingWorks(client *graphql.Client) fiber.Handler {
+	return rankWorks(client, "week_views")
+}
+
+// MostReadWorks sends the approved works that were read to the end the most times.
+func MostReadWorks(client *graphql.Client) fiber.Handler {
+	return rankWorks(client, "reads")
+}
+
+// WorkStats sends the view statistics of the approved work.
+func WorkStats(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		stats, err := fetchWorkViewStats(c, client, map[string]interface{}{
+			"where": map[string]interface{}{
+				"work_id": map[string]interface{}{"_eq": workID},
+				"work": map[string]interface{}{
+					"status": map[string]interface{}{"_eq": workstatus.Approved},
+				},
+			},
+		})
+		if stats == nil {
+			return err
+		}
+
+		// Works that weren't viewed yet have no statistics.
+		if len(stats) == 0 {
+			var empty workStats
+
+			return c.JSON(empty)
+		}
+
+		return c.JSON(newWorkStats(stats[0]))
+	}
+}
+
+// TitleStats sends the view statistics of the approved works about the title, counting both
+// the essays on the title and the characterizations of its characters.
+func TitleStats(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		titleID, err := paramID(c, "id")
+		if titleID == 0 {
+			return err
+		}
+
+		var resp gqlqueries.TitleViewStatsOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.TitleViewStats, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"titleID": titleID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if len(resp.Query) == 0 {
+			return c.JSON(viewStats{Views: 0, Reads: 0, WeekViews: 0, WeekReads: 0})
+		}
+
+		return c.JSON(newViewStats(resp.Query[0]))
+	}
+}
@@ -0,0 +1,374 @@
+package routes
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+// maxAnnotationLength is the maximum number of characters of an annotation's body.
+const maxAnnotationLength = 2000
+
+type annotationOutput struct {
+	ID         int      `json:"id"`
+	WorkID     int      `json:"workID"`
+	Version    int      `json:"version"`
+	Start      int      `json:"start"`
+	End        int      `json:"end"`
+	Body       string   `json:"body"`
+	Outdated   bool     `json:"outdated"`
+	Resolved   bool     `json:"resolved"`
+	ResolvedBy *int     `json:"resolvedBy"`
+	ResolvedAt *string  `json:"resolvedAt"`
+	CreatedAt  string   `json:"createdAt"`
+	UpdatedAt  *string  `json:"updatedAt"`
+	Author     userName `json:"author"`
+}
+
+func newAnnotationOutput(a *gqlqueries.Annotation) annotationOutput {
+	out := annotationOutput{
+		ID:         a.ID,
+		WorkID:     a.WorkID,
+		Version:    a.Version,
+		Start:      a.StartOffset,
+		End:        a.EndOffset,
+		Body:       a.Body,
+		Outdated:   a.Outdated,
+		Resolved:   a.ResolvedAt != nil,
+		ResolvedBy: a.ResolvedBy,
+		ResolvedAt: a.ResolvedAt,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+		//nolint:exhaustivestruct
+		Author: userName{
+			ID: a.UserID,
+		},
+	}
+
+	if u := a.User; u != nil {
+		out.Author.FirstName = u.FirstName
+		out.Author.MiddleName = u.MiddleName
+		out.Author.LastName = u.LastName
+	}
+
+	return out
+}
+
+// assertParticipant checks if the user takes part in the work's review, so they may
+// see its annotations or its grade. The associations of the work's author are fetched only
+// if the user is a teacher that isn't otherwise related to the work.
+func assertParticipant(c *fiber.Ctx, client *graphql.Client, workID int, message string) (*workstatus.Work, error) {
+	claims := c.Locals("claims").(auth.CustomClaims)
+	actor := workstatus.Actor{ID: claims.UserID, Role: claims.Role}
+
+	work, err := fetchWork(c, client, workID)
+	if work == nil {
+		return nil, err
+	}
+
+	if !work.IsParticipant(actor) && actor.Role == workstatus.RoleTeacher {
+		if ok, err := fetchAssociations(c, client, work); !ok {
+			return nil, err
+		}
+	}
+
+	if !work.IsParticipant(actor) {
+		return nil, helpers.SendError(c, fiber.StatusForbidden, message, nil)
+	}
+
+	return work, nil
+}
+
+func assertAnnotationAccess(c *fiber.Ctx, client *graphql.Client, workID int) (bool, error) {
+	work, err := assertParticipant(c, client, workID, "nu ai acces la adnotările acestei lucrări")
+
+	return work != nil, err
+}
+
+func parseAnnotationBody(c *fiber.Ctx, body string) (string, error) {
+	body = strings.TrimSpace(body)
+
+	if body == "" {
+		return "", helpers.SendError(c, fiber.StatusBadRequest, "adnotarea nu are conținut", nil)
+	}
+
+	if utf8.RuneCountInString(body) > maxAnnotationLength {
+		return "", helpers.SendError(c, fiber.StatusBadRequest, "adnotarea este prea lungă", nil)
+	}
+
+	return body, nil
+}
+
+// fetchAnnotation retrieves the annotation from the URL, if it belongs to the work from the URL.
+func fetchAnnotation(c *fiber.Ctx, client *graphql.Client) (*gqlqueries.Annotation, error) {
+	workID, err := paramID(c, "id")
+	if workID == 0 {
+		return nil, err
+	}
+
+	annotationID, err := paramID(c, "annotation")
+	if annotationID == 0 {
+		return nil, err
+	}
+
+	if ok, err := assertAnnotationAccess(c, client, workID); !ok {
+		return nil, err
+	}
+
+	var resp gqlqueries.AnnotationByPKOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.AnnotationByPK, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"id": annotationID,
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	if resp.Query == nil || resp.Query.WorkID != workID {
+		return nil, helpers.SendError(c, fiber.StatusNotFound, "adnotarea nu există", nil)
+	}
+
+	return resp.Query, nil
+}
+
+// fetchOwnAnnotation works like fetchAnnotation, but only the annotation's author may access it.
+func fetchOwnAnnotation(c *fiber.Ctx, client *graphql.Client) (*gqlqueries.Annotation, error) {
+	claims := c.Locals("claims").(auth.CustomClaims)
+
+	annotation, err := fetchAnnotation(c, client)
+	if annotation == nil {
+		return nil, err
+	}
+
+	if annotation.UserID != claims.UserID {
+		return nil, helpers.SendError(c, fiber.StatusForbidden, "doar autorul adnotării o poate modifica", nil)
+	}
+
+	return annotation, nil
+}
+
+func updateAnnotation(c *fiber.Ctx, client *graphql.Client, id int, set map[string]interface{}) error {
+	var resp gqlqueries.UpdateAnnotationOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.UpdateAnnotation, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"id":  id,
+			"set": set,
+		},
+		Promote: true,
+	}); err != nil {
+		return helpers.HandleGraphQLError(c, err)
+	}
+
+	if resp.Query == nil {
+		return helpers.SendError(c, fiber.StatusNotFound, "adnotarea nu există", nil)
+	}
+
+	return c.JSON(newAnnotationOutput(resp.Query))
+}
+
+// Annotations lists the annotations of a work, in the order of the passages they refer to.
+// The resolved or unresolved annotations can be selected using the "resolved" query parameter.
+func Annotations(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		resolved := c.Query("resolved")
+		if resolved != "" && resolved != "true" && resolved != "false" {
+			return helpers.SendError(c, fiber.StatusBadRequest, "filtrul de rezolvare este invalid", nil)
+		}
+
+		if ok, err := assertAnnotationAccess(c, client, workID); !ok {
+			return err
+		}
+
+		var resp gqlqueries.AnnotationsOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.Annotations, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID": workID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		out := make([]annotationOutput, 0, len(resp.Query))
+
+		for i := range resp.Query {
+			a := newAnnotationOutput(&resp.Query[i])
+			if resolved == "" || (resolved == "true") == a.Resolved {
+				out = append(out, a)
+			}
+		}
+
+		return c.JSON(out)
+	}
+}
+
+// CreateAnnotation adds an annotation to a passage of the given version of the work's content.
+// If the content was changed meanwhile, the annotation isn't created, as the offsets could be wrong.
+func CreateAnnotation(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		var input helpers.AnnotationInput
+
+		if err := c.BodyParser(&input); err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "formularul de adnotare este invalid", err)
+		}
+
+		body, err := parseAnnotationBody(c, input.Body)
+		if body == "" {
+			return err
+		}
+
+		if ok, err := assertAnnotationAccess(c, client, workID); !ok {
+			return err
+		}
+
+		content, err := fetchWorkContent(c, client, workID)
+		if content == nil {
+			return err
+		}
+
+		if content.Version != input.Version {
+			return helpers.SendErrorWithData(c, fiber.StatusConflict, "conținutul lucrării s-a schimbat între timp", nil, fiber.Map{
+				"version": content.Version,
+			})
+		}
+
+		if input.Start < 0 || input.Start > input.End || input.End > utf8.RuneCountInString(content.Content) {
+			return helpers.SendError(c, fiber.StatusBadRequest, "pasajul adnotat nu există în lucrare", nil)
+		}
+
+		var resp gqlqueries.InsertAnnotationOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.InsertAnnotation, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"object": map[string]interface{}{
+					"work_id":      workID,
+					"user_id":      claims.UserID,
+					"version":      input.Version,
+					"start_offset": input.Start,
+					"end_offset":   input.End,
+					"body":         body,
+				},
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(newAnnotationOutput(&resp.Query))
+	}
+}
+
+// EditAnnotation changes the body of an annotation. Only the annotation's author may edit it.
+func EditAnnotation(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input helpers.AnnotationEditInput
+
+		if err := c.BodyParser(&input); err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "formularul de adnotare este invalid", err)
+		}
+
+		body, err := parseAnnotationBody(c, input.Body)
+		if body == "" {
+			return err
+		}
+
+		annotation, err := fetchOwnAnnotation(c, client)
+		if annotation == nil {
+			return err
+		}
+
+		return updateAnnotation(c, client, annotation.ID, map[string]interface{}{
+			"body": body,
+		})
+	}
+}
+
+// DeleteAnnotation removes an annotation. Only the annotation's author may remove it.
+func DeleteAnnotation(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		annotation, err := fetchOwnAnnotation(c, client)
+		if annotation == nil {
+			return err
+		}
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.DeleteAnnotation, helpers.GraphQLRequestOptions{
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"id": annotation.ID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// ResolveAnnotation marks an annotation as resolved, for example after the author
+// fixed the annotated passage. Anyone with access to the annotations may resolve them.
+func ResolveAnnotation(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		annotation, err := fetchAnnotation(c, client)
+		if annotation == nil {
+			return err
+		}
+
+		return updateAnnotation(c, client, annotation.ID, map[string]interface{}{
+			"resolved_by": claims.UserID,
+			"resolved_at": timestampNow(),
+		})
+	}
+}
+
+// ReopenAnnotation marks a resolved annotation as unresolved.
+func ReopenAnnotation(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		annotation, err := fetchAnnotation(c, client)
+		if annotation == nil {
+			return err
+		}
+
+		return updateAnnotation(c, client, annotation.ID, map[string]interface{}{
+			"resolved_by": nil,
+			"resolved_at": nil,
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package routes
+
+import (
+	"strings"
+
+	"github.com/FiveIT/eseuri/server/anchor"
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/spelling"
+	"github.com/FiveIT/eseuri/server/storage"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/go-tika/tika"
+	"github.com/machinebox/graphql"
+)
+
+// errContentChanged is the message of the exception raised by the database
+// when the work's content was replaced after it was fetched.
+const errContentChanged = "conținutul lucrării s-a schimbat între timp"
+
+type contentOutput struct {
+	ID      int `json:"id"`
+	Version int `json:"version"`
+}
+
+func fetchWorkContent(c *fiber.Ctx, client *graphql.Client, workID int) (*gqlqueries.WorkContent, error) {
+	var resp gqlqueries.WorkContentOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.WorkContentByPK, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"id": workID,
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	if resp.Query == nil {
+		return nil, helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu există", nil)
+	}
+
+	return resp.Query, nil
+}
+
+// rebaseAnnotations moves the work's annotations onto the new version of its content
+// and returns the objects that must be upserted in the database. Annotations whose
+// passages were removed are kept, but they are marked as outdated.
+//
+//nolint:lll
+func rebaseAnnotations(c *fiber.Ctx, client *graphql.Client, workID int, old *gqlqueries.WorkContent, content string) ([]map[string]interface{}, error) {
+	var resp gqlqueries.AnnotationsOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.Annotations, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"workID": workID,
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	ranges := make([]anchor.Range, 0, len(resp.Query))
+	for _, a := range resp.Query {
+		ranges = append(ranges, anchor.Range{Start: a.StartOffset, End: a.EndOffset})
+	}
+
+	results := anchor.Rebase(old.Content, content, ranges)
+	objects := make([]map[string]interface{}, 0, len(results))
+
+	for i, r := range results {
+		a := resp.Query[i]
+
+		objects = append(objects, map[string]interface{}{
+			"id":           a.ID,
+			"work_id":      a.WorkID,
+			"user_id":      a.UserID,
+			"body":         a.Body,
+			"version":      old.Version + 1,
+			"start_offset": r.Start,
+			"end_offset":   r.End,
+			"outdated":     a.Outdated || r.Lost,
+		})
+	}
+
+	return objects, nil
+}
+
+// UploadContent replaces the content of a work with a new version uploaded by its author.
+// The work's images are replaced, and its annotations are moved onto the new version,
+// in the same transaction. Works that are in review or were approved can't be changed.
+//
+//nolint:lll
+func UploadContent(tikaClient *tika.Client, graphQLClient *graphql.Client, store storage.Storage, checker *spelling.Checker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		work, err := fetchWork(c, graphQLClient, workID)
+		if work == nil {
+			return err
+		}
+
+		if work.AuthorID != claims.UserID {
+			return helpers.SendError(c, fiber.StatusForbidden, "doar autorul lucrării o poate modifica", nil)
+		}
+
+		if work.Status == workstatus.InReview || work.Status == workstatus.Approved {
+			return helpers.SendError(c, fiber.StatusConflict, "lucrarea nu mai poate fi modificată", nil)
+		}
+
+		old, err := fetchWorkContent(c, graphQLClient, workID)
+		if old == nil {
+			return err
+		}
+
+		body, images, err := parseFormFile(c, tikaClient)
+		if body == "" {
+			return err
+		}
+
+		if body == old.Content {
+			return helpers.SendError(c, fiber.StatusBadRequest, "conținutul încărcat este identic cu cel existent", nil)
+		}
+
+		annotations, err := rebaseAnnotations(c, graphQLClient, workID, old, body)
+		if annotations == nil {
+			return err
+		}
+
+		objects, err := storeImages(c, store, images)
+		if objects == nil {
+			return err
+		}
+
+		for _, object := range objects {
+			object["work_id"] = workID
+		}
+
+		var resp gqlqueries.UpdateWorkContentOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(graphQLClient, gqlqueries.UpdateWorkContent, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID":         workID,
+				"version":        old.Version,
+				"content":        body,
+				"spellingIssues": spellingIssues(checker, body),
+				"images":         objects,
+				"annotations":    annotations,
+			},
+			Promote: true,
+		}); err != nil {
+			// Nothing was replaced, so only the new images must be removed.
+			removeImages(c, store, objects)
+
+			if strings.Contains(err.Error(), errContentChanged) {
+				return helpers.SendError(c, fiber.StatusConflict, errContentChanged, err)
+			}
+
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		removed := make([]map[string]interface{}, 0, len(resp.RemovedImages))
+		for _, image := range resp.RemovedImages {
+			removed = append(removed, map[string]interface{}{"storage_key": image.StorageKey})
+		}
+
+		removeImages(c, store, removed)
+
+		return c.JSON(contentOutput{ID: workID, Version: old.Version + 1})
+	}
+}
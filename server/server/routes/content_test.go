@@ -0,0 +1,114 @@
+package routes_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/mime"
+	"github.com/FiveIT/eseuri/server/server/config"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/routes"
+	"github.com/FiveIT/eseuri/server/storage"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/go-tika/tika"
+	"github.com/machinebox/graphql"
+	"github.com/rs/zerolog"
+)
+
+// fakeBackend answers the Tika and Hasura requests of the content upload. The content is replaced by
+// another request between reading it and replacing it, so the database rejects the replacement.
+type fakeBackend struct {
+	replacements int32
+}
+
+func (f *fakeBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/detect/stream" {
+		_, _ = w.Write([]byte(mime.TXT))
+
+		return
+	}
+
+	var req struct {
+		Query string `json:"query"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	var resp string
+
+	switch {
+	case strings.Contains(req.Query, "replace_work_content"):
+		atomic.AddInt32(&f.replacements, 1)
+
+		resp = `{"errors": [{"message": "conținutul lucrării s-a schimbat între timp"}]}`
+	case strings.Contains(req.Query, "annotations("):
+		resp = `{"data": {"annotations": []}}`
+	case strings.Contains(req.Query, "content"):
+		resp = `{"data": {"works_by_pk": {"version": 1, "content": "Conținutul vechi."}}}`
+	default:
+		resp = `{"data": {"works_by_pk": {"id": 1, "user_id": 1, "teacher_id": 0, "status": "pending"}}}`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(resp))
+}
+
+func TestUploadContentConflict(t *testing.T) {
+	t.Parallel()
+
+	backend := &fakeBackend{}
+	srv := httptest.NewServer(backend)
+
+	t.Cleanup(srv.Close)
+
+	store := storage.NewFilesystem(t.TempDir())
+	if err := store.Put(context.Background(), "old.png", bytes.NewReader([]byte("old"))); err != nil {
+		t.Fatalf("Failed to store old image: %v", err)
+	}
+
+	app := fiber.New(config.Config())
+
+	app.Use(func(c *fiber.Ctx) error {
+		//nolint:exhaustivestruct
+		c.Locals("claims", auth.CustomClaims{IsRegistered: true, UserID: 1, Role: "student"})
+		c.Locals("logger", zerolog.Nop())
+
+		return c.Next()
+	})
+
+	app.Post("/works/:id/content", routes.UploadContent(tika.NewClient(nil, srv.URL), graphql.NewClient(srv.URL), store, nil))
+
+	f, err := os.Open("../testdata/file.txt")
+	if err != nil {
+		t.Fatalf("Couldn't open test file: %v", err)
+	}
+
+	res := testhelper.RequestMultipart(t, app, "/works/1/content", "", map[string]interface{}{
+		"file": f,
+	})
+	defer res.Body.Close()
+
+	utils.AssertEqual(t, fiber.StatusConflict, res.StatusCode)
+	utils.AssertEqual(t, int32(1), atomic.LoadInt32(&backend.replacements))
+
+	// The old images are still referenced by the work, so their files must be kept.
+	old, err := store.Get(context.Background(), "old.png")
+	if err != nil {
+		t.Fatalf("Expected the old image to be kept, got %v", err)
+	}
+
+	old.Close()
+}
@@ -0,0 +1,6 @@
+package routes
+
+// WorkLink returns the link to the work sent in the notification emails.
+func WorkLink(workID int) string {
+	return workLink(workID)
+}
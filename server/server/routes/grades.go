@@ -0,0 +1,441 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/rubric"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+type criterionOutput struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MaxScore    int    `json:"maxScore"`
+}
+
+type rubricOutput struct {
+	ID       int               `json:"id"`
+	WorkType string            `json:"workType"`
+	Name     string            `json:"name"`
+	MaxScore int               `json:"maxScore"`
+	Criteria []criterionOutput `json:"criteria"`
+}
+
+type scoreOutput struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	MaxScore int    `json:"maxScore"`
+}
+
+type gradeSubject struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type gradeOutput struct {
+	WorkID    int           `json:"workID"`
+	Status    string        `json:"status"`
+	Subject   *gradeSubject `json:"subject"`
+	Rubric    string        `json:"rubric"`
+	TeacherID *int          `json:"teacherID"`
+	Scores    []scoreOutput `json:"scores"`
+	Total     int           `json:"total"`
+	MaxScore  int           `json:"maxScore"`
+	CreatedAt string        `json:"createdAt"`
+	UpdatedAt *string       `json:"updatedAt"`
+}
+
+func newRubricOutput(r rubric.Rubric) rubricOutput {
+	out := rubricOutput{
+		ID:       r.ID,
+		WorkType: r.WorkType,
+		Name:     r.Name,
+		MaxScore: r.MaxScore(),
+		Criteria: make([]criterionOutput, 0, len(r.Criteria)),
+	}
+
+	for _, c := range r.Criteria {
+		out.Criteria = append(out.Criteria, criterionOutput{
+			Key:         c.Key,
+			Name:        c.Name,
+			Description: c.Description,
+			MaxScore:    c.MaxScore,
+		})
+	}
+
+	return out
+}
+
+// rubricSet holds all the rubrics, which are few, so they are always fetched together.
+type rubricSet []rubric.Rubric
+
+func (s rubricSet) byID(id int) (rubric.Rubric, bool) {
+	for _, r := range s {
+		if r.ID == id {
+			return r, true
+		}
+	}
+
+	return rubric.Rubric{}, false
+}
+
+func (s rubricSet) byWorkType(workType string) (rubric.Rubric, bool) {
+	for _, r := range s {
+		if r.WorkType == workType {
+			return r, true
+		}
+	}
+
+	return rubric.Rubric{}, false
+}
+
+func fetchRubrics(c *fiber.Ctx, client *graphql.Client) (rubricSet, error) {
+	var resp gqlqueries.RubricsOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.Rubrics, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	rubrics := make(rubricSet, 0, len(resp.Query))
+
+	for _, r := range resp.Query {
+		criteria := make([]rubric.Criterion, 0, len(r.Criteria))
+		for _, c := range r.Criteria {
+			criteria = append(criteria, rubric.Criterion{
+				ID:          c.ID,
+				Key:         c.Key,
+				Name:        c.Name,
+				Description: c.Description,
+				MaxScore:    c.MaxScore,
+			})
+		}
+
+		rubrics = append(rubrics, rubric.Rubric{
+			ID:       r.ID,
+			WorkType: r.WorkType,
+			Name:     r.Name,
+			Criteria: criteria,
+		})
+	}
+
+	return rubrics, nil
+}
+
+// newGradeOutput computes the total of the grade using the rubric it was given with.
+func newGradeOutput(rubrics rubricSet, g *gqlqueries.WorkGrade) (gradeOutput, error) {
+	r, ok := rubrics.byID(g.RubricID)
+	if !ok {
+		return gradeOutput{}, fmt.Errorf("rubric %d of work %d not found", g.RubricID, g.WorkID)
+	}
+
+	scores := make(rubric.Scores, len(g.Scores))
+
+	for _, s := range g.Scores {
+		for _, c := range r.Criteria {
+			if c.ID == s.CriterionID {
+				scores[c.Key] = s.Score
+			}
+		}
+	}
+
+	out := gradeOutput{
+		WorkID:    g.WorkID,
+		Status:    g.Work.Status,
+		Subject:   nil,
+		Rubric:    r.Name,
+		TeacherID: g.TeacherID,
+		Scores:    make([]scoreOutput, 0, len(r.Criteria)),
+		Total:     r.Total(scores),
+		MaxScore:  r.MaxScore(),
+		CreatedAt: g.CreatedAt,
+		UpdatedAt: g.UpdatedAt,
+	}
+
+	for _, c := range r.Criteria {
+		out.Scores = append(out.Scores, scoreOutput{
+			Key:      c.Key,
+			Name:     c.Name,
+			Score:    scores[c.Key],
+			MaxScore: c.MaxScore,
+		})
+	}
+
+	if e := g.Work.Essay; e != nil {
+		out.Subject = &gradeSubject{Type: "essay", Name: e.Title.Name}
+	} else if ch := g.Work.Characterization; ch != nil {
+		out.Subject = &gradeSubject{Type: "characterization", Name: ch.Character.Name}
+	}
+
+	return out, nil
+}
+
+func fetchWorkGrade(c *fiber.Ctx, client *graphql.Client, workID int) (*gqlqueries.WorkGrade, error) {
+	var resp gqlqueries.WorkGradeOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.WorkGradeByPK, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"workID": workID,
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	if resp.Query == nil {
+		return nil, helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu a fost notată", nil)
+	}
+
+	return resp.Query, nil
+}
+
+func sendWorkGrade(c *fiber.Ctx, client *graphql.Client, workID int) error {
+	grade, err := fetchWorkGrade(c, client, workID)
+	if grade == nil {
+		return err
+	}
+
+	rubrics, err := fetchRubrics(c, client)
+	if rubrics == nil {
+		return err
+	}
+
+	out, err := newGradeOutput(rubrics, grade)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(out)
+}
+
+func fetchWorkType(c *fiber.Ctx, client *graphql.Client, workID int) (string, error) {
+	var resp gqlqueries.WorkTypeOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.WorkType, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"workID": workID,
+		},
+		Promote: true,
+	}); err != nil {
+		return "", helpers.HandleGraphQLError(c, err)
+	}
+
+	switch w := resp.Query; {
+	case w == nil:
+		return "", helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu există", nil)
+	case w.Essay != nil:
+		return "essay", nil
+	case w.Characterization != nil:
+		return "characterization", nil
+	default:
+		return "", fmt.Errorf("work %d has no type", workID)
+	}
+}
+
+func handleScoresError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, rubric.ErrMissingScore):
+		return helpers.SendError(c, fiber.StatusBadRequest, "nu ai notat toate criteriile", err)
+	case errors.Is(err, rubric.ErrUnknownCriterion):
+		return helpers.SendError(c, fiber.StatusBadRequest, "ai notat un criteriu care nu există", err)
+	case errors.Is(err, rubric.ErrScoreOutOfRange):
+		return helpers.SendError(c, fiber.StatusBadRequest, "un punctaj depășește limitele criteriului", err)
+	default:
+		return fmt.Errorf("failed to validate scores: %w", err)
+	}
+}
+
+// Rubrics lists the grading templates of all the work types.
+func Rubrics(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		rubrics, err := fetchRubrics(c, client)
+		if rubrics == nil {
+			return err
+		}
+
+		out := make([]rubricOutput, 0, len(rubrics))
+		for _, r := range rubrics {
+			out = append(out, newRubricOutput(r))
+		}
+
+		return c.JSON(out)
+	}
+}
+
+// WorkGrade returns the grade of a work, with the scores of each of its rubric's criteria.
+func WorkGrade(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		if work, err := assertParticipant(c, client, workID, "nu ai acces la nota acestei lucrări"); work == nil {
+			return err
+		}
+
+		return sendWorkGrade(c, client, workID)
+	}
+}
+
+// GradeWork saves the scores the reviewing teacher gave to the work, replacing any previous ones.
+// The work is graded using the rubric of its type, and all of the rubric's criteria must be scored.
+func GradeWork(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		var input helpers.GradeInput
+
+		if err := c.BodyParser(&input); err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "formularul de notare este invalid", err)
+		}
+
+		work, err := fetchWork(c, client, workID)
+		if work == nil {
+			return err
+		}
+
+		if !work.CanGrade(workstatus.Actor{ID: claims.UserID, Role: claims.Role}) {
+			return helpers.SendError(c, fiber.StatusForbidden, "doar profesorul care revizuiește lucrarea o poate nota", nil)
+		}
+
+		workType, err := fetchWorkType(c, client, workID)
+		if workType == "" {
+			return err
+		}
+
+		rubrics, err := fetchRubrics(c, client)
+		if rubrics == nil {
+			return err
+		}
+
+		r, ok := rubrics.byWorkType(workType)
+		if !ok {
+			return helpers.SendError(c, fiber.StatusNotFound, "nu există un barem pentru acest tip de lucrare", nil)
+		}
+
+		if err := r.Validate(input.Scores); err != nil {
+			return handleScoresError(c, err)
+		}
+
+		scores := make([]map[string]interface{}, 0, len(r.Criteria))
+		for _, criterion := range r.Criteria {
+			scores = append(scores, map[string]interface{}{
+				"work_id":      workID,
+				"criterion_id": criterion.ID,
+				"score":        input.Scores[criterion.Key],
+			})
+		}
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.SaveWorkGrade, helpers.GraphQLRequestOptions{
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID": workID,
+				"grade": map[string]interface{}{
+					"work_id":    workID,
+					"rubric_id":  r.ID,
+					"teacher_id": claims.UserID,
+					"updated_at": timestampNow(),
+				},
+				"scores": scores,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return sendWorkGrade(c, client, workID)
+	}
+}
+
+// StudentGrades lists the grades of a student's works, newest first, so their progress can be followed.
+// Only the student and the teachers associated with them may see the grades.
+func StudentGrades(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		studentID, err := paramID(c, "id")
+		if studentID == 0 {
+			return err
+		}
+
+		limit, offset, err := pagination(c)
+		if limit == 0 {
+			return err
+		}
+
+		// The teachers associated with the student are checked as if for one of their works.
+		if studentID != claims.UserID {
+			//nolint:exhaustivestruct
+			student := &workstatus.Work{AuthorID: studentID}
+
+			if claims.Role == workstatus.RoleTeacher {
+				if ok, err := fetchAssociations(c, client, student); !ok {
+					return err
+				}
+			}
+
+			if !student.IsParticipant(workstatus.Actor{ID: claims.UserID, Role: claims.Role}) {
+				return helpers.SendError(c, fiber.StatusForbidden, "nu ai acces la notele acestui elev", nil)
+			}
+		}
+
+		var resp gqlqueries.StudentGradesOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.StudentGrades, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"studentID": studentID,
+				"limit":     limit,
+				"offset":    offset,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		rubrics, err := fetchRubrics(c, client)
+		if rubrics == nil {
+			return err
+		}
+
+		out := make([]gradeOutput, 0, len(resp.Query))
+
+		for i := range resp.Query {
+			grade, err := newGradeOutput(rubrics, &resp.Query[i])
+			if err != nil {
+				return err
+			}
+
+			out = append(out, grade)
+		}
+
+		return c.JSON(out)
+	}
+}
@@ -0,0 +1,227 @@
+package routes
+
+import (
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/text"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+type readingPosition struct {
+	Version   int     `json:"version"`
+	Paragraph int     `json:"paragraph"`
+	Progress  float64 `json:"progress"`
+	UpdatedAt string  `json:"updatedAt"`
+	// Outdated is set if the work's content changed since the position was saved,
+	// in which case the paragraph might not be the one the user was reading.
+	Outdated bool `json:"outdated"`
+}
+
+func newReadingPosition(p gqlqueries.WorkPosition, currentVersion int) readingPosition {
+	return readingPosition{
+		Version:   p.Version,
+		Paragraph: p.Paragraph,
+		Progress:  p.Progress,
+		UpdatedAt: p.UpdatedAt,
+		Outdated:  p.Version != currentVersion,
+	}
+}
+
+type historyEntry struct {
+	Work     searchWork      `json:"work"`
+	Position readingPosition `json:"position"`
+}
+
+// SavePosition records that the user opened the work and where they stopped reading it. Readers
+// should send the position when they open the work, and then whenever the user scrolls through it.
+func SavePosition(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		var input helpers.PositionInput
+
+		if err := c.BodyParser(&input); err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "poziția este invalidă", err)
+		}
+
+		if ok, err := isWorkVisible(c, client, workID); !ok {
+			return err
+		}
+
+		content, err := fetchWorkContent(c, client, workID)
+		if content == nil {
+			return err
+		}
+
+		if content.Version != input.Version {
+			return helpers.SendErrorWithData(c, fiber.StatusConflict, "conținutul lucrării s-a schimbat între timp", nil, fiber.Map{
+				"version": content.Version,
+			})
+		}
+
+		if input.Paragraph < 0 || input.Paragraph >= len(text.Paragraphs(content.Content)) {
+			return helpers.SendError(c, fiber.StatusBadRequest, "paragraful nu există în lucrare", nil)
+		}
+
+		if input.Progress < 0 || input.Progress > 1 {
+			return helpers.SendError(c, fiber.StatusBadRequest, "progresul lecturii este invalid", nil)
+		}
+
+		var resp gqlqueries.SaveReadingPositionOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.SaveReadingPosition, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"position": map[string]interface{}{
+					"user_id":    claims.UserID,
+					"work_id":    workID,
+					"version":    input.Version,
+					"paragraph":  input.Paragraph,
+					"progress":   input.Progress,
+					"updated_at": timestampNow(),
+				},
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.JSON(newReadingPosition(resp.Query, content.Version))
+	}
+}
+
+// Position sends where the user stopped reading the work, so the reader can resume from there.
+func Position(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		if ok, err := isWorkVisible(c, client, workID); !ok {
+			return err
+		}
+
+		var resp gqlqueries.ReadingPositionOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.ReadingPosition, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"userID": claims.UserID,
+				"workID": workID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if resp.Query == nil {
+			return helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu a fost citită", nil)
+		}
+
+		content, err := fetchWorkContent(c, client, workID)
+		if content == nil {
+			return err
+		}
+
+		return c.JSON(newReadingPosition(*resp.Query, content.Version))
+	}
+}
+
+// History sends a page of the works the user opened, the most recently read first, with where they stopped reading each.
+func History(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		limit, offset, err := pagination(c)
+		if limit == 0 {
+			return err
+		}
+
+		var resp gqlqueries.ReadingHistoryOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.ReadingHistory, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"userID": claims.UserID,
+				"limit":  limit,
+				"offset": offset,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		entries := make([]historyEntry, 0, len(resp.Query))
+
+		for _, h := range resp.Query {
+			if h.Work == nil {
+				continue
+			}
+
+			entries = append(entries, historyEntry{
+				Work:     newSearchWork(newSearchDocument(*h.Work), 0),
+				Position: newReadingPosition(h.WorkPosition, h.Work.Version),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"total": resp.Aggregate.Aggregate.Count,
+			"works": entries,
+		})
+	}
+}
+
+// ClearHistory forgets the works the user opened. If the "work" route parameter is given,
+// only that work is forgotten.
+func ClearHistory(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		where := map[string]interface{}{
+			"user_id": map[string]interface{}{"_eq": claims.UserID},
+		}
+
+		if c.Params("work") != "" {
+			workID, err := paramID(c, "work")
+			if workID == 0 {
+				return err
+			}
+
+			where["work_id"] = map[string]interface{}{"_eq": workID}
+		}
+
+		var resp gqlqueries.ClearReadingHistoryOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.ClearReadingHistory, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"where": where,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"cleared": resp.Query.AffectedRows,
+		})
+	}
+}
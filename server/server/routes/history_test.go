@@ -0,0 +1,211 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/server/config"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/server/routes"
+	"github.com/FiveIT/eseuri/server/testhelper"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/machinebox/graphql"
+	"github.com/rs/zerolog"
+)
+
+// fakeHistory answers the Hasura requests of the reading history routes, and records the saved positions
+// and the queries of the history.
+type fakeHistory struct {
+	mu        sync.Mutex
+	positions []map[string]interface{}
+	queries   []string
+}
+
+func (f *fakeHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string `json:"query"`
+		Variables struct {
+			Position map[string]interface{} `json:"position"`
+		} `json:"variables"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var resp string
+
+	switch {
+	case strings.Contains(req.Query, "insert_reading_history_one"):
+		f.positions = append(f.positions, req.Variables.Position)
+
+		p := req.Variables.Position
+		resp = `{"data": {"insert_reading_history_one": {"version": 2, "paragraph": ` + jsonNumber(p["paragraph"]) +
+			`, "progress": ` + jsonNumber(p["progress"]) + `, "updated_at": "2021-05-17T12:00:00"}}}`
+	case strings.Contains(req.Query, "reading_history("):
+		f.queries = append(f.queries, req.Query)
+
+		resp = `{"data": {
+			"reading_history": [
+				{"version": 1, "paragraph": 3, "progress": 0.5, "updated_at": "2021-05-17T12:00:00", "work": {"id": 3, "version": 2, "user_id": 1}},
+				{"version": 1, "paragraph": 0, "progress": 0.1, "updated_at": "2021-05-16T12:00:00", "work": null},
+				{"version": 4, "paragraph": 1, "progress": 1, "updated_at": "2021-05-15T12:00:00", "work": {"id": 1, "version": 4, "user_id": 2}}
+			],
+			"reading_history_aggregate": {"aggregate": {"count": 3}}
+		}}`
+	case strings.Contains(req.Query, "content"):
+		resp = `{"data": {"works_by_pk": {"version": 2, "content": "Primul paragraf.\nAl doilea paragraf."}}}`
+	default:
+		resp = `{"data": {"works_by_pk": {"id": 1, "user_id": 2, "teacher_id": null, "status": "approved"}}}`
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(resp))
+}
+
+func jsonNumber(v interface{}) string {
+	b, _ := json.Marshal(v)
+
+	return string(b)
+}
+
+func historyApp(t *testing.T, f *fakeHistory) *fiber.App {
+	t.Helper()
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	client := graphql.NewClient(srv.URL)
+	app := fiber.New(config.Config())
+
+	app.Use(func(c *fiber.Ctx) error {
+		//nolint:exhaustivestruct
+		c.Locals("claims", auth.CustomClaims{IsRegistered: true, UserID: 1, Role: "student"})
+		c.Locals("logger", zerolog.Nop())
+
+		return c.Next()
+	})
+
+	app.Put("/works/:id/position", routes.SavePosition(client))
+	app.Get("/user/history", routes.History(client))
+
+	return app
+}
+
+func TestSavePosition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		version   string
+		paragraph string
+		progress  string
+		status    int
+	}{
+		{"saved", "2", "1", "0.5", fiber.StatusOK},
+		{"first paragraph, not scrolled", "2", "0", "0", fiber.StatusOK},
+		{"outdated version", "1", "1", "0.5", fiber.StatusConflict},
+		{"negative paragraph", "2", "-1", "0.5", fiber.StatusBadRequest},
+		{"paragraph past the end", "2", "2", "0.5", fiber.StatusBadRequest},
+		{"negative progress", "2", "1", "-0.1", fiber.StatusBadRequest},
+		{"progress past the end", "2", "1", "1.5", fiber.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := &fakeHistory{}
+			app := historyApp(t, f)
+
+			body := url.Values{"version": {test.version}, "paragraph": {test.paragraph}, "progress": {test.progress}}
+
+			req := testhelper.Request(t, http.MethodPut, "/works/1/position", strings.NewReader(body.Encode()))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+
+			res := testhelper.DoTestRequest(t, app, req)
+			defer res.Body.Close()
+
+			utils.AssertEqual(t, test.status, res.StatusCode)
+
+			if test.status != fiber.StatusOK {
+				utils.AssertEqual(t, 0, len(f.positions), "saved positions")
+
+				if test.status == fiber.StatusConflict {
+					var conflict struct {
+						Version int `json:"version"`
+					}
+
+					testhelper.DecodeJSON(t, res.Body, &conflict)
+					utils.AssertEqual(t, 2, conflict.Version, "current version")
+				}
+
+				return
+			}
+
+			utils.AssertEqual(t, 1, len(f.positions), "saved positions")
+
+			position := f.positions[0]
+			utils.AssertEqual(t, float64(1), position["user_id"], "user")
+			utils.AssertEqual(t, float64(1), position["work_id"], "work")
+			utils.AssertEqual(t, test.paragraph, jsonNumber(position["paragraph"]), "paragraph")
+			utils.AssertEqual(t, test.progress, jsonNumber(position["progress"]), "progress")
+
+			updatedAt, _ := position["updated_at"].(string)
+			if _, err := time.Parse("2006-01-02T15:04:05", updatedAt); err != nil {
+				t.Fatalf("Invalid update time %q: %v", updatedAt, err)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeHistory{}
+	app := historyApp(t, f)
+
+	res := testhelper.DoTestRequest(t, app, testhelper.Request(t, http.MethodGet, "/user/history?limit=10", nil))
+	defer res.Body.Close()
+
+	utils.AssertEqual(t, fiber.StatusOK, res.StatusCode)
+
+	var history struct {
+		Total int `json:"total"`
+		Works []struct {
+			Work struct {
+				ID int `json:"id"`
+			} `json:"work"`
+			Position struct {
+				Paragraph int  `json:"paragraph"`
+				Outdated  bool `json:"outdated"`
+			} `json:"position"`
+		} `json:"works"`
+	}
+
+	testhelper.DecodeJSON(t, res.Body, &history)
+
+	// The database sends the most recently read works first; the deleted ones are skipped.
+	utils.AssertEqual(t, 1, len(f.queries))
+	utils.AssertEqual(t, true, strings.Contains(f.queries[0], "order_by: [{updated_at: desc}, {work_id: desc}]"), "order")
+	utils.AssertEqual(t, 3, history.Total, "total")
+	utils.AssertEqual(t, 2, len(history.Works), "works")
+	utils.AssertEqual(t, 3, history.Works[0].Work.ID, "most recent")
+	utils.AssertEqual(t, true, history.Works[0].Position.Outdated, "changed since read")
+	utils.AssertEqual(t, 1, history.Works[1].Work.ID, "least recent")
+	utils.AssertEqual(t, false, history.Works[1].Position.Outdated, "unchanged since read")
+}
@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+// isWorkVisible checks if the work exists and the current user is allowed to see it.
+// The check is done by Hasura, using the permissions of the user's role.
+func isWorkVisible(c *fiber.Ctx, client *graphql.Client, workID int) (bool, error) {
+	claims := c.Locals("claims").(auth.CustomClaims)
+
+	var work gqlqueries.WorksByPKOutput
+
+	if err := helpers.GraphQLRequest(client, gqlqueries.WorksByPK, helpers.GraphQLRequestOptions{
+		Output:  &work,
+		Context: c.Context(),
+		Headers: claims.Headers(),
+		Vars: map[string]interface{}{
+			"id": workID,
+		},
+		Promote: true,
+	}); err != nil {
+		return false, helpers.HandleGraphQLError(c, err)
+	}
+
+	if work.Query.ID == 0 {
+		return false, helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu există", nil)
+	}
+
+	return true, nil
+}
+
+// WorkImage serves an image embedded in a work to the users that are allowed to see the work.
+func WorkImage(client *graphql.Client, store storage.Storage) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		imageID, err := paramID(c, "image")
+		if imageID == 0 {
+			return err
+		}
+
+		if ok, err := isWorkVisible(c, client, workID); !ok {
+			return err
+		}
+
+		var image gqlqueries.WorkImageOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.WorkImage, helpers.GraphQLRequestOptions{
+			Output:  &image,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID":  workID,
+				"imageID": imageID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if len(image.Query) == 0 {
+			return helpers.SendError(c, fiber.StatusNotFound, "imaginea nu există", nil)
+		}
+
+		info := image.Query[0]
+
+		r, err := store.Get(c.Context(), info.StorageKey)
+		if errors.Is(err, storage.ErrNotFound) {
+			return helpers.SendError(c, fiber.StatusNotFound, "imaginea nu există", err)
+		} else if errors.Is(err, storage.ErrUnavailable) {
+			return helpers.SendError(c, fiber.StatusServiceUnavailable, "stocarea imaginilor nu este disponibilă", err)
+		} else if err != nil {
+			return fmt.Errorf("failed to get image: %w", err)
+		}
+
+		c.Set(fiber.HeaderContentType, info.ContentType)
+		c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+
+		return c.SendStream(r, info.Size)
+	}
+}
@@ -0,0 +1,212 @@
+package routes
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+// reviewLeaseTTL is how long a teacher may review a work without sending a heartbeat.
+// After the lease expires, the work is put back to pending, so others can review it.
+const reviewLeaseTTL = 10 * time.Minute
+
+type leaseOutput struct {
+	WorkID    int      `json:"workID"`
+	ExpiresAt string   `json:"expiresAt"`
+	Holder    userName `json:"holder"`
+}
+
+func newLeaseOutput(lease *gqlqueries.ReviewLease) *leaseOutput {
+	out := &leaseOutput{
+		WorkID:    lease.WorkID,
+		ExpiresAt: lease.ExpiresAt,
+		//nolint:exhaustivestruct
+		Holder: userName{
+			ID: lease.TeacherID,
+		},
+	}
+
+	if t := lease.Teacher; t != nil {
+		out.Holder.FirstName = t.FirstName
+		out.Holder.MiddleName = t.MiddleName
+		out.Holder.LastName = t.LastName
+	}
+
+	return out
+}
+
+func sendLeaseConflict(c *fiber.Ctx, lease *gqlqueries.ReviewLease) error {
+	return helpers.SendErrorWithData(c, fiber.StatusConflict, "lucrarea este revizuită de alt profesor", nil, fiber.Map{
+		"holder": newLeaseOutput(lease),
+	})
+}
+
+func expireReviewLeases(ctx context.Context, client *graphql.Client) (int, error) {
+	var resp gqlqueries.ExpireReviewLeasesOutput
+
+	//nolint:exhaustivestruct
+	err := helpers.GraphQLRequest(client, gqlqueries.ExpireReviewLeases, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: ctx,
+		Promote: true,
+	})
+
+	return len(resp.Query), err
+}
+
+func fetchReviewLease(c *fiber.Ctx, client *graphql.Client, workID int) (*gqlqueries.ReviewLease, error) {
+	var resp gqlqueries.ReviewLeaseByPKOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.ReviewLeaseByPK, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"workID": workID,
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	if resp.Query == nil {
+		return nil, helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu este revizuită de nimeni", nil)
+	}
+
+	return resp.Query, nil
+}
+
+func reviewLeaseRequest(c *fiber.Ctx, client *graphql.Client, query string, workID int, out interface{}) error {
+	claims := c.Locals("claims").(auth.CustomClaims)
+
+	if err := helpers.GraphQLRequest(client, query, helpers.GraphQLRequestOptions{
+		Output:  out,
+		Context: c.Context(),
+		Headers: map[string]string{
+			"X-Hasura-User-Id": strconv.Itoa(claims.UserID),
+		},
+		Vars: map[string]interface{}{
+			"workID":    workID,
+			"teacherID": claims.UserID,
+			"ttl":       int(reviewLeaseTTL.Seconds()),
+		},
+		Promote: true,
+	}); err != nil {
+		return helpers.HandleGraphQLError(c, err)
+	}
+
+	return nil
+}
+
+// claimReviewLease puts the work in review and gives the user an exclusive lease on it.
+// The work must be pending, or its lease must have expired, and the user must be allowed
+// to review it. If another teacher holds the lease, a conflict is reported with the holder.
+func claimReviewLease(c *fiber.Ctx, client *graphql.Client, workID int) (*gqlqueries.ReviewLease, error) {
+	claims := c.Locals("claims").(auth.CustomClaims)
+
+	var resp gqlqueries.ClaimReviewLeaseOutput
+
+	if err := reviewLeaseRequest(c, client, gqlqueries.ClaimReviewLease, workID, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Query) == 0 {
+		return nil, helpers.SendError(c, fiber.StatusConflict, "starea lucrării a fost schimbată între timp", nil)
+	}
+
+	if lease := &resp.Query[0]; lease.TeacherID != claims.UserID {
+		return nil, sendLeaseConflict(c, lease)
+	}
+
+	return &resp.Query[0], nil
+}
+
+// renewReviewLease extends the lease the user holds on the work. If the user doesn't
+// hold the lease, a conflict is reported with the current holder, if there is one.
+func renewReviewLease(c *fiber.Ctx, client *graphql.Client, workID int) (*gqlqueries.ReviewLease, error) {
+	var resp gqlqueries.RenewReviewLeaseOutput
+
+	if err := reviewLeaseRequest(c, client, gqlqueries.RenewReviewLease, workID, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Query) != 0 {
+		return &resp.Query[0], nil
+	}
+
+	lease, err := fetchReviewLease(c, client, workID)
+	if lease == nil {
+		return nil, err
+	}
+
+	return nil, sendLeaseConflict(c, lease)
+}
+
+func leaseTransitionOutput(lease *gqlqueries.ReviewLease) *transitionOutput {
+	teacherID := lease.TeacherID
+
+	return &transitionOutput{
+		ID:        lease.WorkID,
+		Status:    lease.Work.Status,
+		TeacherID: &teacherID,
+		UpdatedAt: lease.Work.UpdatedAt,
+		Author:    nil,
+		Lease:     newLeaseOutput(lease),
+	}
+}
+
+// ReviewLease returns the lease of the work's review, if the work is in review.
+func ReviewLease(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		lease, err := fetchReviewLease(c, client, workID)
+		if lease == nil {
+			return err
+		}
+
+		return c.JSON(newLeaseOutput(lease))
+	}
+}
+
+// RenewReviewLease is the heartbeat the reviewing teacher sends
+// to keep the lease on the work while they review it.
+func RenewReviewLease(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		lease, err := renewReviewLease(c, client, workID)
+		if lease == nil {
+			return err
+		}
+
+		return c.JSON(newLeaseOutput(lease))
+	}
+}
+
+// ExpireReviewLeases puts back to pending the works whose review leases expired.
+// It is called periodically by a Hasura cron trigger.
+func ExpireReviewLeases(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		count, err := expireReviewLeases(c.Context(), client)
+		if err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"expired": count,
+		})
+	}
+}
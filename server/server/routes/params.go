@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"strconv"
+
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// userName identifies a user by their ID and name in responses.
+type userName struct {
+	ID         int     `json:"id"`
+	FirstName  *string `json:"firstName"`
+	MiddleName *string `json:"middleName"`
+	LastName   *string `json:"lastName"`
+}
+
+func paramID(c *fiber.Ctx, name string) (int, error) {
+	id, err := strconv.Atoi(c.Params(name))
+	if err != nil || id <= 0 {
+		return 0, helpers.SendError(c, fiber.StatusBadRequest, "identificatorul din adresă este invalid", err)
+	}
+
+	return id, nil
+}
+
+// pagination parses the "limit" and "offset" query parameters of list routes.
+// The limit defaults to defaultPageSize and can't be higher than maxPageSize.
+func pagination(c *fiber.Ctx) (limit, offset int, err error) {
+	limit, offset = defaultPageSize, 0
+
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 || limit > maxPageSize {
+			return 0, 0, helpers.SendError(c, fiber.StatusBadRequest, "numărul de rezultate cerut este invalid", err)
+		}
+	}
+
+	if v := c.Query("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, helpers.SendError(c, fiber.StatusBadRequest, "poziția de început cerută este invalidă", err)
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// queryID parses an optional identifier from the query parameter with the given name.
+// It returns 0 if the parameter is missing, and -1 if it is invalid, in which case the response was sent.
+func queryID(c *fiber.Ctx, name string) (int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 {
+		return -1, helpers.SendError(c, fiber.StatusBadRequest, "un identificator din filtre este invalid", err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,259 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/search"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+const (
+	// defaultSimilarWorks and maxSimilarWorks limit the number of similar works sent.
+	defaultSimilarWorks = 5
+	maxSimilarWorks     = 20
+)
+
+type searchSubject struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// searchWork describes a searched work in responses.
+type searchWork struct {
+	ID        int            `json:"id"`
+	Type      string         `json:"type"`
+	Title     searchSubject  `json:"title"`
+	Character *searchSubject `json:"character"`
+	SchoolID  int            `json:"schoolID"`
+	TeacherID int            `json:"teacherID"`
+	Summary   []string       `json:"summary"`
+	Keywords  []string       `json:"keywords"`
+	Score     float64        `json:"score"`
+}
+
+func newSearchWork(d search.Document, score float64) searchWork {
+	w := searchWork{
+		ID:        d.ID,
+		Type:      d.Type,
+		Title:     searchSubject{ID: d.TitleID, Name: d.Title},
+		Character: nil,
+		SchoolID:  d.SchoolID,
+		TeacherID: d.TeacherID,
+		Summary:   d.Summary,
+		Keywords:  d.Keywords,
+		Score:     score,
+	}
+
+	// Works without abstracts are sent with empty ones, so clients don't have to handle nulls.
+	if w.Summary == nil {
+		w.Summary, w.Keywords = []string{}, []string{}
+	}
+
+	if d.CharacterID != 0 {
+		w.Character = &searchSubject{ID: d.CharacterID, Name: d.Character}
+	}
+
+	return w
+}
+
+type searchHit struct {
+	searchWork
+	Highlights []search.Highlight `json:"highlights"`
+}
+
+func newSearchDocument(w gqlqueries.SearchDocument) search.Document {
+	//nolint:exhaustivestruct
+	d := search.Document{
+		ID:       w.ID,
+		AuthorID: w.UserID,
+		Content:  w.Content,
+	}
+
+	if w.TeacherID != nil {
+		d.TeacherID = *w.TeacherID
+	}
+
+	if w.User != nil && w.User.SchoolID != nil {
+		d.SchoolID = *w.User.SchoolID
+	}
+
+	for _, t := range w.Tags {
+		d.Tags = append(d.Tags, t.TagID)
+	}
+
+	if a := w.Abstract; a != nil {
+		d.Summary, d.Keywords = a.Sentences, a.Keywords
+	}
+
+	if e := w.Essay; e != nil {
+		d.Type = "essay"
+		d.TitleID, d.Title = e.Title.ID, e.Title.Name
+	} else if ch := w.Characterization; ch != nil {
+		d.Type = "characterization"
+		d.CharacterID, d.Character = ch.Character.ID, ch.Character.Name
+		d.TitleID, d.Title = ch.Character.Title.ID, ch.Character.Title.Name
+	}
+
+	return d
+}
+
+func fetchSearchDocuments(ctx context.Context, client *graphql.Client, where map[string]interface{}, limit int) ([]search.Document, error) {
+	var resp gqlqueries.SearchDocumentsOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.SearchDocuments, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: ctx,
+		Vars: map[string]interface{}{
+			"where": where,
+			"limit": limit,
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to fetch works: %w", err)
+	}
+
+	docs := make([]search.Document, 0, len(resp.Query))
+	for _, w := range resp.Query {
+		docs = append(docs, newSearchDocument(w))
+	}
+
+	return docs, nil
+}
+
+// Search finds the approved works that contain the words of the "q" query parameter, in any of their
+// inflected forms, with or without diacritics. The results can be filtered by the works' type, title,
+// character, school, teacher and tag, and have highlighted fragments of the works' content.
+func Search(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, offset, err := pagination(c)
+		if limit == 0 {
+			return err
+		}
+
+		text, workType := c.Query("q"), c.Query("type")
+
+		if workType != "" && workType != "essay" && workType != "characterization" {
+			return helpers.SendError(c, fiber.StatusBadRequest, "tipul lucrării selectat este invalid", nil)
+		}
+
+		args := map[string]interface{}{
+			"search": text,
+		}
+
+		if workType != "" {
+			args["worktype"] = workType
+		}
+
+		for name, arg := range map[string]string{
+			"title":     "titleid",
+			"character": "characterid",
+			"school":    "schoolid",
+			"teacher":   "teacherid",
+			"tag":       "tagid",
+		} {
+			id, err := queryID(c, name)
+			if id == -1 {
+				return err
+			}
+
+			if id != 0 {
+				args[arg] = id
+			}
+		}
+
+		var resp gqlqueries.SearchWorksOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.SearchWorks, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"args":   args,
+				"limit":  limit,
+				"offset": offset,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		hits := make([]searchHit, 0, len(resp.Query))
+
+		for _, r := range resp.Query {
+			hit := searchHit{searchWork: newSearchWork(newSearchDocument(r.Work), r.Score), Highlights: []search.Highlight{}}
+
+			if r.Headline != nil {
+				hit.Highlights = search.Highlights(*r.Headline)
+			}
+
+			hits = append(hits, hit)
+		}
+
+		return c.JSON(fiber.Map{
+			"total": resp.Aggregate.Aggregate.Count,
+			"hits":  hits,
+		})
+	}
+}
+
+// SimilarWorks sends the approved works whose content is the most similar to the given work's, to
+// the users that are allowed to see the work. The works are compared by the cosine similarity of their
+// contents' TF-IDF vectors, and those about the same title or character are preferred. The works are
+// picked so that they don't all have the same author. The number of works can be set with the "limit"
+// query parameter.
+func SimilarWorks(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		limit := defaultSimilarWorks
+
+		if v := c.Query("limit"); v != "" {
+			if limit, err = strconv.Atoi(v); err != nil || limit <= 0 || limit > maxSimilarWorks {
+				return helpers.SendError(c, fiber.StatusBadRequest, "numărul de rezultate cerut este invalid", err)
+			}
+		}
+
+		if ok, err := isWorkVisible(c, client, workID); !ok {
+			return err
+		}
+
+		var resp gqlqueries.SimilarWorksOutput
+
+		// More works than needed are fetched, so they can be diversified.
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.SimilarWorks, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID": workID,
+				"limit":  limit * search.CandidatesPerResult,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		candidates := make([]search.Similar, 0, len(resp.Query))
+		for _, r := range resp.Query {
+			candidates = append(candidates, search.Similar{Document: newSearchDocument(r.Work), Score: r.Score})
+		}
+
+		similar := search.Diversify(candidates, limit)
+		works := make([]searchWork, 0, len(similar))
+
+		for _, s := range similar {
+			works = append(works, newSearchWork(s.Document, s.Score))
+		}
+
+		return c.JSON(works)
+	}
+}
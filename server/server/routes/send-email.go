@@ -2,27 +2,92 @@ package routes
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
 	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/workstatus"
 	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
 	"github.com/rs/zerolog"
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
 )
 
-func SendEmailStatusWork() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		var senderInfo helpers.StudentUploaderInfo
+//nolint:gochecknoglobals
+var rejectionReasonLabels = map[workstatus.RejectionReason]string{
+	workstatus.OffTopic:   "Lucrarea nu tratează subiectul ales",
+	workstatus.TooShort:   "Lucrarea este prea scurtă",
+	workstatus.Plagiarism: "Lucrarea este plagiată",
+	workstatus.Formatting: "Lucrarea nu este formatată corespunzător",
+	workstatus.Language:   "Lucrarea are prea multe greșeli de exprimare",
+}
+
+// fetchNotification retrieves the work whose author is notified. The sender must be the work's reviewer.
+func fetchNotification(c *fiber.Ctx, client *graphql.Client, workID int) (*gqlqueries.WorkNotificationOutput, error) {
+	claims := c.Locals("claims").(auth.CustomClaims)
+
+	var resp gqlqueries.WorkNotificationOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.WorkNotification, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"id": workID,
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
 
+	if resp.Query == nil {
+		return nil, helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu există", nil)
+	}
+
+	//nolint:exhaustivestruct
+	work := workstatus.Work{
+		AuthorID:  resp.Query.UserID,
+		TeacherID: resp.Query.TeacherID,
+		Status:    workstatus.Status(resp.Query.Status),
+	}
+
+	if !work.CanNotify(workstatus.Actor{ID: claims.UserID, Role: claims.Role}) {
+		return nil, helpers.SendError(c, fiber.StatusForbidden, "nu ai dreptul să notifici autorul acestei lucrări", nil)
+	}
+
+	if resp.Query.User == nil || resp.Query.User.FirstName == nil || resp.Query.User.Email == nil {
+		return nil, helpers.SendError(c, fiber.StatusConflict, "autorul lucrării nu are un email", nil)
+	}
+
+	return &resp, nil
+}
+
+// workLink returns the absolute address of the work's page, as the emails' links must have one.
+func workLink(workID int) string {
+	return fmt.Sprintf("%s/work/%d", meta.SiteURL, workID)
+}
+
+// SendEmailStatusWork emails the author of the work the outcome of its review. The recipient,
+// the status and the rejection's reason and feedback are those stored for the work.
+func SendEmailStatusWork(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		logger := c.Locals("logger").(zerolog.Logger)
-		m := mail.NewV3Mail()
 
-		if err := c.BodyParser(&senderInfo); err != nil {
-			return helpers.SendError(c, http.StatusBadRequest, "formularul de trimitere email este invalid", err)
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		resp, err := fetchNotification(c, client, workID)
+		if resp == nil {
+			return err
 		}
 
+		work := resp.Query
+		m := mail.NewV3Mail()
+
 		address := "no-reply@eseuri.com"
 		name := "Eseuri"
 		e := mail.NewEmail(name, address)
@@ -30,16 +95,24 @@ func SendEmailStatusWork() fiber.Handler {
 
 		p := mail.NewPersonalization()
 
-		if senderInfo.StatusLucrare == "approved" {
+		if work.Status == string(workstatus.Approved) {
 			m.SetTemplateID("d-709b5b58f80543559068014c4e689bfc")
 		} else {
 			m.SetTemplateID("d-069856a4edc04fd7a0b5ba1709a09ebb")
 		}
-		to := mail.NewEmail(senderInfo.NumeleElevului, senderInfo.EmailElev)
+		to := mail.NewEmail(*work.User.FirstName, *work.User.Email)
 		p.AddTos(to)
-		p.SetDynamicTemplateData("link", senderInfo.URL)
+		p.SetDynamicTemplateData("link", workLink(workID))
 
-		p.SetDynamicTemplateData("first_name", senderInfo.NumeleElevului)
+		p.SetDynamicTemplateData("first_name", *work.User.FirstName)
+
+		if work.Status == string(workstatus.Rejected) && work.RejectionReason != nil {
+			p.SetDynamicTemplateData("rejection_reason", rejectionReasonLabels[workstatus.RejectionReason(*work.RejectionReason)])
+
+			if work.Feedback != nil {
+				p.SetDynamicTemplateData("feedback", *work.Feedback)
+			}
+		}
 
 		m.AddPersonalizations(p)
 
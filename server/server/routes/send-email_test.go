@@ -0,0 +1,19 @@
+package routes_test
+
+import (
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/server/routes"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+//nolint:paralleltest
+func TestWorkLink(t *testing.T) {
+	siteURL := meta.SiteURL
+	defer func() { meta.SiteURL = siteURL }()
+
+	meta.SiteURL = "https://eseuri.com"
+
+	utils.AssertEqual(t, "https://eseuri.com/work/42", routes.WorkLink(42))
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/spelling"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+// spellingIssues returns the number of spelling issues of the content that is saved
+// together with the work, or nil if the spelling checker is disabled.
+func spellingIssues(checker *spelling.Checker, content string) interface{} {
+	if checker == nil {
+		return nil
+	}
+
+	return len(checker.Check(content))
+}
+
+// WorkSpelling sends the spelling issues of the work's content, with suggestions, to the users
+// that are allowed to see the work. The issues' offsets are relative to the returned version.
+func WorkSpelling(client *graphql.Client, checker *spelling.Checker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if checker == nil {
+			return helpers.SendError(c, fiber.StatusServiceUnavailable, "verificarea ortografiei nu este disponibilă", nil)
+		}
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		if ok, err := isWorkVisible(c, client, workID); !ok {
+			return err
+		}
+
+		work, err := fetchWorkContent(c, client, workID)
+		if work == nil {
+			return err
+		}
+
+		return c.JSON(fiber.Map{
+			"version": work.Version,
+			"issues":  checker.Check(work.Content),
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/style"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+// StyleRules sends the rules of the style linter, so teachers can cite them in their feedback.
+func StyleRules(linter *style.Linter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if linter == nil {
+			return helpers.SendError(c, fiber.StatusServiceUnavailable, "verificarea stilului nu este disponibilă", nil)
+		}
+
+		return c.JSON(linter.Rules())
+	}
+}
+
+// WorkStyle sends the style findings of the work's content to the users that are allowed to see the work.
+// Like the spelling issues, the findings' offsets are relative to the returned version of the content.
+func WorkStyle(client *graphql.Client, linter *style.Linter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if linter == nil {
+			return helpers.SendError(c, fiber.StatusServiceUnavailable, "verificarea stilului nu este disponibilă", nil)
+		}
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		if ok, err := isWorkVisible(c, client, workID); !ok {
+			return err
+		}
+
+		workType, err := fetchWorkType(c, client, workID)
+		if workType == "" {
+			return err
+		}
+
+		work, err := fetchWorkContent(c, client, workID)
+		if work == nil {
+			return err
+		}
+
+		return c.JSON(fiber.Map{
+			"version":  work.Version,
+			"findings": linter.Lint(work.Content, workType),
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/text/summary"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+	"github.com/rs/zerolog"
+)
+
+// summarizePageSize is the number of works summarized at once when the summaries are regenerated.
+const summarizePageSize = 50
+
+// summarizeWorks computes and saves the abstracts of the approved works that match the filter, in pages,
+// and returns the number of summarized works.
+func summarizeWorks(ctx context.Context, client *graphql.Client, where map[string]interface{}) (int, error) {
+	count := 0
+
+	for after := 0; ; {
+		var resp gqlqueries.WorksToSummarizeOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.WorksToSummarize, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: ctx,
+			Vars: map[string]interface{}{
+				"where": map[string]interface{}{
+					"_and": []interface{}{where, map[string]interface{}{
+						"status": map[string]interface{}{"_eq": workstatus.Approved},
+						"id":     map[string]interface{}{"_gt": after},
+					}},
+				},
+				"limit": summarizePageSize,
+			},
+			Promote: true,
+		}); err != nil {
+			return count, fmt.Errorf("failed to fetch works to summarize: %w", err)
+		}
+
+		if len(resp.Query) == 0 {
+			return count, nil
+		}
+
+		abstracts := make([]map[string]interface{}, 0, len(resp.Query))
+
+		for _, w := range resp.Query {
+			s := summary.Summarize(w.Content)
+			abstracts = append(abstracts, map[string]interface{}{
+				"work_id":            w.ID,
+				"version":            w.Version,
+				"summarizer_version": summary.Version,
+				"sentences":          s.Sentences,
+				"keywords":           s.Keywords,
+				"created_at":         timestampNow(),
+			})
+		}
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.SaveWorkAbstracts, helpers.GraphQLRequestOptions{
+			Context: ctx,
+			Vars: map[string]interface{}{
+				"abstracts": abstracts,
+			},
+			Promote: true,
+		}); err != nil {
+			return count, fmt.Errorf("failed to save work summaries: %w", err)
+		}
+
+		count += len(resp.Query)
+
+		if len(resp.Query) < summarizePageSize {
+			return count, nil
+		}
+
+		after = resp.Query[len(resp.Query)-1].ID
+	}
+}
+
+// publishWork prepares a work that was just approved to be read, by summarizing it. Failures
+// are only logged, as the summaries can be regenerated at any time.
+func publishWork(c *fiber.Ctx, client *graphql.Client, workID int) {
+	if _, err := summarizeWorks(c.Context(), client, map[string]interface{}{
+		"id": map[string]interface{}{"_eq": workID},
+	}); err != nil {
+		logger := c.Locals("logger").(zerolog.Logger)
+		logger.Warn().Err(err).Int("work", workID).Msg("failed to summarize work")
+	}
+}
+
+// RegenerateSummaries summarizes the approved works that have no summaries, or whose summaries were
+// computed by an older version of the summarizer. If the "work" query parameter is given, only that
+// work is summarized, even if its summary is current.
+func RegenerateSummaries(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := queryID(c, "work")
+		if workID == -1 {
+			return err
+		}
+
+		where := map[string]interface{}{
+			"_not": map[string]interface{}{
+				"abstract": map[string]interface{}{
+					"summarizer_version": map[string]interface{}{"_gte": summary.Version},
+				},
+			},
+		}
+		if workID != 0 {
+			where = map[string]interface{}{"id": map[string]interface{}{"_eq": workID}}
+		}
+
+		count, err := summarizeWorks(c.Context(), client, where)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(fiber.Map{
+			"works": count,
+		})
+	}
+}
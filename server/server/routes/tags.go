@@ -0,0 +1,347 @@
+package routes
+
+import (
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/tags"
+	"github.com/FiveIT/eseuri/server/text/summary"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+	"github.com/rs/zerolog"
+)
+
+// maxTagSuggestions is the number of tags suggested for a work.
+const maxTagSuggestions = 5
+
+type tagCount struct {
+	tags.Tag
+	// Works is the number of approved works that have the tag.
+	Works int `json:"works"`
+}
+
+// fetchVocabulary retrieves all the tags, with the number of approved works that have each of them.
+func fetchVocabulary(c *fiber.Ctx, client *graphql.Client) ([]tagCount, error) {
+	var resp gqlqueries.TagsOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.Tags, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	ret := make([]tagCount, 0, len(resp.Query))
+	for _, t := range resp.Query {
+		ret = append(ret, tagCount{Tag: tags.Tag{ID: t.ID, Name: t.Name}, Works: t.Works.Aggregate.Count})
+	}
+
+	return ret, nil
+}
+
+// suggestTags returns the tags of the vocabulary that appear in the content. Failing to
+// suggest tags doesn't fail the request, so if the vocabulary can't be fetched, no tags are suggested.
+func suggestTags(c *fiber.Ctx, client *graphql.Client, content string) []tags.Suggestion {
+	var resp gqlqueries.TagsOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.Tags, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Promote: true,
+	}); err != nil {
+		logger := c.Locals("logger").(zerolog.Logger)
+		logger.Warn().Err(err).Msg("failed to fetch tags to suggest")
+
+		return []tags.Suggestion{}
+	}
+
+	vocabulary := make([]tags.Tag, 0, len(resp.Query))
+	for _, t := range resp.Query {
+		vocabulary = append(vocabulary, tags.Tag{ID: t.ID, Name: t.Name})
+	}
+
+	return tags.Suggest(content, vocabulary, maxTagSuggestions)
+}
+
+// Tags sends the tags of the vocabulary, with the number of approved works that have each of them.
+func Tags(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		vocabulary, err := fetchVocabulary(c, client)
+		if vocabulary == nil {
+			return err
+		}
+
+		return c.JSON(vocabulary)
+	}
+}
+
+// CreateTag adds a tag to the vocabulary.
+func CreateTag(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input helpers.TagInput
+
+		if err := c.BodyParser(&input); err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "eticheta este invalidă", err)
+		}
+
+		name, err := tags.NormalizeName(input.Name)
+		if err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "numele etichetei este invalid", err)
+		}
+
+		var resp gqlqueries.InsertTagOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.InsertTag, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"name": name,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if resp.Query == nil {
+			return helpers.SendError(c, fiber.StatusConflict, "eticheta există deja", nil)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(tags.Tag{ID: resp.Query.ID, Name: resp.Query.Name})
+	}
+}
+
+// DeleteTag removes a tag from the vocabulary and from all the works that have it.
+func DeleteTag(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tagID, err := paramID(c, "id")
+		if tagID == 0 {
+			return err
+		}
+
+		var resp gqlqueries.DeleteTagOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.DeleteTag, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"id": tagID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if resp.Query == nil {
+			return helpers.SendError(c, fiber.StatusNotFound, "eticheta nu există", nil)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// TitleTags sends the tags of the approved works about the given title, counting both the essays
+// on the title and the characterizations of its characters, the most frequent tags first.
+func TitleTags(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		titleID, err := paramID(c, "id")
+		if titleID == 0 {
+			return err
+		}
+
+		var resp gqlqueries.TitleTagCountsOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.TitleTagCounts, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"titleID": titleID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		ret := make([]tagCount, 0, len(resp.Query))
+		for _, t := range resp.Query {
+			ret = append(ret, tagCount{Tag: tags.Tag{ID: t.TagID, Name: t.Name}, Works: t.WorkCount})
+		}
+
+		return c.JSON(ret)
+	}
+}
+
+// WorkTags sends the tags of the work to the users that are allowed to see the work.
+func WorkTags(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		if ok, err := isWorkVisible(c, client, workID); !ok {
+			return err
+		}
+
+		var resp gqlqueries.WorkTagsOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.WorkTags, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID": workID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		ret := make([]tags.Tag, 0, len(resp.Query))
+		for _, t := range resp.Query {
+			ret = append(ret, tags.Tag{ID: t.Tag.ID, Name: t.Tag.Name})
+		}
+
+		return c.JSON(ret)
+	}
+}
+
+// assertTagger checks if the user may curate the work's tags.
+func assertTagger(c *fiber.Ctx, client *graphql.Client, workID int) (bool, error) {
+	claims := c.Locals("claims").(auth.CustomClaims)
+
+	work, err := fetchWork(c, client, workID)
+	if work == nil {
+		return false, err
+	}
+
+	if !work.CanTag(workstatus.Actor{ID: claims.UserID, Role: claims.Role}) {
+		return false, helpers.SendError(c, fiber.StatusForbidden, "doar profesorul care revizuiește lucrarea o poate eticheta", nil)
+	}
+
+	return true, nil
+}
+
+// workTagParams parses the work and the tag from the route's parameters, and checks if the user may tag the work.
+func workTagParams(c *fiber.Ctx, client *graphql.Client) (workID, tagID int, err error) {
+	if workID, err = paramID(c, "id"); workID == 0 {
+		return 0, 0, err
+	}
+
+	if tagID, err = paramID(c, "tag"); tagID == 0 {
+		return 0, 0, err
+	}
+
+	if ok, err := assertTagger(c, client, workID); !ok {
+		return 0, 0, err
+	}
+
+	return workID, tagID, nil
+}
+
+// AddWorkTag tags the work with a tag of the vocabulary. Tagging a work with a tag it already has does nothing.
+func AddWorkTag(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		workID, tagID, err := workTagParams(c, client)
+		if workID == 0 {
+			return err
+		}
+
+		var tag gqlqueries.TagByPKOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.TagByPK, helpers.GraphQLRequestOptions{
+			Output:  &tag,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"id": tagID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if tag.Query == nil {
+			return helpers.SendError(c, fiber.StatusNotFound, "eticheta nu există", nil)
+		}
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.AddWorkTag, helpers.GraphQLRequestOptions{
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID":    workID,
+				"tagID":     tagID,
+				"teacherID": claims.UserID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.JSON(tags.Tag{ID: tag.Query.ID, Name: tag.Query.Name})
+	}
+}
+
+// RemoveWorkTag removes the tag from the work.
+func RemoveWorkTag(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, tagID, err := workTagParams(c, client)
+		if workID == 0 {
+			return err
+		}
+
+		var resp gqlqueries.RemoveWorkTagOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.RemoveWorkTag, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"workID": workID,
+				"tagID":  tagID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if resp.Query == nil {
+			return helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu are această etichetă", nil)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// TagSuggestions sends the keywords of the work's content and the tags of the vocabulary
+// that appear in it, to the teachers that may tag the work.
+func TagSuggestions(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		if ok, err := assertTagger(c, client, workID); !ok {
+			return err
+		}
+
+		work, err := fetchWorkContent(c, client, workID)
+		if work == nil {
+			return err
+		}
+
+		return c.JSON(fiber.Map{
+			"keywords": summary.Summarize(work.Content).Keywords,
+			"tags":     suggestTags(c, client, work.Content),
+		})
+	}
+}
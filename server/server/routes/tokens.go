@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"strings"
+	"time"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+const (
+	// defaultAccessTokenDays is for how many days the personal access tokens are valid, if not specified.
+	defaultAccessTokenDays = 30
+	maxAccessTokenDays     = 365
+	maxAccessTokenName     = 100
+)
+
+type accessToken struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"createdAt"`
+	ExpiresAt  string   `json:"expiresAt"`
+	LastUsedAt *string  `json:"lastUsedAt"`
+}
+
+func newAccessToken(t gqlqueries.AccessToken) accessToken {
+	return accessToken{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.Scopes,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+}
+
+// validateAccessTokenInput checks the input and fills in the defaults. It returns false
+// if the input is invalid, in which case the response was sent.
+func validateAccessTokenInput(c *fiber.Ctx, input *helpers.AccessTokenInput) (bool, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len([]rune(input.Name)) > maxAccessTokenName {
+		return false, helpers.SendError(c, fiber.StatusBadRequest, "numele tokenului este invalid", nil)
+	}
+
+	if len(input.Scopes) == 0 {
+		return false, helpers.SendErrorWithData(c, fiber.StatusBadRequest, "tokenul trebuie să aibă cel puțin o permisiune", nil, fiber.Map{
+			"scopes": auth.Scopes,
+		})
+	}
+
+	known := make(map[string]bool, len(auth.Scopes))
+	for _, scope := range auth.Scopes {
+		known[scope] = true
+	}
+
+	seen := make(map[string]bool, len(input.Scopes))
+
+	for _, scope := range input.Scopes {
+		if !known[scope] || seen[scope] {
+			return false, helpers.SendErrorWithData(c, fiber.StatusBadRequest, "permisiunile tokenului sunt invalide", nil, fiber.Map{
+				"scopes": auth.Scopes,
+			})
+		}
+
+		seen[scope] = true
+	}
+
+	if input.Days == 0 {
+		input.Days = defaultAccessTokenDays
+	}
+
+	if input.Days < 0 || input.Days > maxAccessTokenDays {
+		return false, helpers.SendErrorWithData(c, fiber.StatusBadRequest, "durata de valabilitate a tokenului este invalidă", nil, fiber.Map{
+			"maxDays": maxAccessTokenDays,
+		})
+	}
+
+	return true, nil
+}
+
+// CreateAccessToken creates a personal access token for the user's scripts. The token is sent only
+// in this response, as only its hash is stored.
+func CreateAccessToken(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		var input helpers.AccessTokenInput
+
+		if err := c.BodyParser(&input); err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "tokenul este invalid", err)
+		}
+
+		if ok, err := validateAccessTokenInput(c, &input); !ok {
+			return err
+		}
+
+		token, prefix, err := auth.NewAccessToken()
+		if err != nil {
+			return helpers.SendError(c, fiber.StatusInternalServerError, "nu s-a putut crea tokenul", err)
+		}
+
+		var resp gqlqueries.InsertAccessTokenOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.InsertAccessToken, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"token": map[string]interface{}{
+					"user_id":    claims.UserID,
+					"name":       input.Name,
+					"prefix":     prefix,
+					"hash":       auth.HashAccessToken(token),
+					"scopes":     "{" + strings.Join(input.Scopes, ",") + "}",
+					"expires_at": time.Now().UTC().AddDate(0, 0, input.Days).Format(time.RFC3339),
+				},
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"token":       token,
+			"accessToken": newAccessToken(resp.Query),
+		})
+	}
+}
+
+// AccessTokens sends the user's personal access tokens that are still valid.
+func AccessTokens(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		var resp gqlqueries.AccessTokensOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.AccessTokens, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"userID": claims.UserID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		tokens := make([]accessToken, 0, len(resp.Query))
+		for _, t := range resp.Query {
+			tokens = append(tokens, newAccessToken(t))
+		}
+
+		return c.JSON(tokens)
+	}
+}
+
+// RevokeAccessToken revokes one of the user's personal access tokens, which can't be used afterwards.
+func RevokeAccessToken(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		tokenID, err := paramID(c, "id")
+		if tokenID == 0 {
+			return err
+		}
+
+		var resp gqlqueries.RevokeAccessTokenOutput
+
+		//nolint:exhaustivestruct
+		if err := helpers.GraphQLRequest(client, gqlqueries.RevokeAccessToken, helpers.GraphQLRequestOptions{
+			Output:  &resp,
+			Context: c.Context(),
+			Vars: map[string]interface{}{
+				"id":     tokenID,
+				"userID": claims.UserID,
+			},
+			Promote: true,
+		}); err != nil {
+			return helpers.HandleGraphQLError(c, err)
+		}
+
+		if resp.Query.AffectedRows == 0 {
+			return helpers.SendError(c, fiber.StatusNotFound, "tokenul nu există", nil)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
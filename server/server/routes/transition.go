@@ -0,0 +1,287 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
+	"github.com/FiveIT/eseuri/server/server/helpers"
+	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/workstatus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/machinebox/graphql"
+)
+
+const (
+	// maxFeedbackLength is the maximum number of characters of the feedback given on rejection.
+	maxFeedbackLength = 2000
+	// errReviewExpired is the message of the exception raised by the database
+	// when a work is approved or rejected without a valid review lease.
+	errReviewExpired = "recenzia lucrării a expirat"
+)
+
+type workAuthor struct {
+	FirstName *string `json:"firstName"`
+	Email     *string `json:"email"`
+}
+
+type transitionOutput struct {
+	ID        int          `json:"id"`
+	Status    string       `json:"status"`
+	TeacherID *int         `json:"teacherID"`
+	UpdatedAt *string      `json:"updatedAt"`
+	Author    *workAuthor  `json:"author"`
+	Lease     *leaseOutput `json:"lease,omitempty"`
+	// RejectionReason and Feedback are set only if the work was rejected.
+	RejectionReason *string `json:"rejectionReason,omitempty"`
+	Feedback        *string `json:"feedback,omitempty"`
+}
+
+// fetchWork retrieves the information required to check what the user may do with the given work.
+// The teacher-student associations of the author are not fetched, see fetchAssociations.
+func fetchWork(c *fiber.Ctx, client *graphql.Client, workID int) (*workstatus.Work, error) {
+	var work gqlqueries.WorksByPKOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.WorksByPK, helpers.GraphQLRequestOptions{
+		Output:  &work,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"id": workID,
+		},
+		Promote: true,
+	}); err != nil {
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	if work.Query.ID == 0 {
+		return nil, helpers.SendError(c, fiber.StatusNotFound, "lucrarea nu există", nil)
+	}
+
+	return &workstatus.Work{
+		AuthorID:  work.Query.UserID,
+		TeacherID: work.Query.TeacherID,
+		Status:    workstatus.Status(work.Query.Status),
+	}, nil
+}
+
+// fetchAssociations adds to the work the teachers associated with its author.
+// They are fetched only when needed, for example when a teacher wants to
+// start reviewing a work for which no teacher was requested.
+func fetchAssociations(c *fiber.Ctx, client *graphql.Client, work *workstatus.Work) (bool, error) {
+	var associations gqlqueries.TeacherAssociationsOutput
+
+	//nolint:exhaustivestruct
+	if err := helpers.GraphQLRequest(client, gqlqueries.TeacherAssociations, helpers.GraphQLRequestOptions{
+		Output:  &associations,
+		Context: c.Context(),
+		Vars: map[string]interface{}{
+			"studentID": work.AuthorID,
+		},
+		Promote: true,
+	}); err != nil {
+		return false, helpers.HandleGraphQLError(c, err)
+	}
+
+	for _, a := range associations.Query {
+		work.AssociatedTeachers = append(work.AssociatedTeachers, a.TeacherID)
+	}
+
+	return true, nil
+}
+
+func handleTransitionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, workstatus.ErrInvalidTransition):
+		return helpers.SendError(c, fiber.StatusConflict, "lucrarea nu poate trece din starea curentă în cea cerută", err)
+	case errors.Is(err, workstatus.ErrForbidden):
+		return helpers.SendError(c, fiber.StatusForbidden, "nu ai dreptul să schimbi starea acestei lucrări", err)
+	default:
+		return fmt.Errorf("failed to check work transition: %w", err)
+	}
+}
+
+// reviewFields validates the rejection reason and the feedback of the given transition
+// and returns the work's fields to update. Approving a work clears the reason and
+// the feedback of any previous rejection. If the input is invalid, nil is returned.
+func reviewFields(c *fiber.Ctx, input helpers.WorkTransitionInput, to workstatus.Status) (map[string]interface{}, error) {
+	feedback := strings.TrimSpace(input.Feedback)
+	set := make(map[string]interface{})
+
+	if to != workstatus.Rejected {
+		if input.RejectionReason != "" || feedback != "" {
+			return nil, helpers.SendError(c, fiber.StatusBadRequest, "doar lucrările respinse pot avea motiv și observații", nil)
+		}
+
+		if to == workstatus.Approved {
+			set["rejection_reason"] = nil
+			set["feedback"] = nil
+		}
+
+		return set, nil
+	}
+
+	reason, err := workstatus.ParseRejectionReason(input.RejectionReason)
+	if err != nil {
+		return nil, helpers.SendError(c, fiber.StatusBadRequest, "motivul respingerii este invalid", err)
+	}
+
+	if utf8.RuneCountInString(feedback) > maxFeedbackLength {
+		return nil, helpers.SendError(c, fiber.StatusBadRequest, "observațiile sunt prea lungi", nil)
+	}
+
+	set["rejection_reason"] = reason
+	set["feedback"] = nil
+
+	if feedback != "" {
+		set["feedback"] = feedback
+	}
+
+	return set, nil
+}
+
+// transitionWork moves the work to the given status, if it is still in the status it
+// was when it was checked. The change is recorded by the database with the user
+// as the actor, which is why the user's ID is sent along the request. Works in review
+// are approved or rejected only if the reviewer's lease hasn't expired.
+//
+//nolint:lll
+func transitionWork(c *fiber.Ctx, client *graphql.Client, workID int, work *workstatus.Work, to workstatus.Status, set map[string]interface{}) (*transitionOutput, error) {
+	claims := c.Locals("claims").(auth.CustomClaims)
+
+	set["status"] = to
+
+	var resp gqlqueries.TransitionWorkOutput
+
+	if err := helpers.GraphQLRequest(client, gqlqueries.TransitionWork, helpers.GraphQLRequestOptions{
+		Output:  &resp,
+		Context: c.Context(),
+		Headers: map[string]string{
+			"X-Hasura-User-Id": strconv.Itoa(claims.UserID),
+		},
+		Vars: map[string]interface{}{
+			"workID": workID,
+			"from":   work.Status,
+			"set":    set,
+		},
+		Promote: true,
+	}); err != nil {
+		if strings.Contains(err.Error(), errReviewExpired) {
+			return nil, helpers.SendError(c, fiber.StatusConflict, "recenzia lucrării a expirat, începe-o din nou", err)
+		}
+
+		return nil, helpers.HandleGraphQLError(c, err)
+	}
+
+	if len(resp.Query.Returning) == 0 {
+		return nil, helpers.SendError(c, fiber.StatusConflict, "starea lucrării a fost schimbată între timp", nil)
+	}
+
+	w := resp.Query.Returning[0]
+	out := &transitionOutput{
+		ID:        w.ID,
+		Status:    w.Status,
+		TeacherID: w.TeacherID,
+		UpdatedAt: w.UpdatedAt,
+		Author:    nil,
+		Lease:     nil,
+
+		RejectionReason: w.RejectionReason,
+		Feedback:        w.Feedback,
+	}
+
+	if w.User != nil {
+		out.Author = &workAuthor{
+			FirstName: w.User.FirstName,
+			Email:     w.User.Email,
+		}
+	}
+
+	return out, nil
+}
+
+// TransitionWork changes the status of a work, if the work's state machine
+// and the user's relation to the work allow it. Approved works are summarized.
+func TransitionWork(client *graphql.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := c.Locals("claims").(auth.CustomClaims)
+
+		workID, err := paramID(c, "id")
+		if workID == 0 {
+			return err
+		}
+
+		var input helpers.WorkTransitionInput
+
+		if err := c.BodyParser(&input); err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "formularul de schimbare a stării este invalid", err)
+		}
+
+		to, err := workstatus.Parse(input.Status)
+		if err != nil {
+			return helpers.SendError(c, fiber.StatusBadRequest, "starea cerută este invalidă", err)
+		}
+
+		set, err := reviewFields(c, input, to)
+		if set == nil {
+			return err
+		}
+
+		// Works whose reviews were abandoned must be available again before checking.
+		if to == workstatus.InReview {
+			if _, err := expireReviewLeases(c.Context(), client); err != nil {
+				return helpers.HandleGraphQLError(c, err)
+			}
+		}
+
+		work, err := fetchWork(c, client, workID)
+		if work == nil {
+			return err
+		}
+
+		if to == workstatus.InReview && work.TeacherID == 0 {
+			if ok, err := fetchAssociations(c, client, work); !ok {
+				return err
+			}
+		}
+
+		// Starting a review that is already in progress either renews
+		// the lease, if the user is the reviewer, or is a conflict.
+		if to == workstatus.InReview && work.Status == workstatus.InReview {
+			lease, err := renewReviewLease(c, client, workID)
+			if lease == nil {
+				return err
+			}
+
+			return c.JSON(leaseTransitionOutput(lease))
+		}
+
+		actor := workstatus.Actor{ID: claims.UserID, Role: claims.Role}
+		if err := workstatus.Check(*work, actor, to); err != nil {
+			return handleTransitionError(c, err)
+		}
+
+		if to == workstatus.InReview {
+			lease, err := claimReviewLease(c, client, workID)
+			if lease == nil {
+				return err
+			}
+
+			return c.JSON(leaseTransitionOutput(lease))
+		}
+
+		out, err := transitionWork(c, client, workID, work, to, set)
+		if out == nil {
+			return err
+		}
+
+		if to == workstatus.Approved {
+			publishWork(c, client, workID)
+		}
+
+		return c.JSON(out)
+	}
+}
@@ -1,20 +1,29 @@
 package routes
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 	"strings"
 
+	"github.com/FiveIT/eseuri/server/docimage"
 	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
 	"github.com/FiveIT/eseuri/server/mime"
 	"github.com/FiveIT/eseuri/server/server/helpers"
 	"github.com/FiveIT/eseuri/server/server/middleware/auth"
+	"github.com/FiveIT/eseuri/server/spelling"
+	"github.com/FiveIT/eseuri/server/storage"
+	"github.com/FiveIT/eseuri/server/tags"
+	"github.com/FiveIT/eseuri/server/text/summary"
+	"github.com/FiveIT/eseuri/server/workstatus"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/go-tika/tika"
 	"github.com/machinebox/graphql"
+	"github.com/rs/zerolog"
 	"github.com/valyala/fasthttp"
 )
 
@@ -35,51 +44,132 @@ func getWorkSupertypeQuery(c *fiber.Ctx, workType string) (string, error) {
 	return query, nil
 }
 
-func parseFormFile(c *fiber.Ctx, client *tika.Client) (string, error) {
+func handleImageError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, docimage.ErrTooManyImages):
+		return helpers.SendError(c, fiber.StatusBadRequest, "lucrarea conține prea multe imagini", err)
+	case errors.Is(err, docimage.ErrImageTooLarge):
+		return helpers.SendError(c, fiber.StatusBadRequest, "o imagine din lucrare este prea mare", err)
+	case errors.Is(err, docimage.ErrInvalidDocument):
+		return helpers.SendError(c, fiber.StatusBadRequest, "documentul încărcat este invalid", err)
+	default:
+		return fmt.Errorf("failed to extract images: %w", err)
+	}
+}
+
+func parseFormFile(c *fiber.Ctx, client *tika.Client) (string, []docimage.Image, error) {
 	formFile, err := c.FormFile("file")
 	if err != nil {
-		return "", handleFormFileError(c, err)
+		return "", nil, handleFormFileError(c, err)
 	}
 
 	file, err := formFile.Open()
 	if err != nil {
-		return "", fmt.Errorf("failed to open form file: %w", err)
+		return "", nil, fmt.Errorf("failed to open form file: %w", err)
 	}
 
 	m, err := client.Detect(c.Context(), file)
 	if err != nil {
-		return "", fmt.Errorf("tika failed to detect MIME-type: %w", err)
+		return "", nil, fmt.Errorf("tika failed to detect MIME-type: %w", err)
 	}
 
 	_, err = file.Seek(0, io.SeekStart)
 	if err != nil {
-		return "", fmt.Errorf("failed to seek file to beginning: %w", err)
+		return "", nil, fmt.Errorf("failed to seek file to beginning: %w", err)
 	}
 
-	var body string
+	var (
+		body   string
+		images []docimage.Image
+	)
 
 	switch m {
 	case mime.DOC, mime.DOCX, mime.RTF, mime.ODT:
 		body, err = client.Parse(c.Context(), file)
 		if err != nil {
-			return "", fmt.Errorf("tika failed to parse: %w", err)
+			return "", nil, fmt.Errorf("tika failed to parse: %w", err)
+		}
+
+		if docimage.Supports(m) {
+			images, err = docimage.Extract(file, formFile.Size, m, docimage.DefaultLimits)
+			if err != nil {
+				return "", nil, handleImageError(c, err)
+			}
 		}
 	case mime.TXT:
 		s := &strings.Builder{}
 		if _, err = io.Copy(s, file); err != nil {
-			return "", fmt.Errorf("failed to copy form file: %w", err)
+			return "", nil, fmt.Errorf("failed to copy form file: %w", err)
 		}
 
 		body = s.String()
 	default:
-		return "", helpers.SendError(c, http.StatusBadRequest, "tipul fișierului încărcat nu este suportat", nil)
+		return "", nil, helpers.SendError(c, http.StatusBadRequest, "tipul fișierului încărcat nu este suportat", nil)
+	}
+
+	return body, images, nil
+}
+
+func imageKey(image docimage.Image) (string, error) {
+	const size = 16
+
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate image key: %w", err)
+	}
+
+	return "images/" + hex.EncodeToString(b) + image.Extension(), nil
+}
+
+// storeImages saves the images in the given storage and returns the objects
+// that must be inserted in the database to reference them from the work.
+// If storing any of the images fails, the already stored ones are removed.
+// If the storage is unavailable, a 503 is sent and the returned objects are nil.
+func storeImages(c *fiber.Ctx, store storage.Storage, images []docimage.Image) ([]map[string]interface{}, error) {
+	objects := make([]map[string]interface{}, 0, len(images))
+
+	for i, image := range images {
+		key, err := imageKey(image)
+		if err == nil {
+			err = store.Put(c.Context(), key, bytes.NewReader(image.Data))
+		}
+
+		if err != nil {
+			removeImages(c, store, objects)
+
+			if errors.Is(err, storage.ErrUnavailable) {
+				return nil, helpers.SendError(c, fiber.StatusServiceUnavailable, "stocarea imaginilor nu este disponibilă", err)
+			}
+
+			return nil, fmt.Errorf("failed to store image: %w", err)
+		}
+
+		objects = append(objects, map[string]interface{}{
+			"paragraph":    image.Paragraph,
+			"position":     i,
+			"content_type": image.ContentType,
+			"size":         len(image.Data),
+			"storage_key":  key,
+		})
 	}
 
-	return body, nil
+	return objects, nil
+}
+
+func removeImages(c *fiber.Ctx, store storage.Storage, objects []map[string]interface{}) {
+	logger := c.Locals("logger").(zerolog.Logger)
+
+	for _, object := range objects {
+		key, _ := object["storage_key"].(string)
+
+		if err := store.Delete(c.Context(), key); err != nil {
+			logger.Warn().Err(err).Str("key", key).Msg("failed to remove stored image")
+		}
+	}
 }
 
 //nolint:lll
-func insertWork(c *fiber.Ctx, body string, supertypeQuery string, input helpers.WorkFormInput, client *graphql.Client) (*gqlqueries.InsertWorkOutput, error) {
+func insertWork(c *fiber.Ctx, body string, spellingIssues interface{}, images []map[string]interface{}, supertypeQuery string, input helpers.WorkFormInput, client *graphql.Client) (*gqlqueries.InsertWorkOutput, error) {
 	claims := c.Locals("claims").(auth.CustomClaims)
 
 	var work gqlqueries.InsertWorkOutput
@@ -87,14 +177,13 @@ func insertWork(c *fiber.Ctx, body string, supertypeQuery string, input helpers.
 	workOpts := helpers.GraphQLRequestOptions{
 		Output:  &work,
 		Context: c.Context(),
-		Headers: map[string]string{
-			"X-Hasura-Role":    claims.Role,
-			"X-Hasura-User-Id": strconv.Itoa(claims.UserID),
-		},
+		Headers: claims.Headers(),
 		Vars: map[string]interface{}{
-			"status":             "pending",
+			"status":             workstatus.Pending,
 			"content":            body,
 			"requestedTeacherID": nil,
+			"spellingIssues":     spellingIssues,
+			"images":             images,
 		},
 		Promote: true,
 	}
@@ -103,11 +192,13 @@ func insertWork(c *fiber.Ctx, body string, supertypeQuery string, input helpers.
 		workOpts.Vars["requestedTeacherID"] = input.RequestedTeacherID
 	}
 
-	if claims.Role == "teacher" {
-		workOpts.Vars["status"] = "approved"
-	} else if info, err := fetchUserInfo(c, client); info != nil && info.Role == "teacher" {
-		workOpts.Vars["status"] = "approved"
-	} else if info == nil {
+	// The role from the token may be outdated, if the user was promoted to teacher after logging in.
+	// The role the user selected is used as it is, as they may have others.
+	if status := workstatus.Initial(claims.Role); status == workstatus.Approved || c.Get(auth.HeaderRole) != "" {
+		workOpts.Vars["status"] = status
+	} else if info, err := fetchUserInfo(c, client); info != nil {
+		workOpts.Vars["status"] = workstatus.Initial(info.Role)
+	} else {
 		return nil, err
 	}
 
@@ -131,7 +222,15 @@ func insertWork(c *fiber.Ctx, body string, supertypeQuery string, input helpers.
 	return &work, nil
 }
 
-func Upload(tikaClient *tika.Client, graphQLClient *graphql.Client) fiber.Handler {
+// uploadOutput is the uploaded work, with the keywords and the tags suggested for it.
+type uploadOutput struct {
+	ID            int               `json:"id"`
+	Keywords      []string          `json:"keywords"`
+	SuggestedTags []tags.Suggestion `json:"suggestedTags"`
+}
+
+//nolint:lll
+func Upload(tikaClient *tika.Client, graphQLClient *graphql.Client, store storage.Storage, checker *spelling.Checker) fiber.Handler {
 	return func(c *fiber.Ctx) (err error) {
 		var workInput helpers.WorkFormInput
 
@@ -145,16 +244,30 @@ func Upload(tikaClient *tika.Client, graphQLClient *graphql.Client) fiber.Handle
 			return err
 		}
 
-		body, err := parseFormFile(c, tikaClient)
+		body, images, err := parseFormFile(c, tikaClient)
 		if body == "" {
 			return err
 		}
 
-		work, err := insertWork(c, body, supertypeQuery, workInput, graphQLClient)
+		objects, err := storeImages(c, store, images)
+		if objects == nil {
+			return err
+		}
+
+		work, err := insertWork(c, body, spellingIssues(checker, body), objects, supertypeQuery, workInput, graphQLClient)
 		if work == nil {
+			removeImages(c, store, objects)
+
 			return err
 		}
 
-		return c.Status(http.StatusCreated).JSON(work.Query)
+		// Works uploaded by teachers are approved right away.
+		publishWork(c, graphQLClient, work.Query.ID)
+
+		return c.Status(http.StatusCreated).JSON(uploadOutput{
+			ID:            work.Query.ID,
+			Keywords:      summary.Summarize(body).Keywords,
+			SuggestedTags: suggestTags(c, graphQLClient, body),
+		})
 	}
 }
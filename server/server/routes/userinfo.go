@@ -1,8 +1,6 @@
 package routes
 
 import (
-	"strconv"
-
 	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
 	"github.com/FiveIT/eseuri/server/server/helpers"
 	"github.com/FiveIT/eseuri/server/server/middleware/auth"
@@ -24,10 +22,7 @@ func fetchUserInfo(c *fiber.Ctx, client *graphql.Client) (*userInfo, error) {
 	if err := helpers.GraphQLRequest(client, gqlqueries.User, helpers.GraphQLRequestOptions{
 		Output:  &response,
 		Context: c.Context(),
-		Headers: map[string]string{
-			"X-Hasura-User-Id": strconv.Itoa(claims.UserID),
-			"X-Hasura-Role":    claims.Role,
-		},
+		Headers: claims.Headers(),
 		Vars: map[string]interface{}{
 			"id": claims.UserID,
 		},
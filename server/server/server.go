@@ -6,6 +6,7 @@ import (
 	"github.com/FiveIT/eseuri/server/server/middleware/auth"
 	"github.com/FiveIT/eseuri/server/server/middleware/logger"
 	"github.com/FiveIT/eseuri/server/server/routes"
+	"github.com/FiveIT/eseuri/server/workstatus"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -16,6 +17,11 @@ import (
 func New() *fiber.App {
 	graphQLClient := graphql.NewClient(meta.HasuraEndpoint + "/v1/graphql")
 	tikaClient := tika.NewClient(nil, meta.TikaEndpoint)
+	store := config.Storage()
+	checker := config.SpellingChecker()
+	linter := config.StyleLinter()
+	hasher := config.ViewHasher()
+	limiter := config.RateLimiter()
 
 	app := fiber.New(config.Config())
 
@@ -35,12 +41,70 @@ func New() *fiber.App {
 	}))
 
 	r.Use(logger.Middleware(graphQLClient))
-	r.Use(auth.Middleware())
 
-	r.Get("/user", routes.UserInfo(graphQLClient))
+	guard := auth.NewGuard(auth.DefaultConfig(), graphQLClient)
+	public, optional, registered, admin := guard.Public(), guard.Optional(), guard.Registered(), guard.Admin()
 
-	r.Use(auth.AssertRegistration(graphQLClient))
-	r.Post("/upload", routes.Upload(tikaClient, graphQLClient))
+	// the routes personal access tokens may access, by the scope they need
+	readWorks, review, upload := guard.Scoped(auth.ScopeReadWorks), guard.Scoped(auth.ScopeReview), guard.Scoped(auth.ScopeUpload)
+	reader, reviewer, teacher := readWorks.Registered(), review.Registered(), review.Registered(workstatus.RoleTeacher)
+
+	// uploads share their bucket, as they all hit Tika
+	uploadLimit := limiter.Route("upload", config.UploadLimit)
+
+	r.Post("/leases/expire", admin, routes.ExpireReviewLeases(graphQLClient))
+	r.Post("/summaries/regenerate", admin, routes.RegenerateSummaries(graphQLClient))
+	r.Post("/tags", admin, routes.CreateTag(graphQLClient))
+	r.Delete("/tags/:id", admin, routes.DeleteTag(graphQLClient))
+	r.Post("/analytics/expire", admin, routes.ExpireViews(graphQLClient))
+	r.Post("/auth0/users/deleted", admin, routes.DeleteAuth0User(meta.Auth0))
+	r.Post("/auth0/users/promoted", admin, routes.PromoteAuth0User(graphQLClient, meta.Auth0))
+
+	r.Get("/search", public, limiter.Route("search", config.SearchLimit), routes.Search(graphQLClient))
+	r.Post("/works/:id/view", optional, limiter.Route("view", config.ViewLimit), routes.RecordView(graphQLClient, hasher))
+	r.Get("/works/trending", public, routes.TrendingWorks(graphQLClient))
+	r.Get("/works/most-read", public, routes.MostReadWorks(graphQLClient))
+	r.Get("/works/:id/stats", public, routes.WorkStats(graphQLClient))
+	r.Get("/titles/:id/stats", public, routes.TitleStats(graphQLClient))
+	r.Get("/tags", public, routes.Tags(graphQLClient))
+	r.Get("/titles/:id/tags", public, routes.TitleTags(graphQLClient))
+
+	r.Get("/user", readWorks.User(), routes.UserInfo(graphQLClient))
+	r.Get("/works/:id/images/:image", readWorks.User(), routes.WorkImage(graphQLClient, store))
+
+	r.Post("/upload", upload.Registered(), uploadLimit, routes.Upload(tikaClient, graphQLClient, store, checker))
+	r.Post("/works/:id/transition", reviewer, routes.TransitionWork(graphQLClient))
+	r.Post("/works/:id/notify", teacher, limiter.Route("email", config.EmailLimit), routes.SendEmailStatusWork(graphQLClient))
+	r.Get("/works/:id/lease", teacher, routes.ReviewLease(graphQLClient))
+	r.Post("/works/:id/lease", teacher, routes.RenewReviewLease(graphQLClient))
+	r.Post("/works/:id/content", upload.Registered(), uploadLimit, routes.UploadContent(tikaClient, graphQLClient, store, checker))
+	r.Get("/works/:id/annotations", reader, routes.Annotations(graphQLClient))
+	r.Post("/works/:id/annotations", reviewer, routes.CreateAnnotation(graphQLClient))
+	r.Patch("/works/:id/annotations/:annotation", reviewer, routes.EditAnnotation(graphQLClient))
+	r.Delete("/works/:id/annotations/:annotation", reviewer, routes.DeleteAnnotation(graphQLClient))
+	r.Post("/works/:id/annotations/:annotation/resolve", reviewer, routes.ResolveAnnotation(graphQLClient))
+	r.Delete("/works/:id/annotations/:annotation/resolve", reviewer, routes.ReopenAnnotation(graphQLClient))
+	r.Get("/rubrics", reader, routes.Rubrics(graphQLClient))
+	r.Get("/works/:id/grade", reader, routes.WorkGrade(graphQLClient))
+	r.Put("/works/:id/grade", teacher, routes.GradeWork(graphQLClient))
+	r.Get("/students/:id/grades", reader, routes.StudentGrades(graphQLClient))
+	r.Get("/works/:id/analysis", reader, routes.WorkAnalysis(graphQLClient))
+	r.Get("/works/:id/spelling", reader, routes.WorkSpelling(graphQLClient, checker))
+	r.Get("/works/:id/style", reader, routes.WorkStyle(graphQLClient, linter))
+	r.Get("/style/rules", reader, routes.StyleRules(linter))
+	r.Get("/works/:id/similar", reader, routes.SimilarWorks(graphQLClient))
+	r.Get("/works/:id/tags", reader, routes.WorkTags(graphQLClient))
+	r.Get("/works/:id/tags/suggestions", teacher, routes.TagSuggestions(graphQLClient))
+	r.Put("/works/:id/tags/:tag", teacher, routes.AddWorkTag(graphQLClient))
+	r.Delete("/works/:id/tags/:tag", teacher, routes.RemoveWorkTag(graphQLClient))
+	r.Get("/works/:id/position", registered, routes.Position(graphQLClient))
+	r.Put("/works/:id/position", registered, routes.SavePosition(graphQLClient))
+	r.Get("/user/history", registered, routes.History(graphQLClient))
+	r.Delete("/user/history", registered, routes.ClearHistory(graphQLClient))
+	r.Delete("/user/history/:work", registered, routes.ClearHistory(graphQLClient))
+	r.Get("/user/tokens", registered, routes.AccessTokens(graphQLClient))
+	r.Post("/user/tokens", registered, limiter.Route("access-tokens", config.AccessTokenLimit), routes.CreateAccessToken(graphQLClient))
+	r.Delete("/user/tokens/:id", registered, routes.RevokeAccessToken(graphQLClient))
 
 	return app
 }
@@ -2,13 +2,14 @@
 package server_test
 
 import (
-	"context"
 	"embed"
 	"io/fs"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/FiveIT/eseuri/server/issuer"
 	"github.com/FiveIT/eseuri/server/meta"
 	"github.com/FiveIT/eseuri/server/meta/gqlqueries"
 	"github.com/FiveIT/eseuri/server/server"
@@ -25,24 +26,23 @@ import (
 var files embed.FS
 
 var (
-	token string
-	gql   = graphql.NewClient(meta.HasuraEndpoint + "/v1/graphql")
+	gql = graphql.NewClient(meta.HasuraEndpoint + "/v1/graphql")
+
+	token     string
+	tokenErr  error
+	tokenOnce sync.Once
 )
 
 func TestMain(m *testing.M) {
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 
-	auth, err := meta.Auth0.AuthorizationToken(context.Background(), true)
-	if err != nil {
-		log.Fatal().Err(err).Msg("failed to get Auth0 token")
-	}
-
-	token = auth
+	// The tests authenticate with the tokens of the offline issuer.
+	meta.TrustDevIssuer = true
 
 	code := m.Run()
 
 	//nolint:exhaustivestruct
-	if err = helpers.GraphQLRequest(gql, gqlqueries.Clear, helpers.GraphQLRequestOptions{
+	if err := helpers.GraphQLRequest(gql, gqlqueries.Clear, helpers.GraphQLRequestOptions{
 		Promote: true,
 	}); err != nil {
 		log.Err(err).Msg("failed to clear up the database")
@@ -51,6 +51,35 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// studentToken creates a registered student, the first time it's called, and returns their token.
+func studentToken(tb testing.TB) string {
+	tb.Helper()
+
+	tokenOnce.Do(func() {
+		var student gqlqueries.InsertStudentOutput
+
+		//nolint:exhaustivestruct
+		if tokenErr = helpers.GraphQLRequest(gql, gqlqueries.InsertStudent, helpers.GraphQLRequestOptions{
+			Output: &student,
+			Vars: map[string]interface{}{
+				"email":   "student@example.com",
+				"auth0ID": "eseuri-dev|student",
+			},
+			Promote: true,
+		}); tokenErr != nil {
+			return
+		}
+
+		token = testhelper.JWT(tb, issuer.User{ID: student.Query.ID, Role: "student", Registered: true})
+	})
+
+	if tokenErr != nil {
+		tb.Fatalf("failed to create student: %v", tokenErr)
+	}
+
+	return token
+}
+
 func file(tb testing.TB, name string) fs.File {
 	tb.Helper()
 
@@ -131,7 +160,7 @@ func TestFiles(t *testing.T) {
 		t.Run(name+" file", func(t *testing.T) {
 			t.Parallel()
 
-			res := testhelper.RequestMultipart(t, app, "/upload", token, map[string]interface{}{
+			res := testhelper.RequestMultipart(t, app, "/upload", studentToken(t), map[string]interface{}{
 				"file":    f,
 				"type":    "essay",
 				"subject": 1,
@@ -148,7 +177,7 @@ func TestInvalidType(t *testing.T) {
 
 	app := server.New()
 
-	res := testhelper.RequestMultipart(t, app, "/upload", token, map[string]interface{}{
+	res := testhelper.RequestMultipart(t, app, "/upload", studentToken(t), map[string]interface{}{
 		"file":    file(t, "txt"),
 		"type":    "lol",
 		"subject": 1,
@@ -165,7 +194,7 @@ func TestRequestedTeacher(t *testing.T) {
 
 	app := server.New()
 
-	res := testhelper.RequestMultipart(t, app, "/upload", token, map[string]interface{}{
+	res := testhelper.RequestMultipart(t, app, "/upload", studentToken(t), map[string]interface{}{
 		"file":             file(t, "teacher.docx"),
 		"type":             "characterization",
 		"subject":          1,
@@ -0,0 +1,613 @@
+package spelling
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnsupportedEncoding = errors.New("spelling: unsupported dictionary encoding")
+	ErrInvalidAffix        = errors.New("spelling: invalid affix rule")
+)
+
+type flagFormat int
+
+const (
+	flagChar flagFormat = iota
+	flagLong
+	flagNum
+)
+
+// conditionPart matches a single character of a stem.
+type conditionPart struct {
+	chars   string
+	negated bool
+	any     bool
+}
+
+// condition is the pattern an affix rule's stem must match, made of
+// characters, character classes ([abc]), negated classes ([^abc]) and dots.
+type condition []conditionPart
+
+func parseCondition(s string) (condition, error) {
+	if s == "." || s == "" {
+		return nil, nil
+	}
+
+	var c condition
+
+	for s != "" {
+		//nolint:exhaustivestruct
+		switch s[0] {
+		case '.':
+			c = append(c, conditionPart{any: true})
+			s = s[1:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("%w: unterminated class in condition %q", ErrInvalidAffix, s)
+			}
+
+			class, negated := s[1:end], false
+			if strings.HasPrefix(class, "^") {
+				class, negated = class[1:], true
+			}
+
+			c = append(c, conditionPart{chars: class, negated: negated})
+			s = s[end+1:]
+		default:
+			_, size := utf8.DecodeRuneInString(s)
+			c = append(c, conditionPart{chars: s[:size]})
+			s = s[size:]
+		}
+	}
+
+	return c, nil
+}
+
+func (c condition) matchRune(i int, r rune) bool {
+	if c[i].any {
+		return true
+	}
+
+	return strings.ContainsRune(c[i].chars, r) != c[i].negated
+}
+
+// matchPrefix reports whether the word starts with the condition.
+func (c condition) matchPrefix(word []rune) bool {
+	if len(word) < len(c) {
+		return false
+	}
+
+	for i := range c {
+		if !c.matchRune(i, word[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchSuffix reports whether the word ends with the condition.
+func (c condition) matchSuffix(word []rune) bool {
+	if len(word) < len(c) {
+		return false
+	}
+
+	offset := len(word) - len(c)
+	for i := range c {
+		if !c.matchRune(i, word[offset+i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// affix is a prefix or suffix rule: the strip string is removed from the stem,
+// and the add string is added in its place, if the stem matches the condition.
+type affix struct {
+	flag         string
+	crossProduct bool
+	strip        string
+	add          string
+	condition    condition
+}
+
+// Dictionary is a Hunspell dictionary. Only the features of the format used by
+// common single-word dictionaries are supported: prefixes and suffixes, with
+// cross products, the TRY and REP suggestion settings and the FORBIDDENWORD,
+// NEEDAFFIX and NOSUGGEST flags. Compounding and continuation classes are ignored.
+type Dictionary struct {
+	flagFormat flagFormat
+	// words maps the stems to the flags of each of their homonyms.
+	words map[string][][]string
+	// prefixes and suffixes are indexed by the string the rules add.
+	prefixes map[string][]affix
+	suffixes map[string][]affix
+	// try are the characters used to build suggestions, ordered by frequency.
+	try []rune
+	// replacements are pairs of common mistakes and their corrections.
+	replacements [][2]string
+
+	forbiddenFlag string
+	needAffixFlag string
+	noSuggestFlag string
+}
+
+// Load reads a Hunspell dictionary from the .aff and .dic files with the given path and without the extension,
+// such as "/usr/share/hunspell/ro_RO".
+func Load(path string) (*Dictionary, error) {
+	aff, err := os.Open(path + ".aff")
+	if err != nil {
+		return nil, fmt.Errorf("spelling: failed to open affix file: %w", err)
+	}
+	defer aff.Close()
+
+	dic, err := os.Open(path + ".dic")
+	if err != nil {
+		return nil, fmt.Errorf("spelling: failed to open dictionary file: %w", err)
+	}
+	defer dic.Close()
+
+	return Parse(aff, dic)
+}
+
+// Parse reads a Hunspell dictionary from its affix and dictionary files. Both must be UTF-8 encoded.
+func Parse(aff, dic io.Reader) (*Dictionary, error) {
+	//nolint:exhaustivestruct
+	d := &Dictionary{
+		words:    make(map[string][][]string),
+		prefixes: make(map[string][]affix),
+		suffixes: make(map[string][]affix),
+	}
+
+	if err := d.parseAffixes(aff); err != nil {
+		return nil, err
+	}
+
+	if err := d.parseWords(dic); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func newScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	//nolint:gomnd
+	s.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	return s
+}
+
+//nolint:cyclop,funlen
+func (d *Dictionary) parseAffixes(r io.Reader) error {
+	s := newScanner(r)
+
+	// The number of rules left to read for the current affix class.
+	remaining := 0
+	// Whether the classes allow combining their prefixes and suffixes.
+	crossProduct := make(map[string]bool)
+
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\uFEFF")
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "SET":
+			if len(fields) > 1 && !strings.EqualFold(fields[1], "UTF-8") {
+				return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, fields[1])
+			}
+		case "FLAG":
+			if len(fields) > 1 {
+				switch fields[1] {
+				case "long":
+					d.flagFormat = flagLong
+				case "num":
+					d.flagFormat = flagNum
+				}
+			}
+		case "TRY":
+			if len(fields) > 1 {
+				d.try = []rune(fields[1])
+			}
+		case "REP":
+			//nolint:gomnd
+			if len(fields) > 2 {
+				d.replacements = append(d.replacements, [2]string{
+					strings.ReplaceAll(fields[1], "_", " "),
+					strings.ReplaceAll(fields[2], "_", " "),
+				})
+			}
+		case "FORBIDDENWORD":
+			if len(fields) > 1 {
+				d.forbiddenFlag = fields[1]
+			}
+		case "NEEDAFFIX":
+			if len(fields) > 1 {
+				d.needAffixFlag = fields[1]
+			}
+		case "NOSUGGEST":
+			if len(fields) > 1 {
+				d.noSuggestFlag = fields[1]
+			}
+		case "PFX", "SFX":
+			//nolint:gomnd
+			if len(fields) == 4 && remaining == 0 {
+				n, err := strconv.Atoi(fields[3])
+				if err != nil {
+					return fmt.Errorf("%w on line %d: invalid rule count", ErrInvalidAffix, line)
+				}
+
+				remaining = n
+				crossProduct[fields[0]+fields[1]] = fields[2] == "Y"
+
+				continue
+			}
+
+			//nolint:gomnd
+			if len(fields) < 4 {
+				return fmt.Errorf("%w on line %d", ErrInvalidAffix, line)
+			}
+
+			a, err := parseAffix(fields, crossProduct[fields[0]+fields[1]])
+			if err != nil {
+				return fmt.Errorf("%w on line %d", err, line)
+			}
+
+			if fields[0] == "PFX" {
+				d.prefixes[a.add] = append(d.prefixes[a.add], a)
+			} else {
+				d.suffixes[a.add] = append(d.suffixes[a.add], a)
+			}
+
+			remaining--
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("spelling: failed to read affix file: %w", err)
+	}
+
+	return nil
+}
+
+func parseAffix(fields []string, crossProduct bool) (affix, error) {
+	strip, add := fields[2], fields[3]
+	if strip == "0" {
+		strip = ""
+	}
+
+	// The continuation classes of the affix are ignored.
+	if i := strings.IndexByte(add, '/'); i != -1 {
+		add = add[:i]
+	}
+
+	if add == "0" {
+		add = ""
+	}
+
+	cond := "."
+	//nolint:gomnd
+	if len(fields) > 4 {
+		cond = fields[4]
+	}
+
+	c, err := parseCondition(cond)
+	if err != nil {
+		return affix{}, err
+	}
+
+	return affix{flag: fields[1], crossProduct: crossProduct, strip: strip, add: add, condition: c}, nil
+}
+
+func (d *Dictionary) parseFlags(s string) []string {
+	switch d.flagFormat {
+	case flagLong:
+		runes := []rune(s)
+		flags := make([]string, 0, len(runes)/2)
+
+		for i := 0; i+1 < len(runes); i += 2 {
+			flags = append(flags, string(runes[i:i+2]))
+		}
+
+		return flags
+	case flagNum:
+		return strings.Split(s, ",")
+	case flagChar:
+	}
+
+	flags := make([]string, 0, len(s))
+	for _, r := range s {
+		flags = append(flags, string(r))
+	}
+
+	return flags
+}
+
+func (d *Dictionary) parseWords(r io.Reader) error {
+	s := newScanner(r)
+
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+
+		// The first line holds the approximate number of words.
+		if line == 1 || text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		// Morphological fields follow the word, after whitespace.
+		if i := strings.IndexAny(text, " \t"); i != -1 {
+			text = text[:i]
+		}
+
+		word, flags := text, ""
+
+		for i := 0; i < len(text); i++ {
+			if text[i] == '\\' {
+				i++
+
+				continue
+			}
+
+			if text[i] == '/' {
+				word, flags = text[:i], text[i+1:]
+
+				break
+			}
+		}
+
+		word = strings.ReplaceAll(word, `\/`, "/")
+		d.words[word] = append(d.words[word], d.parseFlags(flags))
+	}
+
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("spelling: failed to read dictionary file: %w", err)
+	}
+
+	return nil
+}
+
+func hasFlag(flags []string, flag string) bool {
+	if flag == "" {
+		return false
+	}
+
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check reports whether the word is spelled correctly. Capitalized and uppercase
+// words are also accepted if their lowercase form is, as they may start sentences.
+func (d *Dictionary) Check(word string) bool {
+	if d.check(word) {
+		return true
+	}
+
+	lower := strings.ToLower(word)
+	if lower == word {
+		return false
+	}
+
+	if d.check(lower) {
+		return true
+	}
+
+	if strings.ToUpper(word) == word {
+		return d.check(capitalize(lower))
+	}
+
+	return false
+}
+
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+
+	return strings.ToUpper(string(r)) + word[size:]
+}
+
+func (d *Dictionary) check(word string) bool {
+	if homonyms, ok := d.words[word]; ok {
+		for _, flags := range homonyms {
+			if hasFlag(flags, d.forbiddenFlag) {
+				return false
+			}
+		}
+
+		for _, flags := range homonyms {
+			if !hasFlag(flags, d.needAffixFlag) {
+				return true
+			}
+		}
+	}
+
+	runes := []rune(word)
+
+	return d.checkSuffixed(runes, "") || d.checkPrefixed(runes)
+}
+
+// stemHas reports whether the stem is in the dictionary with the given flag and,
+// if it isn't empty, with the other flag too.
+func (d *Dictionary) stemHas(stem, flag, other string) bool {
+	for _, flags := range d.words[stem] {
+		if hasFlag(flags, d.forbiddenFlag) {
+			continue
+		}
+
+		if hasFlag(flags, flag) && (other == "" || hasFlag(flags, other)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkSuffixed reports whether the word is a stem with one of the dictionary's suffixes.
+// If prefixFlag isn't empty, the word was already stripped of a prefix with that flag, so
+// the stem must have both flags and the suffix must allow cross products.
+func (d *Dictionary) checkSuffixed(word []rune, prefixFlag string) bool {
+	for i := 0; i <= len(word); i++ {
+		for _, a := range d.suffixes[string(word[i:])] {
+			if prefixFlag != "" && !a.crossProduct {
+				continue
+			}
+
+			stem := append(append(make([]rune, 0, i+len(a.strip)), word[:i]...), []rune(a.strip)...)
+			if len(stem) == 0 || !a.condition.matchSuffix(stem) {
+				continue
+			}
+
+			if d.stemHas(string(stem), a.flag, prefixFlag) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkPrefixed reports whether the word is a stem with one of the dictionary's
+// prefixes, and optionally with one of its suffixes too.
+func (d *Dictionary) checkPrefixed(word []rune) bool {
+	for i := 0; i <= len(word); i++ {
+		for _, a := range d.prefixes[string(word[:i])] {
+			stem := append([]rune(a.strip), word[i:]...)
+			if len(stem) == 0 || !a.condition.matchPrefix(stem) {
+				continue
+			}
+
+			if d.stemHas(string(stem), a.flag, "") || a.crossProduct && d.checkSuffixed(stem, a.flag) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// suggestable reports whether the word is correct and may be suggested.
+func (d *Dictionary) suggestable(word string) bool {
+	if !d.Check(word) {
+		return false
+	}
+
+	for _, flags := range d.words[word] {
+		if hasFlag(flags, d.noSuggestFlag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Suggest returns at most max correct words that are similar to the given one. The replacements
+// of the dictionary are tried first, and then the words that are a single edit away: with two
+// adjacent characters swapped, or with a character replaced, removed or inserted.
+func (d *Dictionary) Suggest(word string, max int) []string {
+	var (
+		suggestions []string
+		seen        = map[string]bool{word: true}
+	)
+
+	try := func(candidate string) bool {
+		if !seen[candidate] {
+			seen[candidate] = true
+
+			if d.suggestable(candidate) {
+				suggestions = append(suggestions, candidate)
+			}
+		}
+
+		return len(suggestions) >= max
+	}
+
+	for _, rep := range d.replacements {
+		for i := strings.Index(word, rep[0]); i != -1; {
+			if try(word[:i] + rep[1] + word[i+len(rep[0]):]) {
+				return suggestions
+			}
+
+			next := strings.Index(word[i+1:], rep[0])
+			if next == -1 {
+				break
+			}
+
+			i += next + 1
+		}
+	}
+
+	runes := []rune(word)
+
+	for _, candidate := range d.edits(runes) {
+		if try(candidate) {
+			break
+		}
+	}
+
+	return suggestions
+}
+
+func (d *Dictionary) edits(runes []rune) []string {
+	var edits []string
+
+	edit := func(f func(r []rune) []rune) {
+		edits = append(edits, string(f(append(make([]rune, 0, len(runes)+1), runes...))))
+	}
+
+	for i := 0; i+1 < len(runes); i++ {
+		i := i
+		edit(func(r []rune) []rune {
+			r[i], r[i+1] = r[i+1], r[i]
+
+			return r
+		})
+	}
+
+	for i := range runes {
+		for _, c := range d.try {
+			i, c := i, c
+			edit(func(r []rune) []rune {
+				r[i] = c
+
+				return r
+			})
+		}
+	}
+
+	for i := range runes {
+		i := i
+		edit(func(r []rune) []rune {
+			return append(r[:i], r[i+1:]...)
+		})
+	}
+
+	for i := 0; i <= len(runes); i++ {
+		for _, c := range d.try {
+			i, c := i, c
+			edit(func(r []rune) []rune {
+				return append(r[:i], append([]rune{c}, r[i:]...)...)
+			})
+		}
+	}
+
+	return edits
+}
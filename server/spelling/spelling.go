@@ -0,0 +1,205 @@
+/*
+Package spelling finds the misspelled words of Romanian texts, using a Hunspell
+dictionary, such as the ro_RO one distributed with LibreOffice.
+
+Words written without their diacritics ("pamant" instead of "pământ") are
+reported separately from the other misspellings, since they are the most
+common mistakes in the uploaded works. The cedilla forms of "ș" and "ț" are
+accepted, as they are still produced by many keyboard layouts.
+*/
+package spelling
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/FiveIT/eseuri/server/text"
+)
+
+// Kind tells why a word is reported.
+type Kind string
+
+const (
+	// Misspelling is reported for words that are not in the dictionary.
+	Misspelling Kind = "misspelling"
+	// MissingDiacritics is reported for words that are in the dictionary if their diacritics are fixed.
+	MissingDiacritics Kind = "diacritics"
+)
+
+const (
+	// MaxSuggestions is the maximum number of suggestions returned for each issue.
+	MaxSuggestions = 5
+	// maxVariants is the maximum number of diacritic variants checked for a word.
+	maxVariants = 4096
+)
+
+// Issue is a misspelled word, found at the rune offsets [Start, End) of the text.
+type Issue struct {
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Word        string   `json:"word"`
+	Kind        Kind     `json:"kind"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// Checker finds the spelling issues of texts.
+type Checker struct {
+	dict *Dictionary
+}
+
+// NewChecker creates a checker that uses the given dictionary.
+func NewChecker(dict *Dictionary) *Checker {
+	return &Checker{dict: dict}
+}
+
+// isIgnored reports whether the word must not be checked: numbers, words that contain
+// digits, single letters and acronyms, which are usually not in dictionaries.
+func isIgnored(word string) bool {
+	if len([]rune(word)) < 2 || strings.IndexFunc(word, unicode.IsDigit) != -1 {
+		return true
+	}
+
+	return strings.ToUpper(word) == word
+}
+
+// isHyphen reports whether the runes between two words are a single hyphen, as in "într-o".
+func isHyphen(runes []rune, prev, next text.Span) bool {
+	return next.Start-prev.End == 1 && runes[prev.End] == '-'
+}
+
+// valid reports whether the word is correct, given the words it is joined to by hyphens.
+// Romanian dictionaries contain either the joined forms, like "într-o", or the
+// hyphenated clitics, like "s-" and "-l".
+func (c *Checker) valid(word string, joined string, before, after bool) bool {
+	if c.dict.Check(word) || joined != word && c.dict.Check(joined) {
+		return true
+	}
+
+	return after && c.dict.Check(word+"-") || before && c.dict.Check("-"+word)
+}
+
+// Check returns the spelling issues of the text, in order.
+func (c *Checker) Check(s string) []Issue {
+	s = text.FixCedillas(s)
+	runes := []rune(s)
+	words := text.Words(s)
+	issues := make([]Issue, 0)
+
+	for i := 0; i < len(words); {
+		// The words joined by hyphens are checked together.
+		j := i + 1
+		for j < len(words) && isHyphen(runes, words[j-1], words[j]) {
+			j++
+		}
+
+		joined := string(runes[words[i].Start:words[j-1].End])
+
+		for k := i; k < j; k++ {
+			w := words[k]
+			if isIgnored(w.Text) || c.valid(w.Text, joined, k > i, k < j-1) {
+				continue
+			}
+
+			issues = append(issues, c.issue(w))
+		}
+
+		i = j
+	}
+
+	return issues
+}
+
+func (c *Checker) issue(w text.Span) Issue {
+	if variants := c.diacriticVariants(w.Text); len(variants) != 0 {
+		if len(variants) > MaxSuggestions {
+			variants = variants[:MaxSuggestions]
+		}
+
+		return Issue{Start: w.Start, End: w.End, Word: w.Text, Kind: MissingDiacritics, Suggestions: variants}
+	}
+
+	suggestions := c.dict.Suggest(w.Text, MaxSuggestions)
+	if suggestions == nil {
+		suggestions = []string{}
+	}
+
+	return Issue{Start: w.Start, End: w.End, Word: w.Text, Kind: Misspelling, Suggestions: suggestions}
+}
+
+//nolint:gochecknoglobals
+var alternatives = map[rune][]rune{
+	'a': {'a', 'ă', 'â'}, 'i': {'i', 'î'}, 's': {'s', 'ș'}, 't': {'t', 'ț'},
+	'A': {'A', 'Ă', 'Â'}, 'I': {'I', 'Î'}, 'S': {'S', 'Ș'}, 'T': {'T', 'Ț'},
+}
+
+// diacriticVariants returns the correct words that differ from the given one only by their
+// diacritics, the ones with the fewest differences first. Words with too many letters that
+// could have diacritics are not checked.
+func (c *Checker) diacriticVariants(word string) []string {
+	base := []rune(text.Fold(word))
+	original := []rune(word)
+
+	count := 1
+	for _, r := range base {
+		if alts, ok := alternatives[r]; ok {
+			if count *= len(alts); count > maxVariants {
+				return nil
+			}
+		}
+	}
+
+	candidates := [][]rune{{}}
+
+	for _, r := range base {
+		alts, ok := alternatives[r]
+		if !ok {
+			alts = []rune{r}
+		}
+
+		next := make([][]rune, 0, len(candidates)*len(alts))
+
+		for _, cand := range candidates {
+			for _, alt := range alts {
+				next = append(next, append(append(make([]rune, 0, len(base)), cand...), alt))
+			}
+		}
+
+		candidates = next
+	}
+
+	type variant struct {
+		word        string
+		differences int
+	}
+
+	var variants []variant
+
+	for _, cand := range candidates {
+		v := string(cand)
+		if v == word || !c.dict.suggestable(v) {
+			continue
+		}
+
+		differences := 0
+
+		for i := range cand {
+			if cand[i] != original[i] {
+				differences++
+			}
+		}
+
+		variants = append(variants, variant{word: v, differences: differences})
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		return variants[i].differences < variants[j].differences
+	})
+
+	ret := make([]string, 0, len(variants))
+	for _, v := range variants {
+		ret = append(ret, v.word)
+	}
+
+	return ret
+}
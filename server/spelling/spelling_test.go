@@ -0,0 +1,83 @@
+package spelling_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/spelling"
+)
+
+func load(t *testing.T) *spelling.Dictionary {
+	t.Helper()
+
+	dict, err := spelling.Load("testdata/ro")
+	if err != nil {
+		t.Fatalf("Failed to load dictionary: %v", err)
+	}
+
+	return dict
+}
+
+func TestDictionaryCheck(t *testing.T) {
+	t.Parallel()
+
+	dict := load(t)
+
+	tests := []struct {
+		Word     string
+		Expected bool
+	}{
+		{Word: "casă", Expected: true},
+		{Word: "case", Expected: true},
+		{Word: "pământul", Expected: true},
+		{Word: "marele", Expected: true},
+		{Word: "necunoscut", Expected: true},
+		{Word: "necunoscutul", Expected: true},
+		{Word: "Pământul", Expected: true},
+		{Word: "SĂ", Expected: true},
+		{Word: "ion", Expected: false},
+		{Word: "casele", Expected: false},
+		{Word: "nepământ", Expected: false},
+		{Word: "ortografe", Expected: false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Word, func(t *testing.T) {
+			t.Parallel()
+
+			if ok := dict.Check(test.Word); ok != test.Expected {
+				t.Fatalf("Expected %v, got %v", test.Expected, ok)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+
+	dict := load(t)
+
+	if s := dict.Suggest("frunos", spelling.MaxSuggestions); !reflect.DeepEqual(s, []string{"frumos"}) {
+		t.Fatalf("Expected suggestion %q, got %v", "frumos", s)
+	}
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+
+	checker := spelling.NewChecker(load(t))
+
+	issues := checker.Check("Ion merge într-o casa mare. Pamantul e frumos, s-a dus 2 KM.\nEl merge frunos.")
+
+	expected := []spelling.Issue{
+		{Start: 17, End: 21, Word: "casa", Kind: spelling.MissingDiacritics, Suggestions: []string{"casă"}},
+		{Start: 28, End: 36, Word: "Pamantul", Kind: spelling.MissingDiacritics, Suggestions: []string{"Pământul"}},
+		{Start: 70, End: 76, Word: "frunos", Kind: spelling.Misspelling, Suggestions: []string{"frumos"}},
+	}
+
+	if !reflect.DeepEqual(issues, expected) {
+		t.Fatalf("Expected issues\n%+v\ngot\n%+v", expected, issues)
+	}
+}
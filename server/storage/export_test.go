@@ -0,0 +1,11 @@
+package storage
+
+import (
+	"net/http"
+	"time"
+)
+
+// Sign signs the request like the requests to the object store are signed.
+func (s *S3) Sign(req *http.Request, payloadHash string, now time.Time) {
+	s.sign(req, payloadHash, now)
+}
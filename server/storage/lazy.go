@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrUnavailable is returned by the lazy backends whose backend couldn't be created.
+var ErrUnavailable = errors.New("storage: backend unavailable")
+
+// Lazy is a Storage backend that creates the actual backend when it is first used, so that
+// the processes that never store objects don't need a backend to be configured.
+type Lazy struct {
+	create  func() (Storage, error)
+	once    sync.Once
+	backend Storage
+	err     error
+}
+
+// NewLazy creates a backend that is created with the given function when it is first used.
+// If the function fails, every operation fails with ErrUnavailable.
+func NewLazy(create func() (Storage, error)) *Lazy {
+	return &Lazy{create: create, once: sync.Once{}, backend: nil, err: nil}
+}
+
+func (l *Lazy) get() (Storage, error) {
+	l.once.Do(func() {
+		if l.backend, l.err = l.create(); l.err != nil {
+			l.err = fmt.Errorf("%w: %v", ErrUnavailable, l.err)
+		}
+	})
+
+	return l.backend, l.err
+}
+
+func (l *Lazy) Put(ctx context.Context, key string, r io.Reader) error {
+	s, err := l.get()
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, key, r)
+}
+
+func (l *Lazy) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, key)
+}
+
+func (l *Lazy) Delete(ctx context.Context, key string) error {
+	s, err := l.get()
+	if err != nil {
+		return err
+	}
+
+	return s.Delete(ctx, key)
+}
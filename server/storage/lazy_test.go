@@ -0,0 +1,49 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/storage"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+var errMisconfigured = errors.New("misconfigured")
+
+func TestLazy(t *testing.T) {
+	t.Parallel()
+
+	created := 0
+	s := storage.NewLazy(func() (storage.Storage, error) {
+		created++
+
+		return storage.NewFilesystem(t.TempDir()), nil
+	})
+
+	utils.AssertEqual(t, 0, created, "created before use")
+	utils.AssertEqual(t, nil, s.Put(context.Background(), "a", strings.NewReader("a")), "put")
+
+	r, err := s.Get(context.Background(), "a")
+	utils.AssertEqual(t, nil, err, "get")
+	utils.AssertEqual(t, nil, r.Close(), "close")
+	utils.AssertEqual(t, 1, created, "created once")
+}
+
+func TestLazyUnavailable(t *testing.T) {
+	t.Parallel()
+
+	s := storage.NewLazy(func() (storage.Storage, error) {
+		return nil, errMisconfigured
+	})
+
+	err := s.Put(context.Background(), "a", strings.NewReader("a"))
+	utils.AssertEqual(t, true, errors.Is(err, storage.ErrUnavailable), "put")
+
+	_, err = s.Get(context.Background(), "a")
+	utils.AssertEqual(t, true, errors.Is(err, storage.ErrUnavailable), "get")
+
+	err = s.Delete(context.Background(), "a")
+	utils.AssertEqual(t, true, errors.Is(err, storage.ErrUnavailable), "delete")
+}
@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat = "20060102T150405Z"
+	// emptyHash is the SHA-256 hash of an empty payload.
+	emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// maxErrorBody is how much of an error response is kept in the error message.
+	maxErrorBody = 512
+)
+
+var ErrS3 = errors.New("storage: S3 request failed")
+
+// S3 is a Storage backend that keeps objects in a bucket of an S3-compatible object store,
+// like Amazon S3, Cloudflare R2 or MinIO. The objects are addressed with path-style URLs.
+type S3 struct {
+	// Endpoint is the URL of the object store, like "https://s3.eu-central-1.amazonaws.com".
+	Endpoint        string
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// HTTPClient makes the requests to the object store. The default client is used if it's nil.
+	HTTPClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3 creates a backend that keeps the objects in the given bucket.
+func NewS3(endpoint, bucket, region, accessKeyID, secretAccessKey string) *S3 {
+	return &S3{
+		Endpoint:        strings.TrimSuffix(endpoint, "/"),
+		Bucket:          bucket,
+		Region:          region,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		HTTPClient:      nil,
+		now:             time.Now,
+	}
+}
+
+func (s *S3) client() *http.Client {
+	if s.HTTPClient != nil {
+		return s.HTTPClient
+	}
+
+	return http.DefaultClient
+}
+
+// escapePath encodes the path as S3 expects in the signed requests: everything but
+// the unreserved characters and the slashes is percent-encoded.
+func escapePath(p string) string {
+	var sb strings.Builder
+
+	for _, c := range []byte(p) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.IndexByte("-._~/", c) >= 0:
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// sign adds the AWS Signature Version 4 authorization to the request. The host and all the request's
+// headers are signed, and the x-amz-date and x-amz-content-sha256 headers are set if they're missing.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	if req.Header.Get("X-Amz-Date") == "" {
+		req.Header.Set("X-Amz-Date", now.UTC().Format(amzDateFormat))
+	}
+
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	amzDate := req.Header.Get("X-Amz-Date")
+	scope := amzDate[:8] + "/" + s.Region + "/s3/aws4_request"
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+// do sends a signed request for the object with the given key. Responses with error status codes are
+// returned as errors, except for the 404 responses, which the callers handle.
+func (s *S3) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(s.Endpoint + "/" + s.Bucket + "/" + key)
+	if err != nil {
+		return nil, fmt.Errorf("storage: invalid S3 endpoint: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to create S3 request: %w", err)
+	}
+
+	payloadHash := emptyHash
+	if body != nil {
+		payloadHash = sha256Hex(body)
+		req.ContentLength = int64(len(body))
+	}
+
+	s.sign(req, payloadHash, s.now())
+
+	res, err := s.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage: S3 request failed: %w", err)
+	}
+
+	if res.StatusCode >= 300 && res.StatusCode != http.StatusNotFound {
+		defer res.Body.Close()
+
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+
+		return nil, fmt.Errorf("%w: %s %q: %d %s", ErrS3, method, key, res.StatusCode, msg)
+	}
+
+	return res, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader) error {
+	// The objects are buffered, as S3 needs their length.
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("storage: failed to read %q: %w", key, err)
+	}
+
+	if body == nil {
+		body = []byte{}
+	}
+
+	res, err := s.do(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: bucket %q not found", ErrS3, s.Bucket)
+	}
+
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, key)
+	}
+
+	return res.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	return res.Body.Close()
+}
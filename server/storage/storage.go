@@ -0,0 +1,129 @@
+/*
+Package storage provides backends for persisting binary objects,
+such as the images extracted from uploaded works.
+
+Objects are addressed by slash-separated keys, for example:
+
+	s := storage.NewFilesystem(meta.StoragePath)
+	if err := s.Put(ctx, "images/abc.png", r); err != nil {
+		// handle error
+	}
+*/
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// Storage is implemented by every object storage backend.
+type Storage interface {
+	// Put stores the contents of the reader under the given key,
+	// overwriting any existing object.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get returns a reader for the object with the given key.
+	// If the object does not exist, ErrNotFound is returned.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object with the given key. Deleting
+	// an object that doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Filesystem is a Storage backend that keeps objects
+// as files inside a root directory on the local disk.
+type Filesystem struct {
+	Root string
+}
+
+// NewFilesystem creates a filesystem backend rooted at the given directory.
+// The directory is created when the first object is stored.
+func NewFilesystem(root string) *Filesystem {
+	return &Filesystem{Root: root}
+}
+
+// validateKey checks that the key is a clean, relative path, so it can't address objects outside of the backend's root.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return nil
+}
+
+func (f *Filesystem) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(f.Root, filepath.FromSlash(key)), nil
+}
+
+func (f *Filesystem) Put(_ context.Context, key string, r io.Reader) error {
+	p, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	//nolint:gomnd
+	if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("storage: failed to create directory for %q: %w", key, err)
+	}
+
+	file, err := os.Create(p)
+	if err != nil {
+		return fmt.Errorf("storage: failed to create %q: %w", key, err)
+	}
+
+	if _, err = io.Copy(file, r); err != nil {
+		file.Close()
+		os.Remove(p)
+
+		return fmt.Errorf("storage: failed to write %q: %w", key, err)
+	}
+
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("storage: failed to close %q: %w", key, err)
+	}
+
+	return nil
+}
+
+func (f *Filesystem) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrNotFound, key)
+	} else if err != nil {
+		return nil, fmt.Errorf("storage: failed to open %q: %w", key, err)
+	}
+
+	return file, nil
+}
+
+func (f *Filesystem) Delete(_ context.Context, key string) error {
+	p, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: failed to delete %q: %w", key, err)
+	}
+
+	return nil
+}
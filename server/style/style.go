@@ -0,0 +1,313 @@
+/*
+Package style finds the style problems teachers usually correct in Romanian school
+essays, such as colloquialisms, pleonasms or anglicisms.
+
+The rules are data, not code: they are read from JSON files, each holding the rules
+of a category. A rule matches phrases, written as sequences of words, where a word
+ending in "*" matches all the words starting with it, so that inflected forms are
+matched too ("urc* sus" matches both "urcă sus" and "urcau sus"). Matching ignores
+case and diacritics. The default rules are embedded in the package.
+
+A rule file looks like this:
+
+	{
+	  "category": "pleonasm",
+	  "rules": [
+	    {
+	      "id": "pleonasm-direction",
+	      "message": "Pleonasm: verbul exprimă deja direcția mișcării.",
+	      "patterns": ["urc* sus", "coboar* jos"],
+	      "suggestions": ["urcă", "coboară"],
+	      "workTypes": ["essay", "characterization"],
+	      "sentenceStart": false,
+	      "maxPer100Words": 0
+	    }
+	  ]
+	}
+
+Only the id, the message and the patterns are required. A rule with work types applies only
+to works of those types, a rule with sentenceStart matches only at the start of sentences,
+and a rule with maxPer100Words reports its matches only if there are more than that many
+for every 100 words of the text.
+*/
+package style
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/FiveIT/eseuri/server/text"
+)
+
+var (
+	ErrInvalidRule   = errors.New("style: invalid rule")
+	ErrDuplicateRule = errors.New("style: duplicate rule")
+)
+
+// Rule is a style rule, which teachers can cite by its ID.
+type Rule struct {
+	ID             string   `json:"id"`
+	Category       string   `json:"category"`
+	Message        string   `json:"message"`
+	Patterns       []string `json:"patterns"`
+	Suggestions    []string `json:"suggestions,omitempty"`
+	WorkTypes      []string `json:"workTypes,omitempty"`
+	SentenceStart  bool     `json:"sentenceStart,omitempty"`
+	MaxPer100Words float64  `json:"maxPer100Words,omitempty"`
+
+	patterns [][]patternWord
+}
+
+type patternWord struct {
+	text   string
+	prefix bool
+}
+
+func (w patternWord) matches(word string) bool {
+	if w.prefix {
+		return strings.HasPrefix(word, w.text)
+	}
+
+	return word == w.text
+}
+
+// compile parses the rule's patterns.
+func (r *Rule) compile() error {
+	if r.ID == "" || r.Message == "" || len(r.Patterns) == 0 {
+		return fmt.Errorf("%w: %q must have an id, a message and patterns", ErrInvalidRule, r.ID)
+	}
+
+	for _, p := range r.Patterns {
+		var words []patternWord
+
+		for _, field := range strings.Fields(p) {
+			stem := strings.TrimSuffix(field, "*")
+			parts := text.Words(stem)
+
+			for i, part := range parts {
+				words = append(words, patternWord{
+					text:   key(part.Text),
+					prefix: i == len(parts)-1 && stem != field,
+				})
+			}
+		}
+
+		if len(words) == 0 {
+			return fmt.Errorf("%w: %q has an empty pattern", ErrInvalidRule, r.ID)
+		}
+
+		r.patterns = append(r.patterns, words)
+	}
+
+	return nil
+}
+
+func (r *Rule) appliesTo(workType string) bool {
+	if len(r.WorkTypes) == 0 {
+		return true
+	}
+
+	for _, t := range r.WorkTypes {
+		if t == workType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Finding is a match of a rule, found at the rune offsets [Start, End) of the text.
+type Finding struct {
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Text        string   `json:"text"`
+	RuleID      string   `json:"ruleID"`
+	Category    string   `json:"category"`
+	Message     string   `json:"message"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// Linter finds the matches of a set of rules.
+type Linter struct {
+	rules []Rule
+}
+
+type ruleFile struct {
+	Category string `json:"category"`
+	Rules    []Rule `json:"rules"`
+}
+
+//go:embed rules/*.json
+var defaultRules embed.FS
+
+// Default returns a linter that uses the rules embedded in the package.
+func Default() (*Linter, error) {
+	rules, err := fs.Sub(defaultRules, "rules")
+	if err != nil {
+		return nil, fmt.Errorf("style: failed to open default rules: %w", err)
+	}
+
+	return Load(rules)
+}
+
+// Load reads the rules from all the JSON files at the root of the file system.
+func Load(fsys fs.FS) (*Linter, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("style: failed to list rule files: %w", err)
+	}
+
+	l := &Linter{rules: nil}
+	ids := make(map[string]string)
+
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("style: failed to read %s: %w", name, err)
+		}
+
+		var file ruleFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRule, name, err)
+		}
+
+		for _, r := range file.Rules {
+			r.Category = file.Category
+			if r.Category == "" {
+				r.Category = strings.TrimSuffix(path.Base(name), ".json")
+			}
+
+			if err := r.compile(); err != nil {
+				return nil, fmt.Errorf("%w in %s", err, name)
+			}
+
+			if other, ok := ids[r.ID]; ok {
+				return nil, fmt.Errorf("%w: %q in %s and %s", ErrDuplicateRule, r.ID, other, name)
+			}
+
+			ids[r.ID] = name
+			l.rules = append(l.rules, r)
+		}
+	}
+
+	return l, nil
+}
+
+// Rules returns the linter's rules.
+func (l *Linter) Rules() []Rule {
+	return l.rules
+}
+
+func key(word string) string {
+	return text.Fold(text.Normalize(word))
+}
+
+// Lint returns the findings of the rules that apply to the given work type, ordered by their offsets.
+func (l *Linter) Lint(s, workType string) []Finding {
+	runes := []rune(s)
+	words := text.Words(s)
+
+	keys := make([]string, 0, len(words))
+	for _, w := range words {
+		keys = append(keys, key(w.Text))
+	}
+
+	// sentenceStarts holds the indices of the words that start sentences.
+	sentenceStarts := make(map[int]bool)
+	i := 0
+
+	for _, sentence := range text.Sentences(s) {
+		for i < len(words) && words[i].Start < sentence.Start {
+			i++
+		}
+
+		if i < len(words) && words[i].End <= sentence.End {
+			sentenceStarts[i] = true
+		}
+	}
+
+	findings := make([]Finding, 0)
+
+	for k := range l.rules {
+		r := &l.rules[k]
+		if !r.appliesTo(workType) {
+			continue
+		}
+
+		matches := r.match(keys, sentenceStarts)
+
+		if r.MaxPer100Words > 0 && float64(len(matches)) <= r.MaxPer100Words*float64(len(words))/100 {
+			continue
+		}
+
+		for _, m := range matches {
+			suggestions := r.Suggestions
+			if suggestions == nil {
+				suggestions = []string{}
+			}
+
+			start, end := words[m[0]].Start, words[m[1]-1].End
+			findings = append(findings, Finding{
+				Start:       start,
+				End:         end,
+				Text:        string(runes[start:end]),
+				RuleID:      r.ID,
+				Category:    r.Category,
+				Message:     r.Message,
+				Suggestions: suggestions,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Start != findings[j].Start {
+			return findings[i].Start < findings[j].Start
+		}
+
+		return findings[i].RuleID < findings[j].RuleID
+	})
+
+	return findings
+}
+
+// match returns the word ranges [start, end) matched by the rule's patterns.
+// A range matched by more than one pattern is returned only once.
+func (r *Rule) match(keys []string, sentenceStarts map[int]bool) [][2]int {
+	var matches [][2]int
+
+	for i := range keys {
+		if r.SentenceStart && !sentenceStarts[i] {
+			continue
+		}
+
+		for _, p := range r.patterns {
+			if i+len(p) > len(keys) {
+				continue
+			}
+
+			matched := true
+
+			for j, w := range p {
+				if !w.matches(keys[i+j]) {
+					matched = false
+
+					break
+				}
+			}
+
+			if matched {
+				matches = append(matches, [2]int{i, i + len(p)})
+
+				break
+			}
+		}
+	}
+
+	return matches
+}
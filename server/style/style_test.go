@@ -0,0 +1,137 @@
+package style_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/FiveIT/eseuri/server/style"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	linter, err := style.Default()
+	if err != nil {
+		t.Fatalf("Failed to load default rules: %v", err)
+	}
+
+	type finding struct {
+		Text   string
+		RuleID string
+	}
+
+	tests := []struct {
+		Name     string
+		Text     string
+		WorkType string
+		Expected []finding
+	}{
+		{
+			Name:     "Pleonasm",
+			Text:     "Ion urcă sus pe deal. Apoi Urcau Sus din nou.",
+			WorkType: "essay",
+			Expected: []finding{{"urcă sus", "pleonasm-direction"}, {"Urcau Sus", "pleonasm-direction"}},
+		},
+		{
+			Name:     "Sentence start",
+			Text:     "Ion pleacă și se întoarce. Și apoi adoarme. „Și totuși...”",
+			WorkType: "essay",
+			Expected: []finding{{"Și", "sentence-start-si"}, {"Și", "sentence-start-si"}},
+		},
+		{
+			Name:     "Colloquialisms and anglicisms",
+			Text:     "A fost o chestie nasparo, dar finalul e cool.",
+			WorkType: "essay",
+			Expected: []finding{{"chestie", "colloquialism-chestie"}, {"cool", "anglicism-loanword"}},
+		},
+		{
+			Name:     "First person overuse in characterization",
+			Text:     "Eu cred că Ion este lacom. Mi se pare că eu am dreptate.",
+			WorkType: "characterization",
+			Expected: []finding{
+				{"Eu", "first-person-characterization"},
+				{"cred", "first-person-characterization"},
+				{"Mi se pare", "first-person-characterization"},
+				{"eu", "first-person-characterization"},
+			},
+		},
+		{
+			Name:     "First person in essay",
+			Text:     "Eu cred că Ion este lacom. Mi se pare că eu am dreptate.",
+			WorkType: "essay",
+			Expected: []finding{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			findings := linter.Lint(test.Text, test.WorkType)
+			if len(findings) != len(test.Expected) {
+				t.Fatalf("Expected %d findings, got %+v", len(test.Expected), findings)
+			}
+
+			for i, f := range findings {
+				if f.Text != test.Expected[i].Text || f.RuleID != test.Expected[i].RuleID {
+					t.Fatalf("Expected finding %+v, got %+v", test.Expected[i], f)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Files    fstest.MapFS
+		Expected error
+	}{
+		{
+			Name: "Valid rules",
+			Files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"category": "a", "rules": [{"id": "a", "message": "A", "patterns": ["a*"]}]}`)},
+			},
+		},
+		{
+			Name: "Missing patterns",
+			Files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"category": "a", "rules": [{"id": "a", "message": "A"}]}`)},
+			},
+			Expected: style.ErrInvalidRule,
+		},
+		{
+			Name: "Duplicate rule",
+			Files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"rules": [{"id": "a", "message": "A", "patterns": ["a"]}]}`)},
+				"b.json": {Data: []byte(`{"rules": [{"id": "a", "message": "B", "patterns": ["b"]}]}`)},
+			},
+			Expected: style.ErrDuplicateRule,
+		},
+		{
+			Name: "Invalid JSON",
+			Files: fstest.MapFS{
+				"a.json": {Data: []byte(`{"rules": [`)},
+			},
+			Expected: style.ErrInvalidRule,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := style.Load(test.Files)
+			if test.Expected == nil && err != nil || !errors.Is(err, test.Expected) {
+				t.Fatalf("Expected error %v, got %v", test.Expected, err)
+			}
+		})
+	}
+}
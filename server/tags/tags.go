@@ -0,0 +1,121 @@
+/*
+Package tags suggests the tags that describe a work, from the controlled vocabulary managed
+by the administrators.
+
+A tag is suggested if its name appears in the work's content, in any inflected form, with or
+without diacritics, so "condiția femeii" is suggested for a work that mentions "condiției
+femeilor". The tags that appear the most times are suggested first.
+*/
+package tags
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/FiveIT/eseuri/server/text"
+)
+
+// MaxNameLength is the maximum number of characters of a tag's name.
+const MaxNameLength = 50
+
+var ErrInvalidName = errors.New("tags: invalid name")
+
+// Tag is a tag of the vocabulary.
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Suggestion is a tag suggested for a work.
+type Suggestion struct {
+	Tag
+	// Matches is the number of times the tag appears in the work's content.
+	Matches int `json:"matches"`
+}
+
+// terms returns the terms a text is matched by: the stems of its words, without diacritics.
+// Stop words are kept, so that a tag's words must appear in the content in the same order.
+func terms(s string) []string {
+	words := text.Words(s)
+	ret := make([]string, 0, len(words))
+
+	for _, w := range words {
+		ret = append(ret, text.Fold(text.Stem(w.Text)))
+	}
+
+	return ret
+}
+
+// NormalizeName trims the name of a tag and checks that it's valid: it must have words
+// and it must not be too long. Names are lowercase, as they are themes, not proper nouns.
+func NormalizeName(name string) (string, error) {
+	name = strings.Join(strings.Fields(text.FixCedillas(strings.ToLower(name))), " ")
+
+	if len(text.Words(name)) == 0 || utf8.RuneCountInString(name) > MaxNameLength {
+		return "", ErrInvalidName
+	}
+
+	return name, nil
+}
+
+// Suggest returns at most max tags of the vocabulary that appear in the content, the most frequent first.
+func Suggest(content string, vocabulary []Tag, max int) []Suggestion {
+	contentTerms := terms(content)
+
+	// positions indexes the content's terms, so that only the tags whose first term is in the content are matched.
+	positions := make(map[string][]int)
+	for i, t := range contentTerms {
+		positions[t] = append(positions[t], i)
+	}
+
+	suggestions := make([]Suggestion, 0)
+
+	for _, tag := range vocabulary {
+		tagTerms := terms(tag.Name)
+		if len(tagTerms) == 0 {
+			continue
+		}
+
+		matches := 0
+
+		for _, start := range positions[tagTerms[0]] {
+			if matchesAt(contentTerms, tagTerms, start) {
+				matches++
+			}
+		}
+
+		if matches != 0 {
+			suggestions = append(suggestions, Suggestion{Tag: tag, Matches: matches})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Matches != suggestions[j].Matches {
+			return suggestions[i].Matches > suggestions[j].Matches
+		}
+
+		return suggestions[i].Name < suggestions[j].Name
+	})
+
+	if len(suggestions) > max {
+		suggestions = suggestions[:max]
+	}
+
+	return suggestions
+}
+
+func matchesAt(content, tag []string, start int) bool {
+	if start+len(tag) > len(content) {
+		return false
+	}
+
+	for i, t := range tag {
+		if content[start+i] != t {
+			return false
+		}
+	}
+
+	return true
+}
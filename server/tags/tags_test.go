@@ -0,0 +1,103 @@
+package tags_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/tags"
+)
+
+//nolint:gochecknoglobals
+var vocabulary = []tags.Tag{
+	{ID: 1, Name: "iubire"},
+	{ID: 2, Name: "condiția femeii"},
+	{ID: 3, Name: "conflict interior"},
+	{ID: 4, Name: "pământ"},
+}
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Content  string
+		Max      int
+		Expected []int
+	}{
+		{
+			Name:     "Inflected forms and missing diacritics",
+			Content:  "Romanul arată condiției femeilor de la sat. Conditia femeii depinde de pamantul pe care il are.",
+			Max:      5,
+			Expected: []int{2, 4},
+		},
+		{
+			Name:     "Most frequent first",
+			Content:  "Iubirea lui Ion pentru pământ. Pământul e mai presus de iubiri. Pământurile și pământul.",
+			Max:      5,
+			Expected: []int{4, 1},
+		},
+		{
+			Name:     "Words in order",
+			Content:  "Conflictul nu este doar unul interior.",
+			Max:      5,
+			Expected: []int{},
+		},
+		{
+			Name:     "Limit",
+			Content:  "Iubire și pământ, pământ.",
+			Max:      1,
+			Expected: []int{4},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			suggestions := tags.Suggest(test.Content, vocabulary, test.Max)
+			if len(suggestions) != len(test.Expected) {
+				t.Fatalf("Expected %v, got %v", test.Expected, suggestions)
+			}
+
+			for i, s := range suggestions {
+				if s.ID != test.Expected[i] {
+					t.Fatalf("Expected %v, got %v", test.Expected, suggestions)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Input    string
+		Expected string
+		Err      error
+	}{
+		{Name: "Spaces and case", Input: "  Condiţia   Femeii ", Expected: "condiția femeii"},
+		{Name: "No words", Input: " - ", Err: tags.ErrInvalidName},
+		{Name: "Too long", Input: "un nume de etichetă mult prea lung pentru a fi util cititorilor", Err: tags.ErrInvalidName},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			name, err := tags.NormalizeName(test.Input)
+			if !errors.Is(err, test.Err) {
+				t.Fatalf("Expected error %v, got %v", test.Err, err)
+			}
+
+			if name != test.Expected {
+				t.Fatalf("Expected %q, got %q", test.Expected, name)
+			}
+		})
+	}
+}
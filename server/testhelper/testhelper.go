@@ -11,7 +11,7 @@ import (
 	"time"
 
 	"github.com/FiveIT/eseuri/pkg/request"
-	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/issuer"
 	"github.com/FiveIT/eseuri/server/server/config"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
@@ -63,18 +63,20 @@ func App(tb testing.TB, middlewares ...interface{}) *fiber.App {
 	return app
 }
 
-func JWT(tb testing.TB, registerUser ...bool) string {
+// JWT issues a token for the user with the offline issuer. The tests must enable meta.TrustDevIssuer
+// for the guard to accept it.
+func JWT(tb testing.TB, user issuer.User) string {
 	tb.Helper()
 
-	token, err := meta.Auth0.AuthorizationToken(context.Background(), registerUser...)
+	iss, err := issuer.Default()
 	if err != nil {
-		tb.Fatalf("Failed to get JWT: %v", token)
+		tb.Fatalf("Failed to create token issuer: %v", err)
 	}
 
-	// Sleep to prevent JWT error "Token used before issued"
-	const seconds = 3
-
-	time.Sleep(time.Second * seconds)
+	token, err := iss.Token(user, time.Hour)
+	if err != nil {
+		tb.Fatalf("Failed to get JWT: %v", err)
+	}
 
 	return token
 }
@@ -0,0 +1,451 @@
+/*
+Package analysis computes metrics that give students quick feedback on their
+works before a teacher reviews them: how long and varied their sentences are,
+which words and phrases they repeat, how balanced their paragraphs are, whether
+the work has an introduction and a conclusion, and how readable it is.
+
+Everything is computed locally, using heuristics tuned for Romanian school essays.
+The metrics are hints for the students, not grades.
+*/
+package analysis
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/FiveIT/eseuri/server/text"
+)
+
+// Version identifies the heuristics used to compute the reports. It must be incremented
+// whenever they change, so reports computed with older heuristics are not reused.
+const Version = 1
+
+const (
+	// diversityWindow is the number of words over which the lexical diversity is averaged,
+	// so that it doesn't decrease just because the text is longer.
+	diversityWindow = 50
+	// maxRepetitions is the maximum number of repeated words and phrases that are reported.
+	maxRepetitions = 10
+	// minRepeatedWordLength is the length under which words are not reported as repeated.
+	minRepeatedWordLength = 3
+	// maxPhraseLength is the maximum number of words of a repeated phrase.
+	maxPhraseLength = 5
+	// maxBalancedDeviation is the highest coefficient of variation of the
+	// paragraphs' lengths for which the paragraphs are considered balanced.
+	maxBalancedDeviation = 0.5
+	// minStructuredParagraphs is the number of paragraphs a work must have
+	// to have an introduction, a body and a conclusion.
+	minStructuredParagraphs = 3
+	// maxEndsRatio is how many times longer than the average body paragraph
+	// the introduction and the conclusion may be.
+	maxEndsRatio = 1.5
+)
+
+// Count is a word or a phrase, and the number of times it appears in the text.
+type Count struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// Balance describes how evenly the text is split into paragraphs.
+type Balance struct {
+	// Lengths are the number of words of each paragraph.
+	Lengths []int `json:"lengths"`
+	// Deviation is the coefficient of variation of the lengths.
+	Deviation float64 `json:"deviation"`
+	Balanced  bool    `json:"balanced"`
+}
+
+// Readability is a Flesch reading ease score adapted to Romanian.
+type Readability struct {
+	// Score is between 0 and 100, higher meaning easier to read.
+	Score float64 `json:"score"`
+	Level string  `json:"level"`
+}
+
+// Report holds all the metrics computed for a text.
+type Report struct {
+	Words                 int     `json:"words"`
+	Sentences             int     `json:"sentences"`
+	Paragraphs            int     `json:"paragraphs"`
+	AverageSentenceLength float64 `json:"averageSentenceLength"`
+	// LexicalDiversity is the moving average of the ratio of distinct words to all words.
+	LexicalDiversity float64     `json:"lexicalDiversity"`
+	RepeatedWords    []Count     `json:"repeatedWords"`
+	RepeatedPhrases  []Count     `json:"repeatedPhrases"`
+	ParagraphBalance Balance     `json:"paragraphBalance"`
+	Introduction     bool        `json:"introduction"`
+	Conclusion       bool        `json:"conclusion"`
+	Quotations       int         `json:"quotations"`
+	Readability      Readability `json:"readability"`
+}
+
+// Analyze computes the report of the given text.
+func Analyze(s string) Report {
+	words := text.Words(s)
+	sentences := text.Sentences(s)
+	paragraphs := text.Paragraphs(s)
+
+	normalized := make([]string, 0, len(words))
+	for _, w := range words {
+		normalized = append(normalized, text.Normalize(w.Text))
+	}
+
+	balance := paragraphBalance(paragraphs)
+
+	r := Report{
+		Words:                 len(words),
+		Sentences:             len(sentences),
+		Paragraphs:            len(paragraphs),
+		AverageSentenceLength: 0,
+		LexicalDiversity:      round(lexicalDiversity(normalized)),
+		RepeatedWords:         repeatedWords(normalized),
+		RepeatedPhrases:       repeatedPhrases(sentences),
+		ParagraphBalance:      balance,
+		Introduction:          hasIntroduction(paragraphs, balance.Lengths),
+		Conclusion:            hasConclusion(paragraphs, balance.Lengths),
+		Quotations:            quotations(s),
+		Readability:           readability(normalized, len(sentences)),
+	}
+
+	if len(sentences) != 0 {
+		r.AverageSentenceLength = round(float64(len(words)) / float64(len(sentences)))
+	}
+
+	return r
+}
+
+func round(f float64) float64 {
+	//nolint:gomnd
+	return math.Round(f*100) / 100
+}
+
+func lexicalDiversity(words []string) float64 {
+	if len(words) == 0 {
+		return 0
+	}
+
+	window := diversityWindow
+	if len(words) < window {
+		window = len(words)
+	}
+
+	// The number of times each word appears in the current window.
+	counts := make(map[string]int)
+	for _, w := range words[:window] {
+		counts[w]++
+	}
+
+	sum := float64(len(counts))
+
+	for i := window; i < len(words); i++ {
+		removed := words[i-window]
+		if counts[removed]--; counts[removed] == 0 {
+			delete(counts, removed)
+		}
+
+		counts[words[i]]++
+		sum += float64(len(counts))
+	}
+
+	return sum / float64(len(words)-window+1) / float64(window)
+}
+
+func sortCounts(counts map[string]int, surface map[string]string, min int) []Count {
+	ret := make([]Count, 0)
+
+	for key, count := range counts {
+		if count >= min {
+			ret = append(ret, Count{Text: surface[key], Count: count})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+
+		return ret[i].Text < ret[j].Text
+	})
+
+	if len(ret) > maxRepetitions {
+		ret = ret[:maxRepetitions]
+	}
+
+	return ret
+}
+
+// repeatedWords returns the meaningful words that appear too often. The threshold increases
+// with the text's length, so that words that are naturally frequent, like the name of
+// the main character, are reported only if they are really overused.
+func repeatedWords(words []string) []Count {
+	counts := make(map[string]int)
+	surface := make(map[string]string)
+
+	for _, w := range words {
+		if len([]rune(w)) < minRepeatedWordLength || text.IsStopword(w) || !unicode.IsLetter([]rune(w)[0]) {
+			continue
+		}
+
+		key := text.Fold(w)
+		if _, ok := surface[key]; !ok {
+			surface[key] = w
+		}
+
+		counts[key]++
+	}
+
+	//nolint:gomnd
+	min := 3 + len(words)/150
+
+	return sortCounts(counts, surface, min)
+}
+
+// repeatedPhrases returns the phrases of two or more words that appear at least twice.
+// Phrases that start or end with a stop word are ignored, as are the phrases
+// that are part of a longer phrase which is repeated as many times.
+func repeatedPhrases(sentences []text.Span) []Count {
+	counts := make(map[string]int)
+	surface := make(map[string]string)
+
+	for _, s := range sentences {
+		words := text.Words(s.Text)
+
+		for n := 2; n <= maxPhraseLength; n++ {
+			for i := 0; i+n <= len(words); i++ {
+				first, last := words[i].Text, words[i+n-1].Text
+				if text.IsStopword(first) || text.IsStopword(last) {
+					continue
+				}
+
+				parts := make([]string, 0, n)
+				for _, w := range words[i : i+n] {
+					parts = append(parts, text.Normalize(w.Text))
+				}
+
+				phrase := strings.Join(parts, " ")
+				key := text.Fold(phrase)
+
+				if _, ok := surface[key]; !ok {
+					surface[key] = phrase
+				}
+
+				counts[key]++
+			}
+		}
+	}
+
+	for key, count := range counts {
+		if count < 2 {
+			delete(counts, key)
+		}
+	}
+
+	for key, count := range counts {
+		for other, otherCount := range counts {
+			if otherCount == count && len(other) > len(key) && strings.Contains(" "+other+" ", " "+key+" ") {
+				delete(counts, key)
+
+				break
+			}
+		}
+	}
+
+	//nolint:gomnd
+	return sortCounts(counts, surface, 2)
+}
+
+func paragraphBalance(paragraphs []text.Span) Balance {
+	b := Balance{Lengths: make([]int, 0, len(paragraphs)), Deviation: 0, Balanced: true}
+
+	total := 0
+
+	for _, p := range paragraphs {
+		n := len(text.Words(p.Text))
+		b.Lengths = append(b.Lengths, n)
+		total += n
+	}
+
+	if len(paragraphs) < 2 || total == 0 {
+		return b
+	}
+
+	mean := float64(total) / float64(len(paragraphs))
+	variance := 0.0
+
+	for _, n := range b.Lengths {
+		variance += (float64(n) - mean) * (float64(n) - mean)
+	}
+
+	b.Deviation = round(math.Sqrt(variance/float64(len(paragraphs))) / mean)
+	b.Balanced = b.Deviation <= maxBalancedDeviation
+
+	return b
+}
+
+//nolint:gochecknoglobals
+var (
+	introductionMarkers = []string{
+		"in opinia mea", "consider ca", "voi argumenta", "voi demonstra", "in cele ce urmeaza", "in aceasta lucrare",
+	}
+	conclusionMarkers = []string{
+		"in concluzie", "in incheiere", "asadar", "prin urmare", "in final", "pe scurt", "in consecinta",
+		"concluzionand", "putem afirma", "se poate spune",
+	}
+)
+
+func containsMarker(paragraph string, markers []string) bool {
+	p := " " + strings.Join(strings.Fields(text.Fold(strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) {
+			return ' '
+		}
+
+		return r
+	}, paragraph)))), " ") + " "
+
+	for _, m := range markers {
+		if strings.Contains(p, " "+m+" ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isShortEnd reports whether the paragraph with the given length is short
+// compared to the body paragraphs, as introductions and conclusions are.
+func isShortEnd(length int, lengths []int) bool {
+	body := lengths[1 : len(lengths)-1]
+
+	total := 0
+	for _, n := range body {
+		total += n
+	}
+
+	return float64(length) <= maxEndsRatio*float64(total)/float64(len(body))
+}
+
+// hasIntroduction reports whether the first paragraph looks like an introduction:
+// it either uses a phrase specific to introductions, or it is short compared to the body.
+func hasIntroduction(paragraphs []text.Span, lengths []int) bool {
+	if len(paragraphs) < minStructuredParagraphs {
+		return false
+	}
+
+	return containsMarker(paragraphs[0].Text, introductionMarkers) || isShortEnd(lengths[0], lengths)
+}
+
+// hasConclusion reports whether the last paragraph looks like a conclusion,
+// using the same rules as hasIntroduction.
+func hasConclusion(paragraphs []text.Span, lengths []int) bool {
+	if len(paragraphs) < minStructuredParagraphs {
+		return false
+	}
+
+	last := len(paragraphs) - 1
+
+	return containsMarker(paragraphs[last].Text, conclusionMarkers) || isShortEnd(lengths[last], lengths)
+}
+
+// quotations counts the quotations of the text. Both Romanian („…”), French («…»)
+// and straight ("…") quotation marks are recognized.
+func quotations(s string) int {
+	count, straight := 0, 0
+
+	for _, r := range s {
+		switch r {
+		case '„', '«', '“':
+			count++
+		case '"':
+			straight++
+		}
+	}
+
+	//nolint:gomnd
+	return count + straight/2
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aăâeiîou", r)
+}
+
+// hiatuses are pairs of vowels that are pronounced in separate syllables.
+//
+//nolint:gochecknoglobals
+var hiatuses = []string{"aa", "ee", "ii", "oo", "uu", "ae", "ao", "eo", "oe", "ue"}
+
+// syllables counts the syllables of a lowercase Romanian word. Each group of vowels is
+// a syllable, unless it contains a hiatus. A final "i" after a consonant isn't a syllable,
+// as in "pomi" or "lupi".
+func syllables(word string) int {
+	runes := []rune(text.Normalize(word))
+	count := 0
+
+	for i := 0; i < len(runes); i++ {
+		if !isVowel(runes[i]) || i > 0 && isVowel(runes[i-1]) {
+			continue
+		}
+
+		count++
+
+		for j := i + 1; j < len(runes) && isVowel(runes[j]); j++ {
+			pair := string(runes[j-1 : j+1])
+
+			for _, h := range hiatuses {
+				if pair == h {
+					count++
+				}
+			}
+		}
+	}
+
+	n := len(runes)
+	//nolint:gomnd
+	if n >= 2 && count > 1 && runes[n-1] == 'i' && !isVowel(runes[n-2]) {
+		count--
+	}
+
+	if count == 0 {
+		return 1
+	}
+
+	return count
+}
+
+// readability computes the Flesch reading ease of the text. Romanian words are longer
+// on average than English ones, so the weight of the number of syllables per word is
+// lowered from 84.6 to 60, which puts usual school essays in the middle of the scale.
+func readability(words []string, sentences int) Readability {
+	if len(words) == 0 || sentences == 0 {
+		return Readability{Score: 0, Level: "necunoscut"}
+	}
+
+	total := 0
+	for _, w := range words {
+		total += syllables(w)
+	}
+
+	wordsPerSentence := float64(len(words)) / float64(sentences)
+	syllablesPerWord := float64(total) / float64(len(words))
+
+	//nolint:gomnd
+	score := math.Max(0, math.Min(100, 206.835-1.015*wordsPerSentence-60*syllablesPerWord))
+
+	var level string
+
+	//nolint:gomnd
+	switch {
+	case score >= 70:
+		level = "ușor"
+	case score >= 50:
+		level = "mediu"
+	case score >= 30:
+		level = "dificil"
+	default:
+		level = "foarte dificil"
+	}
+
+	return Readability{Score: round(score), Level: level}
+}
@@ -0,0 +1,102 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/text/analysis"
+)
+
+const essay = `În opinia mea, romanul „Ion” de Liviu Rebreanu este un roman realist. Voi argumenta această idee.
+
+Romanul prezintă viața satului ardelean. Personajul principal, Ion, este dominat de dorința de a avea pământ. Pentru pământ, Ion se căsătorește cu Ana, deși o iubește pe Florica.
+
+Conflictul interior al personajului este evident. Ion spune: „Pământul îi era drag ca ochii din cap”. Dorința de a avea pământ îl transformă într-un om fără scrupule.
+
+În concluzie, romanul este realist prin tema aleasă și prin personaje.`
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	r := analysis.Analyze(essay)
+
+	if r.Paragraphs != 4 {
+		t.Errorf("Expected 4 paragraphs, got %d", r.Paragraphs)
+	}
+
+	if r.Sentences != 9 {
+		t.Errorf("Expected 9 sentences, got %d", r.Sentences)
+	}
+
+	if !r.Introduction || !r.Conclusion {
+		t.Errorf("Expected an introduction and a conclusion, got %v and %v", r.Introduction, r.Conclusion)
+	}
+
+	if r.Quotations != 2 {
+		t.Errorf("Expected 2 quotations, got %d", r.Quotations)
+	}
+
+	if r.LexicalDiversity <= 0 || r.LexicalDiversity > 1 {
+		t.Errorf("Expected lexical diversity in (0, 1], got %v", r.LexicalDiversity)
+	}
+
+	if r.Readability.Score < 0 || r.Readability.Score > 100 {
+		t.Errorf("Expected readability score in [0, 100], got %v", r.Readability.Score)
+	}
+
+	found := false
+
+	for _, p := range r.RepeatedPhrases {
+		if p.Text == "dorința de a avea pământ" && p.Count == 2 {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Expected repeated phrase %q, got %v", "dorința de a avea pământ", r.RepeatedPhrases)
+	}
+}
+
+func TestAnalyzeEmpty(t *testing.T) {
+	t.Parallel()
+
+	r := analysis.Analyze("")
+
+	if r.Words != 0 || r.Introduction || r.Conclusion || r.AverageSentenceLength != 0 {
+		t.Fatalf("Expected an empty report, got %+v", r)
+	}
+}
+
+func TestRepeatedWords(t *testing.T) {
+	t.Parallel()
+
+	r := analysis.Analyze("Frumosul este frumos. Un lucru frumos rămâne frumos. Frumos, FRUMOS, frumoș.")
+
+	if len(r.RepeatedWords) == 0 || r.RepeatedWords[0].Text != "frumos" || r.RepeatedWords[0].Count != 6 {
+		t.Fatalf("Expected %q repeated 6 times, got %v", "frumos", r.RepeatedWords)
+	}
+}
+
+func TestParagraphBalance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Text     string
+		Balanced bool
+	}{
+		{Name: "Even paragraphs", Text: "unu doi trei\npatru cinci șase\nșapte opt nouă", Balanced: true},
+		{Name: "Uneven paragraphs", Text: "unu\ndoi trei patru cinci șase șapte opt nouă zece unsprezece", Balanced: false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if b := analysis.Analyze(test.Text).ParagraphBalance; b.Balanced != test.Balanced {
+				t.Fatalf("Expected balanced %v, got %+v", test.Balanced, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package text
+
+import (
+	"strings"
+)
+
+// The suffixes used by the stemmer, grouped by the way they are removed.
+//
+//nolint:gochecknoglobals,lll
+var (
+	articleSuffixes = map[string]string{
+		"ul": "", "ului": "", "aua": "a", "ea": "e", "ele": "e", "elor": "e",
+		"ii": "i", "iua": "i", "iei": "i", "iile": "i", "iilor": "i", "ilor": "i",
+		"ile": "i", "atei": "at", "ație": "ați", "ația": "ați",
+	}
+	comboSuffixes = map[string]string{
+		"abilitate": "abil", "abilitati": "abil", "abilităi": "abil", "abilități": "abil",
+		"ibilitate": "ibil",
+		"ivitate":   "iv", "ivitati": "iv", "ivităi": "iv", "ivități": "iv",
+		"icitate": "ic", "icitati": "ic", "icităi": "ic", "icități": "ic", "icator": "ic", "icatori": "ic",
+		"iciv": "ic", "iciva": "ic", "icive": "ic", "icivi": "ic", "icivă": "ic",
+		"ical": "ic", "icala": "ic", "icale": "ic", "icali": "ic", "icală": "ic",
+		"ativ": "at", "ativa": "at", "ative": "at", "ativi": "at", "ativă": "at", "ațiune": "at",
+		"atoare": "at", "ator": "at", "atori": "at", "ătoare": "at", "ător": "at", "ători": "at",
+		"itiv": "it", "itiva": "it", "itive": "it", "itivi": "it", "itivă": "it", "ițiune": "it",
+		"itoare": "it", "itor": "it", "itori": "it",
+	}
+	standardSuffixes = map[string]string{
+		"anta": "", "ante": "", "anti": "", "antă": "", "ator": "", "atori": "", "itate": "", "itati": "",
+		"ităi": "", "ități": "", "iv": "", "iva": "", "ive": "", "ivi": "", "ivă": "", "ic": "", "ica": "",
+		"ice": "", "ici": "", "ică": "", "abil": "", "abila": "", "abile": "", "abili": "", "abilă": "",
+		"ibil": "", "ibila": "", "ibile": "", "ibili": "", "ibilă": "", "oasa": "", "oasă": "", "oase": "",
+		"os": "", "osi": "", "oși": "", "ant": "", "ut": "", "uta": "", "ute": "", "uti": "", "ută": "",
+		"it": "", "ita": "", "ite": "", "iti": "", "ită": "",
+		"iune": "", "iuni": "",
+		"ism": "ist", "isme": "ist", "ist": "ist", "ista": "ist", "iste": "ist", "isti": "ist", "istă": "ist", "iști": "ist",
+	}
+	// verbSuffixes are removed only if they follow a consonant or "u".
+	verbSuffixes = toSet(
+		"are", "ere", "ire", "âre", "ind", "ând", "indu", "ându", "eze", "ească", "ez", "ezi", "ează", "esc", "ești",
+		"ește", "ăsc", "ăști", "ăște", "am", "ai", "au", "eam", "eai", "ea", "eați", "eau", "iam", "iai", "ia",
+		"iați", "iau", "ui", "ași", "arăm", "arăți", "ară", "uși", "urăm", "urăți", "ură", "iși", "irăm", "irăți",
+		"iră", "âi", "âși", "ârăm", "ârăți", "âră", "asem", "aseși", "ase", "aserăm", "aserăți", "aseră", "isem",
+		"iseși", "ise", "iserăm", "iserăți", "iseră", "âsem", "âseși", "âse", "âserăm", "âserăți", "âseră", "usem",
+		"useși", "use", "userăm", "userăți", "useră",
+	)
+	// personSuffixes are always removed.
+	personSuffixes = toSet(
+		"ăm", "ați", "em", "eți", "im", "iți", "âm", "âți", "seși", "serăm", "serăți", "seră", "sei", "se",
+		"sesem", "seseși", "sese", "seserăm", "seserăți", "seseră",
+	)
+	vowelSuffixes = toSet("a", "e", "i", "ie", "ă")
+)
+
+func toSet(values ...string) map[string]string {
+	m := make(map[string]string, len(values))
+	for _, v := range values {
+		m[v] = ""
+	}
+
+	return m
+}
+
+func isStemVowel(r rune) bool {
+	return strings.ContainsRune("aăâeiîou", r)
+}
+
+// stemmer holds the state of the Snowball Romanian stemming algorithm.
+type stemmer struct {
+	w []rune
+	// rv, r1 and r2 are the starts of the regions of the word, as defined by the algorithm.
+	rv, r1, r2 int
+}
+
+// Stem returns the stem of the word, using the Snowball stemming algorithm for Romanian.
+// Words that are inflected forms of the same word usually have the same stem, so stems are
+// used to match words regardless of their inflection. The stem is lowercase and keeps its diacritics.
+func Stem(word string) string {
+	w := []rune(Normalize(word))
+
+	// Between vowels, "u" and "i" are consonants, which are marked by uppercasing them.
+	for i := 1; i+1 < len(w); i++ {
+		if isStemVowel(w[i-1]) && isStemVowel(w[i+1]) {
+			switch w[i] {
+			case 'u':
+				w[i] = 'U'
+			case 'i':
+				w[i] = 'I'
+			}
+		}
+	}
+
+	s := &stemmer{w: w, rv: len(w), r1: len(w), r2: len(w)}
+	s.markRegions()
+
+	s.replace(articleSuffixes, s.r1, func(suffix string, start int) bool {
+		return suffix != "ile" || start < 2 || string(s.w[start-2:start]) != "ab"
+	})
+
+	removed := false
+
+	for s.replace(comboSuffixes, s.r1, nil) != "" {
+		removed = true
+	}
+
+	switch s.replace(standardSuffixes, s.r2, func(suffix string, start int) bool {
+		return suffix != "iune" && suffix != "iuni" || start > 0 && s.w[start-1] == 'ț'
+	}) {
+	case "":
+	case "iune", "iuni":
+		// The "ț" before "iune" is replaced with "t".
+		s.w[len(s.w)-1] = 't'
+		removed = true
+	default:
+		removed = true
+	}
+
+	if !removed && !s.removeWithin(verbSuffixes, func(start int) bool {
+		return start > s.rv && (!isStemVowel(s.w[start-1]) || s.w[start-1] == 'u')
+	}) {
+		s.removeWithin(personSuffixes, nil)
+	}
+
+	s.removeWithin(vowelSuffixes, nil)
+
+	return strings.NewReplacer("I", "i", "U", "u").Replace(string(s.w))
+}
+
+func (s *stemmer) markRegions() {
+	w := s.w
+	n := len(w)
+
+	next := func(from int, vowel bool) int {
+		for i := from; i < n; i++ {
+			if isStemVowel(w[i]) == vowel {
+				return i + 1
+			}
+		}
+
+		return n
+	}
+
+	//nolint:gomnd
+	if n >= 2 {
+		switch {
+		case !isStemVowel(w[1]):
+			s.rv = next(2, true)
+			if s.rv == n && isStemVowel(w[0]) {
+				s.rv = 2
+			}
+		case isStemVowel(w[0]):
+			s.rv = next(2, false)
+		default:
+			s.rv = 3
+		}
+	}
+
+	s.r1 = next(next(0, true), false)
+	s.r2 = next(next(s.r1, true), false)
+}
+
+// longest returns the start of the longest suffix of the word that is in the set.
+func (s *stemmer) longest(suffixes map[string]string, min int) (string, int, bool) {
+	for start := min; start < len(s.w); start++ {
+		suffix := string(s.w[start:])
+		if _, ok := suffixes[suffix]; ok {
+			return suffix, start, true
+		}
+	}
+
+	return "", 0, false
+}
+
+// replace replaces the longest suffix of the word that is in the set with its replacement, if the
+// suffix is in the region starting at the given position and the condition holds. It returns the
+// replaced suffix, or an empty string if nothing was replaced.
+func (s *stemmer) replace(suffixes map[string]string, region int, cond func(string, int) bool) string {
+	suffix, start, ok := s.longest(suffixes, 0)
+	if !ok || start < region || cond != nil && !cond(suffix, start) {
+		return ""
+	}
+
+	s.w = append(s.w[:start], []rune(suffixes[suffix])...)
+
+	return suffix
+}
+
+// removeWithin removes the longest suffix in the set that is inside the RV region, if the condition holds.
+func (s *stemmer) removeWithin(suffixes map[string]string, cond func(int) bool) bool {
+	_, start, ok := s.longest(suffixes, s.rv)
+	if !ok || cond != nil && !cond(start) {
+		return false
+	}
+
+	s.w = s.w[:start]
+
+	return true
+}
@@ -0,0 +1,278 @@
+/*
+Package summary summarizes works, so readers know what a work is about before opening it.
+
+The summaries are extractive: they are made of the work's most representative sentences,
+picked using TextRank. The sentences are the nodes of a graph whose edges are weighted by
+how many words two sentences have in common, and the sentences that are the most similar to
+the others are ranked the highest. The keywords are found the same way, in the graph of the
+words that appear close to each other. Words are compared by their stems, so inflected forms
+of a word count as the same word.
+*/
+package summary
+
+import (
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/FiveIT/eseuri/server/text"
+)
+
+// Version identifies the algorithm used to compute the summaries. It must be incremented
+// whenever it changes, so summaries computed with the older algorithm are regenerated.
+const Version = 1
+
+const (
+	// minSentences and maxSentences are the number of sentences of a summary. Works with
+	// more than longWorkSentences sentences get the longer summaries.
+	minSentences      = 2
+	maxSentences      = 3
+	longWorkSentences = 20
+	// maxKeywords is the number of keywords of a work.
+	maxKeywords = 8
+	// minKeywordLength is the length under which words are not keywords.
+	minKeywordLength = 3
+	// window is the number of consecutive candidate words that are linked in the keywords' graph.
+	window = 3
+	// damping, iterations and tolerance are the parameters of the ranking algorithm.
+	damping    = 0.85
+	iterations = 50
+	tolerance  = 1e-6
+)
+
+// Summary is the summary and the keywords of a text.
+type Summary struct {
+	// Sentences are the sentences of the summary, in the order they appear in the text.
+	Sentences []string `json:"sentences"`
+	// Keywords are the most important words of the text, the most important first.
+	Keywords []string `json:"keywords"`
+}
+
+// Summarize computes the summary and the keywords of the text.
+func Summarize(s string) Summary {
+	runes := []rune(s)
+	spans := text.Sentences(s)
+
+	sentences := make([][]string, 0, len(spans))
+	for _, span := range spans {
+		sentences = append(sentences, stems(string(runes[span.Start:span.End])))
+	}
+
+	return Summary{
+		Sentences: summarize(runes, spans, sentences),
+		Keywords:  keywords(s),
+	}
+}
+
+// stems returns the stems of the sentence's words that aren't stop words.
+func stems(sentence string) []string {
+	var ret []string
+
+	for _, w := range text.Words(sentence) {
+		if !text.IsStopword(w.Text) {
+			ret = append(ret, text.Fold(text.Stem(w.Text)))
+		}
+	}
+
+	return ret
+}
+
+// similarity is the TextRank similarity of two sentences: the number of words
+// they have in common, normalized by the logarithms of their lengths.
+func similarity(a, b []string) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+
+	words := make(map[string]bool, len(a))
+	for _, w := range a {
+		words[w] = true
+	}
+
+	common := 0
+
+	for _, w := range b {
+		if words[w] {
+			common++
+			// Every common word is counted once.
+			delete(words, w)
+		}
+	}
+
+	return float64(common) / (math.Log(float64(len(a))) + math.Log(float64(len(b))))
+}
+
+// rank computes the scores of the nodes of the weighted, undirected graph given by its adjacency matrix.
+func rank(weights [][]float64) []float64 {
+	n := len(weights)
+	scores := make([]float64, n)
+	totals := make([]float64, n)
+
+	for i := range weights {
+		scores[i] = 1
+
+		for _, w := range weights[i] {
+			totals[i] += w
+		}
+	}
+
+	for it := 0; it < iterations; it++ {
+		next := make([]float64, n)
+		change := 0.0
+
+		for i := range weights {
+			sum := 0.0
+
+			for j, w := range weights[i] {
+				if w != 0 {
+					sum += w / totals[j] * scores[j]
+				}
+			}
+
+			next[i] = 1 - damping + damping*sum
+			change += math.Abs(next[i] - scores[i])
+		}
+
+		scores = next
+
+		if change < tolerance {
+			break
+		}
+	}
+
+	return scores
+}
+
+// summarize returns the highest ranked sentences.
+func summarize(runes []rune, spans []text.Span, sentences [][]string) []string {
+	count := minSentences
+	if len(sentences) > longWorkSentences {
+		count = maxSentences
+	}
+
+	if len(sentences) <= count {
+		ret := make([]string, 0, len(spans))
+		for _, span := range spans {
+			ret = append(ret, string(runes[span.Start:span.End]))
+		}
+
+		return ret
+	}
+
+	weights := make([][]float64, len(sentences))
+	for i := range weights {
+		weights[i] = make([]float64, len(sentences))
+	}
+
+	for i := range sentences {
+		for j := i + 1; j < len(sentences); j++ {
+			w := similarity(sentences[i], sentences[j])
+			weights[i][j], weights[j][i] = w, w
+		}
+	}
+
+	scores := rank(weights)
+	order := make([]int, len(sentences))
+
+	for i := range order {
+		order[i] = i
+	}
+
+	// Ties are broken in favor of the earlier sentences, which usually introduce the subject.
+	sort.SliceStable(order, func(i, j int) bool {
+		return scores[order[i]] > scores[order[j]]
+	})
+
+	order = order[:count]
+	sort.Ints(order)
+
+	ret := make([]string, 0, count)
+	for _, i := range order {
+		ret = append(ret, string(runes[spans[i].Start:spans[i].End]))
+	}
+
+	return ret
+}
+
+// keywords returns the highest ranked words of the text. Every keyword is
+// written in the form in which its stem appears the most times in the text.
+func keywords(s string) []string {
+	var (
+		candidates []string
+		ids        = make(map[string]int)
+		surface    = make(map[string]map[string]int)
+	)
+
+	for _, w := range text.Words(s) {
+		word := text.Normalize(w.Text)
+		if text.IsStopword(word) || utf8.RuneCountInString(word) < minKeywordLength {
+			continue
+		}
+
+		stem := text.Fold(text.Stem(word))
+		if _, ok := ids[stem]; !ok {
+			ids[stem] = len(ids)
+			surface[stem] = make(map[string]int)
+		}
+
+		surface[stem][word]++
+		candidates = append(candidates, stem)
+	}
+
+	if len(ids) == 0 {
+		return []string{}
+	}
+
+	weights := make([][]float64, len(ids))
+	for i := range weights {
+		weights[i] = make([]float64, len(ids))
+	}
+
+	for i, a := range candidates {
+		for j := i + 1; j < len(candidates) && j <= i+window-1; j++ {
+			if b := candidates[j]; a != b {
+				weights[ids[a]][ids[b]]++
+				weights[ids[b]][ids[a]]++
+			}
+		}
+	}
+
+	scores := rank(weights)
+	stems := make([]string, 0, len(ids))
+
+	for stem := range ids {
+		stems = append(stems, stem)
+	}
+
+	sort.Slice(stems, func(i, j int) bool {
+		a, b := scores[ids[stems[i]]], scores[ids[stems[j]]]
+		if a != b {
+			return a > b
+		}
+
+		return ids[stems[i]] < ids[stems[j]]
+	})
+
+	if len(stems) > maxKeywords {
+		stems = stems[:maxKeywords]
+	}
+
+	ret := make([]string, 0, len(stems))
+	for _, stem := range stems {
+		ret = append(ret, mostFrequent(surface[stem]))
+	}
+
+	return ret
+}
+
+func mostFrequent(counts map[string]int) string {
+	best := ""
+
+	for word, count := range counts {
+		if count > counts[best] || count == counts[best] && word < best {
+			best = word
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,78 @@
+package summary_test
+
+import (
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/text/summary"
+)
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name      string
+		Text      string
+		Sentences []string
+		// Keywords are the first keywords expected.
+		Keywords []string
+	}{
+		{
+			Name:      "Empty",
+			Text:      "",
+			Sentences: []string{},
+			Keywords:  []string{},
+		},
+		{
+			Name:      "Short text",
+			Text:      "Ion iubește pământul. Ana suferă.",
+			Sentences: []string{"Ion iubește pământul.", "Ana suferă."},
+			Keywords:  []string{"pământul", "iubește", "ana"},
+		},
+		{
+			Name: "Representative sentences",
+			Text: "Romanul „Ion” este scris de Liviu Rebreanu. " +
+				"Ion este un țăran sărac care iubește pământul. " +
+				"Vremea era frumoasă în acea zi. " +
+				"Pentru pământ, Ion se căsătorește cu Ana, fata bogată a satului. " +
+				"Lăcomia de pământ a lui Ion o distruge pe Ana. " +
+				"Câinii lătrau în depărtare.",
+			Sentences: []string{
+				"Pentru pământ, Ion se căsătorește cu Ana, fata bogată a satului.",
+				"Lăcomia de pământ a lui Ion o distruge pe Ana.",
+			},
+			Keywords: []string{"ion", "pământ", "ana"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			s := summary.Summarize(test.Text)
+
+			if !equal(s.Sentences, test.Sentences) {
+				t.Errorf("Expected sentences %q, got %q", test.Sentences, s.Sentences)
+			}
+
+			if len(s.Keywords) < len(test.Keywords) || !equal(s.Keywords[:len(test.Keywords)], test.Keywords) {
+				t.Errorf("Expected keywords %q, got %q", test.Keywords, s.Keywords)
+			}
+		})
+	}
+}
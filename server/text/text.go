@@ -0,0 +1,226 @@
+/*
+Package text splits Romanian texts into paragraphs, sentences and words, and
+normalizes words so they can be compared regardless of case and diacritics.
+
+All the offsets are rune offsets, so they can be used directly by clients,
+which index the works' content by characters.
+*/
+package text
+
+import (
+	// Used to embed the stop words.
+	_ "embed"
+	"strings"
+	"unicode"
+)
+
+// Span is a part of a text, delimited by the rune offsets [Start, End).
+type Span struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Len returns the number of runes in the span.
+func (s Span) Len() int {
+	return s.End - s.Start
+}
+
+// isWordRune reports whether the rune is part of a word. Hyphens and apostrophes
+// are not, so words like "într-o" or "s-a" are split into two words.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// Words returns the words of the text, in order.
+func Words(s string) []Span {
+	var (
+		words []Span
+		b     strings.Builder
+		start = -1
+		i     = 0
+	)
+
+	for _, r := range s {
+		if isWordRune(r) {
+			if start == -1 {
+				start = i
+			}
+
+			b.WriteRune(r)
+		} else if start != -1 {
+			words = append(words, Span{Text: b.String(), Start: start, End: i})
+			start = -1
+
+			b.Reset()
+		}
+
+		i++
+	}
+
+	if start != -1 {
+		words = append(words, Span{Text: b.String(), Start: start, End: i})
+	}
+
+	return words
+}
+
+// Paragraphs returns the non-empty lines of the text, without the surrounding whitespace.
+func Paragraphs(s string) []Span {
+	var paragraphs []Span
+
+	runes := []rune(s)
+	start := 0
+
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && runes[i] != '\n' {
+			continue
+		}
+
+		if p, ok := trim(runes, start, i); ok {
+			paragraphs = append(paragraphs, p)
+		}
+
+		start = i + 1
+	}
+
+	return paragraphs
+}
+
+// abbreviations are the common abbreviations ending in a period, which don't end sentences.
+//
+//nolint:gochecknoglobals
+var abbreviations = map[string]bool{
+	"etc": true, "ș.a": true, "dl": true, "dna": true, "dr": true, "prof": true, "pag": true,
+	"p": true, "nr": true, "cf": true, "vol": true, "sec": true, "cap": true, "ed": true, "n.n": true,
+}
+
+func isSentenceEnd(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+func isClosing(r rune) bool {
+	return r == '"' || r == '”' || r == '»' || r == ')' || r == '\''
+}
+
+// Sentences returns the sentences of the text. A sentence ends at a period, an exclamation
+// or question mark, or an ellipsis followed by whitespace, and at the end of a paragraph.
+func Sentences(s string) []Span {
+	var sentences []Span
+
+	for _, p := range Paragraphs(s) {
+		runes := []rune(p.Text)
+		start := 0
+
+		for i := 0; i < len(runes); i++ {
+			if !isSentenceEnd(runes[i]) {
+				continue
+			}
+
+			end := i + 1
+			for end < len(runes) && (isSentenceEnd(runes[end]) || isClosing(runes[end])) {
+				end++
+			}
+
+			if end < len(runes) && !unicode.IsSpace(runes[end]) || runes[i] == '.' && isAbbreviation(runes[start:i]) {
+				i = end - 1
+
+				continue
+			}
+
+			if sentence, ok := trim(runes, start, end); ok {
+				sentence.Start += p.Start
+				sentence.End += p.Start
+				sentences = append(sentences, sentence)
+			}
+
+			start, i = end, end-1
+		}
+
+		if sentence, ok := trim(runes, start, len(runes)); ok {
+			sentence.Start += p.Start
+			sentence.End += p.Start
+			sentences = append(sentences, sentence)
+		}
+	}
+
+	return sentences
+}
+
+// isAbbreviation reports whether the text before a period ends with an abbreviation.
+func isAbbreviation(before []rune) bool {
+	i := len(before)
+	for i > 0 && !unicode.IsSpace(before[i-1]) {
+		i--
+	}
+
+	word := strings.ToLower(strings.TrimLeftFunc(string(before[i:]), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}))
+
+	return abbreviations[Fold(word)] || abbreviations[word]
+}
+
+func trim(runes []rune, start, end int) (Span, bool) {
+	for start < end && unicode.IsSpace(runes[start]) {
+		start++
+	}
+
+	for end > start && unicode.IsSpace(runes[end-1]) {
+		end--
+	}
+
+	if start == end {
+		return Span{}, false
+	}
+
+	return Span{Text: string(runes[start:end]), Start: start, End: end}, true
+}
+
+//nolint:gochecknoglobals
+var folder = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+	"Ă", "A", "Â", "A", "Î", "I", "Ș", "S", "Ş", "S", "Ț", "T", "Ţ", "T",
+)
+
+// Fold removes the diacritics of the Romanian alphabet from the text.
+func Fold(s string) string {
+	return folder.Replace(s)
+}
+
+//nolint:gochecknoglobals
+var cedillas = strings.NewReplacer("ş", "ș", "ţ", "ț", "Ş", "Ș", "Ţ", "Ț")
+
+// FixCedillas replaces the cedilla forms of "ș" and "ț", which are often
+// produced by old keyboard layouts, with the correct comma forms.
+func FixCedillas(s string) string {
+	return cedillas.Replace(s)
+}
+
+// Normalize lowercases the word and fixes its cedillas.
+func Normalize(word string) string {
+	return FixCedillas(strings.ToLower(word))
+}
+
+//go:embed stopwords.txt
+var stopwordsFile string
+
+//nolint:gochecknoglobals
+var stopwords = func() map[string]bool {
+	m := make(map[string]bool)
+
+	for _, line := range strings.Split(stopwordsFile, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			m[Fold(line)] = true
+		}
+	}
+
+	return m
+}()
+
+// IsStopword reports whether the word is a common Romanian word that carries
+// little meaning by itself, such as a preposition or an auxiliary verb.
+// Diacritics are ignored, so that texts written without them are handled too.
+func IsStopword(word string) bool {
+	return stopwords[Fold(Normalize(word))]
+}
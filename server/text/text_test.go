@@ -0,0 +1,111 @@
+package text_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/text"
+)
+
+func spans(spans []text.Span) []string {
+	ret := make([]string, 0, len(spans))
+	for _, s := range spans {
+		ret = append(ret, s.Text)
+	}
+
+	return ret
+}
+
+func TestWords(t *testing.T) {
+	t.Parallel()
+
+	words := text.Words("Într-o zi, Ion s-a întâlnit cu Ana.")
+	expected := []string{"Într", "o", "zi", "Ion", "s", "a", "întâlnit", "cu", "Ana"}
+
+	if got := spans(words); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected words %q, got %q", expected, got)
+	}
+
+	if w := words[6]; w.Start != 19 || w.End != 27 {
+		t.Fatalf("Expected offsets [19, 27) for %q, got [%d, %d)", w.Text, w.Start, w.End)
+	}
+}
+
+func TestSentences(t *testing.T) {
+	t.Parallel()
+
+	s := "Romanul a apărut în 1920. Autorul, dl. Rebreanu, scrie obiectiv!\nȚăranul vrea pământ"
+	expected := []string{
+		"Romanul a apărut în 1920.",
+		"Autorul, dl. Rebreanu, scrie obiectiv!",
+		"Țăranul vrea pământ",
+	}
+
+	sentences := text.Sentences(s)
+	if got := spans(sentences); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected sentences %q, got %q", expected, got)
+	}
+
+	for _, sentence := range sentences {
+		if got := string([]rune(s)[sentence.Start:sentence.End]); got != sentence.Text {
+			t.Fatalf("Expected offsets of %q to point to it, got %q", sentence.Text, got)
+		}
+	}
+}
+
+func TestParagraphs(t *testing.T) {
+	t.Parallel()
+
+	paragraphs := text.Paragraphs("  Introducere.\n\n\tCuprins.\r\n  \nÎncheiere.")
+	expected := []string{"Introducere.", "Cuprins.", "Încheiere."}
+
+	if got := spans(paragraphs); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected paragraphs %q, got %q", expected, got)
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	t.Parallel()
+
+	if got := text.Fold("Ştiinţă și țară"); got != "Stiinta si tara" {
+		t.Fatalf("Expected folded text %q, got %q", "Stiinta si tara", got)
+	}
+
+	if got := text.Normalize("Ştiinţă"); got != "știință" {
+		t.Fatalf("Expected normalized word %q, got %q", "știință", got)
+	}
+
+	if !text.IsStopword("Și") || !text.IsStopword("pentru") || text.IsStopword("pământ") {
+		t.Fatal("Stop words are not recognized correctly")
+	}
+}
+
+func TestStem(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Words    []string
+		Expected string
+	}{
+		{Words: []string{"pământul", "pământului", "Pământ"}, Expected: "pământ"},
+		{Words: []string{"personajul", "personajului", "personaje", "personajelor"}, Expected: "personaj"},
+		{Words: []string{"țăranul", "ţărani", "țăranilor"}, Expected: "țăran"},
+		{Words: []string{"caracterizare", "caracterizarea"}, Expected: "caracteriz"},
+		{Words: []string{"abilitate", "abilități"}, Expected: "abil"},
+		{Words: []string{"națiune", "națiunii"}, Expected: "națiun"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Expected, func(t *testing.T) {
+			t.Parallel()
+
+			for _, w := range test.Words {
+				if got := text.Stem(w); got != test.Expected {
+					t.Fatalf("Expected the stem of %q to be %q, got %q", w, test.Expected, got)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"github.com/FiveIT/eseuri/server/meta"
+	"github.com/FiveIT/eseuri/server/server/config"
 	"github.com/google/go-tika/tika"
 	"github.com/machinebox/graphql"
 )
@@ -10,4 +11,5 @@ import (
 var (
 	TikaClient    = tika.NewClient(nil, meta.TikaEndpoint)
 	GraphQLClient = graphql.NewClient(meta.HasuraEndpoint + "/v1/graphql")
+	RateLimiter   = config.RateLimiter()
 )
@@ -12,7 +12,6 @@ var (
 	Panic = recover.New(recover.Config{
 		EnableStackTrace: true,
 	})
-	Logger     = logger.Middleware(GraphQLClient)
-	Auth       = auth.Middleware()
-	AuthAssert = auth.AssertRegistration(GraphQLClient)
+	Logger = logger.Middleware(GraphQLClient)
+	Guard  = auth.NewGuard(auth.DefaultConfig(), GraphQLClient)
 )
@@ -0,0 +1,212 @@
+/*
+Package workstatus implements the state machine a work goes through
+from its creation until it's published or rejected:
+
+	draft → pending → inReview → approved
+	                           → rejected → pending
+	                           → pending (the review is abandoned)
+
+Which transitions a user may do depends on their role, on whether
+they are the work's author, and on the teacher-student associations
+the author is part of. Rejected works also carry one of a fixed set
+of reasons, so their authors know what they must improve.
+*/
+package workstatus
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Status string
+
+const (
+	Draft    Status = "draft"
+	Pending  Status = "pending"
+	InReview Status = "inReview"
+	Approved Status = "approved"
+	Rejected Status = "rejected"
+)
+
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+)
+
+type RejectionReason string
+
+const (
+	OffTopic   RejectionReason = "offTopic"
+	TooShort   RejectionReason = "tooShort"
+	Plagiarism RejectionReason = "plagiarism"
+	Formatting RejectionReason = "formatting"
+	Language   RejectionReason = "language"
+)
+
+var (
+	ErrInvalidStatus          = errors.New("workstatus: invalid status")
+	ErrInvalidTransition      = errors.New("workstatus: invalid transition")
+	ErrForbidden              = errors.New("workstatus: transition not allowed for user")
+	ErrInvalidRejectionReason = errors.New("workstatus: invalid rejection reason")
+)
+
+// Parse validates the given string as a work status.
+func Parse(s string) (Status, error) {
+	switch status := Status(s); status {
+	case Draft, Pending, InReview, Approved, Rejected:
+		return status, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidStatus, s)
+	}
+}
+
+// ParseRejectionReason validates the given string as a rejection reason.
+func ParseRejectionReason(s string) (RejectionReason, error) {
+	switch reason := RejectionReason(s); reason {
+	case OffTopic, TooShort, Plagiarism, Formatting, Language:
+		return reason, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidRejectionReason, s)
+	}
+}
+
+// Actor is the user that requests a transition.
+type Actor struct {
+	ID   int
+	Role string
+}
+
+// Work holds the information about a work required to decide if a transition is allowed.
+type Work struct {
+	AuthorID int
+	// TeacherID is the teacher that was requested to review the work
+	// or that is currently reviewing it. It is 0 if there is none.
+	TeacherID int
+	Status    Status
+	// AssociatedTeachers are the teachers that have an approved association with the author.
+	AssociatedTeachers []int
+}
+
+func (w Work) isAssociatedWith(teacherID int) bool {
+	for _, id := range w.AssociatedTeachers {
+		if id == teacherID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canReview reports whether the teacher may start reviewing the work. If the author
+// requested a teacher, only that teacher may review it. Otherwise, if the author has
+// associated teachers, only they may review it, and if not, any teacher can.
+func (w Work) canReview(a Actor) bool {
+	switch {
+	case a.Role != RoleTeacher || a.ID == w.AuthorID:
+		return false
+	case w.TeacherID != 0:
+		return w.TeacherID == a.ID
+	case len(w.AssociatedTeachers) != 0:
+		return w.isAssociatedWith(a.ID)
+	default:
+		return true
+	}
+}
+
+// IsParticipant reports whether the user takes part in the work's review, and so may see
+// and write its annotations or see its grade. The participants are the author, the teacher
+// that reviews or was requested to review the work, and the teachers associated with the author.
+func (w Work) IsParticipant(a Actor) bool {
+	if a.ID == w.AuthorID {
+		return true
+	}
+
+	return a.Role == RoleTeacher && (w.TeacherID == a.ID || w.isAssociatedWith(a.ID))
+}
+
+// CanGrade reports whether the user may grade the work. Only the teacher
+// that reviews or reviewed the work may grade it.
+func (w Work) CanGrade(a Actor) bool {
+	switch w.Status {
+	case InReview, Approved, Rejected:
+		return isReviewer(w, a) && a.ID != w.AuthorID
+	default:
+		return false
+	}
+}
+
+// CanNotify reports whether the user may email the author about the outcome of the work's review.
+// Only the teacher that reviewed the work may do it, once the work was approved or rejected.
+func (w Work) CanNotify(a Actor) bool {
+	switch w.Status {
+	case Approved, Rejected:
+		return isReviewer(w, a) && a.ID != w.AuthorID
+	default:
+		return false
+	}
+}
+
+// CanTag reports whether the user may curate the work's tags. The teacher that reviews
+// or reviewed the work may tag it, and so may the teachers that wrote it.
+func (w Work) CanTag(a Actor) bool {
+	if a.Role != RoleTeacher || w.Status == Draft {
+		return false
+	}
+
+	return w.AuthorID == a.ID || w.TeacherID == a.ID && w.Status != Pending
+}
+
+type rule func(Work, Actor) bool
+
+func isAuthor(w Work, a Actor) bool {
+	return w.AuthorID == a.ID
+}
+
+func isReviewer(w Work, a Actor) bool {
+	return a.Role == RoleTeacher && w.TeacherID == a.ID
+}
+
+//nolint:gochecknoglobals
+var transitions = map[Status]map[Status]rule{
+	Draft: {
+		Pending: isAuthor,
+	},
+	Pending: {
+		InReview: Work.canReview,
+	},
+	InReview: {
+		Pending:  isReviewer,
+		Approved: isReviewer,
+		Rejected: isReviewer,
+	},
+	Rejected: {
+		Pending: isAuthor,
+	},
+}
+
+// Check returns nil if the actor is allowed to move the work to the given status.
+// It returns ErrInvalidTransition if the state machine doesn't allow moving
+// from the work's current status to the given one, and ErrForbidden if the
+// transition exists, but the actor isn't allowed to do it.
+func Check(w Work, a Actor, to Status) error {
+	allowed, ok := transitions[w.Status][to]
+	if !ok {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, w.Status, to)
+	}
+
+	if !allowed(w, a) {
+		return fmt.Errorf("%w: %s → %s", ErrForbidden, w.Status, to)
+	}
+
+	return nil
+}
+
+// Initial returns the status a newly uploaded work starts in. Works uploaded
+// by teachers don't need a review, so they are approved directly.
+func Initial(role string) Status {
+	if role == RoleTeacher {
+		return Approved
+	}
+
+	return Pending
+}
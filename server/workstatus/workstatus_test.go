@@ -0,0 +1,385 @@
+package workstatus_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/FiveIT/eseuri/server/workstatus"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	const (
+		author     = 1
+		teacher    = 2
+		associated = 3
+		other      = 4
+	)
+
+	var (
+		student         = workstatus.Actor{ID: author, Role: workstatus.RoleStudent}
+		requested       = workstatus.Actor{ID: teacher, Role: workstatus.RoleTeacher}
+		associatedActor = workstatus.Actor{ID: associated, Role: workstatus.RoleTeacher}
+		otherTeacher    = workstatus.Actor{ID: other, Role: workstatus.RoleTeacher}
+		otherStudent    = workstatus.Actor{ID: other, Role: workstatus.RoleStudent}
+	)
+
+	type testCase struct {
+		Name     string
+		Work     workstatus.Work
+		Actor    workstatus.Actor
+		To       workstatus.Status
+		Expected error
+	}
+
+	tests := []testCase{
+		{
+			Name:  "Author submits draft",
+			Work:  workstatus.Work{AuthorID: author, Status: workstatus.Draft},
+			Actor: student,
+			To:    workstatus.Pending,
+		},
+		{
+			Name:     "Other user submits draft",
+			Work:     workstatus.Work{AuthorID: author, Status: workstatus.Draft},
+			Actor:    otherStudent,
+			To:       workstatus.Pending,
+			Expected: workstatus.ErrForbidden,
+		},
+		{
+			Name:     "Draft approved directly",
+			Work:     workstatus.Work{AuthorID: author, Status: workstatus.Draft},
+			Actor:    otherTeacher,
+			To:       workstatus.Approved,
+			Expected: workstatus.ErrInvalidTransition,
+		},
+		{
+			Name:  "Any teacher reviews unassigned work",
+			Work:  workstatus.Work{AuthorID: author, Status: workstatus.Pending},
+			Actor: otherTeacher,
+			To:    workstatus.InReview,
+		},
+		{
+			Name:     "Student reviews work",
+			Work:     workstatus.Work{AuthorID: author, Status: workstatus.Pending},
+			Actor:    otherStudent,
+			To:       workstatus.InReview,
+			Expected: workstatus.ErrForbidden,
+		},
+		{
+			Name:  "Requested teacher reviews work",
+			Work:  workstatus.Work{AuthorID: author, TeacherID: teacher, Status: workstatus.Pending},
+			Actor: requested,
+			To:    workstatus.InReview,
+		},
+		{
+			Name:     "Other teacher reviews requested work",
+			Work:     workstatus.Work{AuthorID: author, TeacherID: teacher, Status: workstatus.Pending},
+			Actor:    otherTeacher,
+			To:       workstatus.InReview,
+			Expected: workstatus.ErrForbidden,
+		},
+		{
+			Name: "Associated teacher reviews work",
+			Work: workstatus.Work{
+				AuthorID:           author,
+				Status:             workstatus.Pending,
+				AssociatedTeachers: []int{associated},
+			},
+			Actor: associatedActor,
+			To:    workstatus.InReview,
+		},
+		{
+			Name: "Unassociated teacher reviews work",
+			Work: workstatus.Work{
+				AuthorID:           author,
+				Status:             workstatus.Pending,
+				AssociatedTeachers: []int{associated},
+			},
+			Actor:    otherTeacher,
+			To:       workstatus.InReview,
+			Expected: workstatus.ErrForbidden,
+		},
+		{
+			Name:  "Reviewer approves work",
+			Work:  workstatus.Work{AuthorID: author, TeacherID: teacher, Status: workstatus.InReview},
+			Actor: requested,
+			To:    workstatus.Approved,
+		},
+		{
+			Name:  "Reviewer abandons review",
+			Work:  workstatus.Work{AuthorID: author, TeacherID: teacher, Status: workstatus.InReview},
+			Actor: requested,
+			To:    workstatus.Pending,
+		},
+		{
+			Name:     "Other teacher rejects work",
+			Work:     workstatus.Work{AuthorID: author, TeacherID: teacher, Status: workstatus.InReview},
+			Actor:    otherTeacher,
+			To:       workstatus.Rejected,
+			Expected: workstatus.ErrForbidden,
+		},
+		{
+			Name:  "Author resubmits rejected work",
+			Work:  workstatus.Work{AuthorID: author, TeacherID: teacher, Status: workstatus.Rejected},
+			Actor: student,
+			To:    workstatus.Pending,
+		},
+		{
+			Name:     "Approved work is final",
+			Work:     workstatus.Work{AuthorID: author, TeacherID: teacher, Status: workstatus.Approved},
+			Actor:    requested,
+			To:       workstatus.Rejected,
+			Expected: workstatus.ErrInvalidTransition,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := workstatus.Check(test.Work, test.Actor, test.To)
+			if test.Expected == nil && err != nil || !errors.Is(err, test.Expected) {
+				t.Fatalf("Expected error %v, got %v", test.Expected, err)
+			}
+		})
+	}
+}
+
+func TestIsParticipant(t *testing.T) {
+	t.Parallel()
+
+	work := workstatus.Work{
+		AuthorID:           1,
+		TeacherID:          2,
+		Status:             workstatus.InReview,
+		AssociatedTeachers: []int{3},
+	}
+
+	type testCase struct {
+		Name     string
+		Actor    workstatus.Actor
+		Expected bool
+	}
+
+	tests := []testCase{
+		{Name: "Author", Actor: workstatus.Actor{ID: 1, Role: workstatus.RoleStudent}, Expected: true},
+		{Name: "Reviewer", Actor: workstatus.Actor{ID: 2, Role: workstatus.RoleTeacher}, Expected: true},
+		{Name: "Associated teacher", Actor: workstatus.Actor{ID: 3, Role: workstatus.RoleTeacher}, Expected: true},
+		{Name: "Other teacher", Actor: workstatus.Actor{ID: 4, Role: workstatus.RoleTeacher}, Expected: false},
+		{Name: "Other student", Actor: workstatus.Actor{ID: 3, Role: workstatus.RoleStudent}, Expected: false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := work.IsParticipant(test.Actor); got != test.Expected {
+				t.Fatalf("Expected %t, got %t", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestCanGrade(t *testing.T) {
+	t.Parallel()
+
+	reviewer := workstatus.Actor{ID: 2, Role: workstatus.RoleTeacher}
+
+	type testCase struct {
+		Name     string
+		Work     workstatus.Work
+		Actor    workstatus.Actor
+		Expected bool
+	}
+
+	tests := []testCase{
+		{
+			Name:     "Reviewer grades work in review",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.InReview},
+			Actor:    reviewer,
+			Expected: true,
+		},
+		{
+			Name:     "Reviewer grades approved work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.Approved},
+			Actor:    reviewer,
+			Expected: true,
+		},
+		{
+			Name:     "Requested teacher grades pending work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.Pending},
+			Actor:    reviewer,
+			Expected: false,
+		},
+		{
+			Name:     "Other teacher grades work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 3, Status: workstatus.InReview},
+			Actor:    reviewer,
+			Expected: false,
+		},
+		{
+			Name:     "Author grades own work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 1, Status: workstatus.Approved},
+			Actor:    workstatus.Actor{ID: 1, Role: workstatus.RoleTeacher},
+			Expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := test.Work.CanGrade(test.Actor); got != test.Expected {
+				t.Fatalf("Expected %t, got %t", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestCanNotify(t *testing.T) {
+	t.Parallel()
+
+	reviewer := workstatus.Actor{ID: 2, Role: workstatus.RoleTeacher}
+
+	type testCase struct {
+		Name     string
+		Work     workstatus.Work
+		Actor    workstatus.Actor
+		Expected bool
+	}
+
+	tests := []testCase{
+		{
+			Name:     "Reviewer notifies about approved work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.Approved},
+			Actor:    reviewer,
+			Expected: true,
+		},
+		{
+			Name:     "Reviewer notifies about rejected work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.Rejected},
+			Actor:    reviewer,
+			Expected: true,
+		},
+		{
+			Name:     "Reviewer notifies about work in review",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.InReview},
+			Actor:    reviewer,
+			Expected: false,
+		},
+		{
+			Name:     "Associated teacher notifies about approved work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 3, Status: workstatus.Approved, AssociatedTeachers: []int{2}},
+			Actor:    reviewer,
+			Expected: false,
+		},
+		{
+			Name:     "Student notifies about approved work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.Approved},
+			Actor:    workstatus.Actor{ID: 2, Role: workstatus.RoleStudent},
+			Expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := test.Work.CanNotify(test.Actor); got != test.Expected {
+				t.Fatalf("Expected %t, got %t", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestCanTag(t *testing.T) {
+	t.Parallel()
+
+	teacher := workstatus.Actor{ID: 2, Role: workstatus.RoleTeacher}
+
+	type testCase struct {
+		Name     string
+		Work     workstatus.Work
+		Actor    workstatus.Actor
+		Expected bool
+	}
+
+	tests := []testCase{
+		{
+			Name:     "Reviewer tags approved work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.Approved},
+			Actor:    teacher,
+			Expected: true,
+		},
+		{
+			Name:     "Requested teacher tags pending work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.Pending},
+			Actor:    teacher,
+			Expected: false,
+		},
+		{
+			Name:     "Teacher tags own work",
+			Work:     workstatus.Work{AuthorID: 2, Status: workstatus.Approved},
+			Actor:    teacher,
+			Expected: true,
+		},
+		{
+			Name:     "Other teacher tags work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 3, Status: workstatus.Approved},
+			Actor:    teacher,
+			Expected: false,
+		},
+		{
+			Name:     "Student tags own work",
+			Work:     workstatus.Work{AuthorID: 1, TeacherID: 2, Status: workstatus.Approved},
+			Actor:    workstatus.Actor{ID: 1, Role: workstatus.RoleStudent},
+			Expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.Name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := test.Work.CanTag(test.Actor); got != test.Expected {
+				t.Fatalf("Expected %t, got %t", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	if _, err := workstatus.Parse("inReview"); err != nil {
+		t.Fatalf("Failed to parse valid status: %v", err)
+	}
+
+	if _, err := workstatus.Parse("published"); !errors.Is(err, workstatus.ErrInvalidStatus) {
+		t.Fatalf("Expected invalid status error, got %v", err)
+	}
+}
+
+func TestParseRejectionReason(t *testing.T) {
+	t.Parallel()
+
+	if _, err := workstatus.ParseRejectionReason("tooShort"); err != nil {
+		t.Fatalf("Failed to parse valid rejection reason: %v", err)
+	}
+
+	if _, err := workstatus.ParseRejectionReason("boring"); !errors.Is(err, workstatus.ErrInvalidRejectionReason) {
+		t.Fatalf("Expected invalid rejection reason error, got %v", err)
+	}
+}
@@ -452,31 +452,7 @@ export const UNREVISED_WORK = gql<Data<UnrevisedWorkQuery>, Vars<UnrevisedWorkQu
 
 export type WorkStatus = 'draft' | 'pending' | 'inReview' | 'approved' | 'rejected'
 
-interface UpdateWorkStatusVars {
-  workID: number
-  status: WorkStatus
-}
-
-interface UpdateWorkStatusData {
-  user: Pick<FullNamer, 'first_name'> & Emailer
-}
-
-type UpdateWorkStatus = Query<
-  'update_works_by_pk',
-  UpdateWorkStatusData | null,
-  UpdateWorkStatusVars
->
-
-export const UPDATE_WORK_STATUS = gql<Data<UpdateWorkStatus>, Vars<UpdateWorkStatus>>`
-  mutation updateWorkStatus($workID: Int!, $status: work_status_enum!) {
-    update_works_by_pk(pk_columns: { id: $workID }, _set: { status: $status }) {
-      user {
-        first_name
-        email
-      }
-    }
-  }
-`
+export type RejectionReason = 'offTopic' | 'tooShort' | 'plagiarism' | 'formatting' | 'language'
 
 type County = ID<string> & Namer
 type Counties = Query<'counties', County[]>
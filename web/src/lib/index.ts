@@ -4,3 +4,4 @@ export * from './theme'
 export * from './types'
 export * from './user'
 export * from './util'
+export * from './works'
@@ -0,0 +1,65 @@
+import type { Observable } from 'rxjs'
+import { switchMap } from 'rxjs/operators'
+import { fromFetch } from 'rxjs/fetch'
+
+import type { WorkStatus, RejectionReason } from '$/graphql/queries'
+import { getHeaders, requestError } from '.'
+import type { MessagesRecord } from '.'
+
+const endpoint = `${import.meta.env.VITE_FUNCTIONS_URL}` as const
+
+export interface WorkTransition {
+  id: number
+  status: WorkStatus
+  teacherID: number | null
+  updatedAt: string | null
+  author: {
+    firstName: string | null
+    email: string | null
+  } | null
+  rejectionReason?: RejectionReason
+  feedback?: string
+}
+
+const transitionErrorMessages: MessagesRecord = {
+  400: {
+    message: 'Lucrarea nu a putut fi revizuită.',
+    explanation: 'Dacă respingi lucrarea, alege și motivul respingerii.',
+  },
+  403: {
+    message: 'Nu poți schimba starea acestei lucrări.',
+    explanation: 'Lucrarea a fost trimisă spre revizuire altui profesor.',
+  },
+  404: {
+    message: 'Lucrarea pe care dorești să o revizuiești nu există, de fapt.',
+    explanation: 'Știu, înfricoșător... Vezi altă lucrare!',
+  },
+  409: {
+    message: 'Lucrarea aceasta este în prezent revizuită de altcineva.',
+    explanation:
+      'Un alt profesor a intrat pe ea înaintea ta, iar tu ai accesat lucrarea exact înainte de a se actualiza meniul. Revizuiește altă lucrare!',
+  },
+}
+
+export function transitionWork(
+  workID: number,
+  status: WorkStatus,
+  fields: Record<string, string> = {}
+): Observable<WorkTransition> {
+  const headers = getHeaders().headers || {}
+
+  return fromFetch(`${endpoint}/works/${workID}/transition`, {
+    method: 'POST',
+    headers: { ...headers, 'Content-Type': 'application/json' },
+    body: JSON.stringify({ ...fields, status }),
+    selector: r => r.json().then(v => [v, r.ok, r.status] as const),
+  }).pipe(
+    switchMap(async ([data, ok, status]) => {
+      if (!ok) {
+        throw requestError(transitionErrorMessages, status, data.error)
+      }
+
+      return data as WorkTransition
+    })
+  )
+}